@@ -60,11 +60,10 @@ func main() {
 		log.Println("Using libvirt connector")
 	}
 
-	// start lookup and collect runners
+	// start collection cycles
 	var wg sync.WaitGroup
 	wg.Add(1) // terminate when first thread terminates
-	go runners.InitializeLookup(&wg)
-	go runners.InitializeCollect(&wg)
+	go runners.InitializeCycles(&wg, nil)
 	go profiler.InitializeProfiler(&wg)
 	wg.Wait()
 
@@ -6,12 +6,21 @@ import (
 	"fmt"
 
 	"proxtop/collectors/cpucollector"
+	"proxtop/collectors/dirtyratecollector"
 	"proxtop/collectors/diskcollector"
 	"proxtop/collectors/hostcollector"
 	"proxtop/collectors/iocollector"
+	"proxtop/collectors/irqcollector"
+	"proxtop/collectors/jobcollector"
 	"proxtop/collectors/memcollector"
+	"proxtop/collectors/migratecollector"
 	"proxtop/collectors/netcollector"
+	"proxtop/collectors/overheadcollector"
+	"proxtop/collectors/plugincollector"
+	"proxtop/collectors/powercollector"
 	"proxtop/collectors/psicollector"
+	"proxtop/collectors/tccollector"
+	"proxtop/collectors/vfiocollector"
 	"proxtop/config"
 	"proxtop/models"
 	"proxtop/printers"
@@ -68,6 +77,42 @@ func initializeFlags() {
 		enableHOST()
 		hasCollector = true
 	}
+	if config.Options.EnableTC {
+		enableTC()
+		hasCollector = true
+	}
+	if config.Options.EnableIRQ {
+		enableIRQ()
+		hasCollector = true
+	}
+	if config.Options.EnablePower {
+		enablePower()
+		hasCollector = true
+	}
+	if config.Options.EnableVFIO {
+		enableVFIO()
+		hasCollector = true
+	}
+	if config.Options.EnableMigrate {
+		enableMigrate()
+		hasCollector = true
+	}
+	if config.Options.EnableDirty {
+		enableDirtyRate()
+		hasCollector = true
+	}
+	if config.Options.EnableJobs {
+		enableJobs()
+		hasCollector = true
+	}
+	if config.Options.EnableOverhead {
+		enableOverhead()
+		hasCollector = true
+	}
+	if len(config.Options.Plugins) > 0 {
+		enablePlugins()
+		hasCollector = true
+	}
 
 	if !hasCollector {
 		// no collector selected, enable all common collectors for full functionality
@@ -78,6 +123,25 @@ func initializeFlags() {
 		enableIO()
 	}
 
+	// define computed fields, the file first so command line definitions can reference them
+	if config.Options.DeriveFile != "" {
+		if err := models.LoadDerivedFields(config.Options.DeriveFile); err != nil {
+			fmt.Printf("Error loading derived fields: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	for _, definition := range config.Options.Derive {
+		if err := models.DefineDerivedField(definition); err != nil {
+			fmt.Printf("Error defining derived field: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// the ncurses printer prints the verbose fields, hidden in its field selector
+	if err := models.CheckDerivedFields(config.Options.Verbose || config.Options.Printer == "ncurses"); err != nil {
+		fmt.Printf("Error defining derived field: %v\n", err)
+		os.Exit(1)
+	}
+
 	// select printer, ncurse as default.
 	switch config.Options.Printer {
 	case "ncurses":
@@ -89,10 +153,23 @@ func initializeFlags() {
 	case "json":
 		printer := printers.CreateJSON()
 		models.Collection.Printer = &printer
+	case "prometheus":
+		printer := printers.CreatePrometheus()
+		models.Collection.Printer = &printer
+	case "influx":
+		printer := printers.CreateInflux()
+		models.Collection.Printer = &printer
+	case "csv":
+		printer := printers.CreateCSV()
+		models.Collection.Printer = &printer
 	default:
 		fmt.Println("unknown printer")
 		os.Exit(1)
 	}
+	if config.Options.Output == "http" && config.Options.Printer != "influx" {
+		fmt.Println("output http is only supported by printer influx")
+		os.Exit(1)
+	}
 
 }
 
@@ -137,3 +214,61 @@ func enableHOST() {
 	collector := hostcollector.CreateCollector()
 	models.Collection.Collectors.Store("host", &collector)
 }
+
+// enableTC adds more tc collector
+func enableTC() {
+	collector := tccollector.CreateCollector()
+	models.Collection.Collectors.Store("tc", &collector)
+}
+
+// enableIRQ adds more irq collector
+func enableIRQ() {
+	collector := irqcollector.CreateCollector()
+	models.Collection.Collectors.Store("irq", &collector)
+}
+
+// enablePower adds more power collector
+func enablePower() {
+	collector := powercollector.CreateCollector()
+	models.Collection.Collectors.Store("power", &collector)
+}
+
+// enableVFIO adds more vfio collector
+func enableVFIO() {
+	collector := vfiocollector.CreateCollector()
+	models.Collection.Collectors.Store("vfio", &collector)
+}
+
+// enableMigrate adds more migration collector
+func enableMigrate() {
+	collector := migratecollector.CreateCollector()
+	models.Collection.Collectors.Store("migrate", &collector)
+}
+
+// enableDirtyRate adds more dirty rate collector
+func enableDirtyRate() {
+	collector := dirtyratecollector.CreateCollector()
+	models.Collection.Collectors.Store("dirtyrate", &collector)
+}
+
+// enableJobs adds more job collector
+func enableJobs() {
+	collector := jobcollector.CreateCollector()
+	models.Collection.Collectors.Store("jobs", &collector)
+}
+
+// enableOverhead adds more overhead collector
+func enableOverhead() {
+	collector := overheadcollector.CreateCollector()
+	models.Collection.Collectors.Store("overhead", &collector)
+}
+
+// enablePlugins adds more plugin collector
+func enablePlugins() {
+	collector, err := plugincollector.CreateCollector(config.Options.Plugins)
+	if err != nil {
+		fmt.Printf("Error defining plugins: %v\n", err)
+		os.Exit(1)
+	}
+	models.Collection.Collectors.Store("plugin", &collector)
+}
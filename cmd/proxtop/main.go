@@ -9,6 +9,7 @@ import (
 	"runtime/debug"
 	"syscall"
 
+	"proxtop/collectors/dirtyratecollector"
 	"proxtop/config"
 	"proxtop/connector"
 	"proxtop/models"
@@ -33,6 +34,15 @@ func main() {
 		fmt.Println("proxtop version " + version)
 		return
 	}
+	if config.Options.Schema {
+		schema, err := models.FieldSchema()
+		if err != nil {
+			fmt.Printf("Error creating schema: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(schema))
+		return
+	}
 
 	// Disable logging output when using ncurses printer to avoid corrupting the screen
 	if config.Options.Printer == "ncurses" {
@@ -48,6 +58,15 @@ func main() {
 		shutdown(0)
 	}()
 
+	// SIGUSR1 requests an on-demand dirty rate measurement
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	go func() {
+		for range usr1 {
+			dirtyratecollector.RequestMeasurement()
+		}
+	}()
+
 	// Determine which connector to use
 	// Auto-detect by default, unless explicitly specified
 	useProxmox := false
@@ -92,7 +111,9 @@ func main() {
 	}
 
 	// start runners
-	runners.InitializeRunners()
+	if err := runners.InitializeRunners(); err != nil {
+		shutdown(1)
+	}
 
 	// when runners terminate, shutdown proxtop
 	shutdown(0)
@@ -4,6 +4,7 @@ import (
 	"proxtop/config"
 	"proxtop/connector"
 	"proxtop/models"
+	"proxtop/util"
 )
 
 // Collector describes the cpu collector
@@ -13,17 +14,20 @@ type Collector struct {
 
 // Lookup cpu collector data
 func (collector *Collector) Lookup() {
-	models.Collection.Domains.Range(func(key, value interface{}) bool {
+	// vhost workers are separate kernel threads, map them to their QEMU process once
+	vhostWorkers := util.GetVhostWorkers()
+
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
 		uuid := key.(string)
 		domain := value.(models.Domain)
 		if connector.IsProxmox() {
 			vmInfo, ok := connector.ProxmoxVMStore.Load(uuid)
 			if ok {
-				cpuLookupProxmox(&domain, vmInfo)
+				cpuLookupProxmox(&domain, vmInfo, vhostWorkers)
 			}
 		} else {
 			libvirtDomain, _ := models.Collection.LibvirtDomains.Load(uuid)
-			cpuLookup(&domain, libvirtDomain)
+			cpuLookup(&domain, libvirtDomain, vhostWorkers)
 		}
 		return true
 	})
@@ -35,7 +39,7 @@ func (collector *Collector) Lookup() {
 // Collect cpu collector data
 func (collector *Collector) Collect() {
 	// lookup for each domain
-	models.Collection.Domains.Range(func(key, value interface{}) bool {
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
 		// uuid := key.(string)
 		domain := value.(models.Domain)
 		cpuCollect(&domain)
@@ -49,44 +53,10 @@ func (collector *Collector) Collect() {
 // Print returns the collectors measurements in a Printable struct
 func (collector *Collector) Print() models.Printable {
 
-	// Host fields - esxtop style naming
-	hostFields := []string{
-		"cpu_cores",
-		"cpu_curfreq",
-		"cpu_%user",
-		"cpu_%sys",
-		"cpu_%idle",
-		"cpu_%steal",
-		"cpu_%iowait",
-	}
-	if config.Options.Verbose {
-		hostFields = append(hostFields,
-			"cpu_minfreq",
-			"cpu_maxfreq",
-			"cpu_%nice",
-			"cpu_%irq",
-			"cpu_%softirq",
-			"cpu_%guest",
-			"cpu_%guestnice",
-		)
-	}
-	// Domain fields - esxtop style: %USED (cpu time), %RDY (queue/steal time)
-	// %sys = CPU time used by other threads (I/O, emulation) - like %SYS in esxtop
-	// %othrdy = queue/wait time for other threads
-	domainFields := []string{
-		"cpu_cores",
-		"cpu_%used",
-		"cpu_%rdy",
-		"cpu_%sys",
-	}
-	if config.Options.Verbose {
-		domainFields = append(domainFields,
-			"cpu_%othrdy",
-		)
-	}
+	// fields are declared in fields.go
 	printable := models.Printable{
-		HostFields:   hostFields,
-		DomainFields: domainFields,
+		HostFields:   models.FieldNames("cpu", models.ScopeHost, config.Options.Verbose),
+		DomainFields: models.FieldNames("cpu", models.ScopeDomain, config.Options.Verbose),
 	}
 
 	// lookup for each domain
@@ -95,11 +65,13 @@ func (collector *Collector) Print() models.Printable {
 		uuid := key.(string)
 		domain := value.(models.Domain)
 		printable.DomainValues[uuid] = cpuPrint(&domain)
+		printable.AddDomainDevices(uuid, models.DomainDevicesVCPU, vcpuFields, DomainPrintPerVCPU(&domain))
 		return true
 	})
 
 	// lookup for host
 	printable.HostValues = cpuPrintHost(&models.Collection.Host)
+	printable.AddDevices(models.DevicesCores, HostCoreFields(), HostPrintPerCore())
 
 	return printable
 }
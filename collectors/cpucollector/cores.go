@@ -0,0 +1,95 @@
+package cpucollector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"proxtop/config"
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// cpuCollectCores stores the /proc/stat times and the current frequency of each logical CPU
+func cpuCollectCores(host *models.Host, stats []util.ProcStatCPU) {
+	coreIDs := []int{}
+	for _, s := range stats {
+		if s.Name == "cpu" {
+			continue
+		}
+		core, err := strconv.Atoi(strings.TrimPrefix(s.Name, "cpu"))
+		if err != nil {
+			continue
+		}
+		coreIDs = append(coreIDs, core)
+
+		// guest time is already accounted in user time
+		total := s.User + s.Nice + s.System + s.Idle + s.IOWait + s.IRQ + s.SoftIRQ + s.Steal
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_user", core), models.CreateMeasurement(s.User))
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_nice", core), models.CreateMeasurement(s.Nice))
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_system", core), models.CreateMeasurement(s.System))
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_idle", core), models.CreateMeasurement(s.Idle))
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_iowait", core), models.CreateMeasurement(s.IOWait))
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_irq", core), models.CreateMeasurement(s.IRQ))
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_softirq", core), models.CreateMeasurement(s.SoftIRQ))
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_steal", core), models.CreateMeasurement(s.Steal))
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_guest", core), models.CreateMeasurement(s.Guest))
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_total", core), models.CreateMeasurement(total))
+	}
+	host.AddMetricMeasurement("cpu_coreIDs", models.CreateMeasurement(coreIDs))
+
+	// current frequency changes with load, so it is collected each cycle (kHz to MHz)
+	for _, c := range util.GetSysCPU() {
+		host.AddMetricMeasurement(fmt.Sprintf("cpu_core%d_curfreq", c.CPU), models.CreateMeasurement(uint64(c.CurFreq/1000)))
+	}
+}
+
+// HostCoreFields returns the field names for the per-core CPU view
+func HostCoreFields() []string {
+	return models.DeviceFieldNames(models.DevicesCores, config.Options.Verbose)
+}
+
+// HostPrintPerCore returns per-core CPU stats for the per-core view
+// Returns a map of core name -> []string (field values in same order as HostCoreFields)
+func HostPrintPerCore() map[string][]string {
+	host := &models.Collection.Host
+	result := make(map[string][]string)
+
+	// vCPUs that ran on each core during the last interval
+	placement := VCPUPlacement(true)
+
+	for _, core := range host.GetMetricIntArray("cpu_coreIDs") {
+		curFreq, _ := host.GetMetricUint64(fmt.Sprintf("cpu_core%d_curfreq", core), 0)
+		values := []string{
+			fmt.Sprintf("cpu%d", core),
+			curFreq,
+			corePercent(host, core, "user"),
+			corePercent(host, core, "system"),
+			corePercent(host, core, "steal"),
+			corePercent(host, core, "irq"),
+			corePercent(host, core, "softirq"),
+			corePercent(host, core, "idle"),
+			FormatVCPUPlacement(placement, core),
+		}
+		if config.Options.Verbose {
+			values = append(values,
+				corePercent(host, core, "nice"),
+				corePercent(host, core, "iowait"),
+				corePercent(host, core, "guest"),
+			)
+		}
+		result[fmt.Sprintf("cpu%d", core)] = values
+	}
+
+	return result
+}
+
+// corePercent returns the share of a core's time spent in the given state during the last interval
+func corePercent(host *models.Host, core int, state string) string {
+	total := host.GetMetricDiffUint64AsFloat(fmt.Sprintf("cpu_core%d_total", core), false)
+	if total <= 0 {
+		return ""
+	}
+	value := host.GetMetricDiffUint64AsFloat(fmt.Sprintf("cpu_core%d_%s", core, state), false)
+	return fmt.Sprintf("%.0f", value/total*100)
+}
@@ -0,0 +1,75 @@
+package cpucollector
+
+import "proxtop/models"
+
+func init() {
+	models.RegisterFields("cpu", models.ScopeHost, []models.FieldDef{
+		{Name: "cpu_cores", Kind: models.KindInfo, Description: "Logical CPUs of the host"},
+		{Name: "cpu_curfreq", Kind: models.KindGauge, Unit: models.UnitMegahertz, Description: "Mean current frequency of all CPUs"},
+		{Name: "cpu_%user", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent in user space"},
+		{Name: "cpu_%sys", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent in kernel space"},
+		{Name: "cpu_%idle", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent idle"},
+		{Name: "cpu_%steal", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time stolen by a hypervisor below the host"},
+		{Name: "cpu_%iowait", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Idle time waiting for I/O completion"},
+		{Name: "cpu_minfreq", Kind: models.KindInfo, Unit: models.UnitMegahertz, Description: "Minimum supported CPU frequency", Verbose: true},
+		{Name: "cpu_maxfreq", Kind: models.KindInfo, Unit: models.UnitMegahertz, Description: "Maximum supported CPU frequency", Verbose: true},
+		{Name: "cpu_%nice", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent in niced user processes", Verbose: true},
+		{Name: "cpu_%irq", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent handling hardware interrupts", Verbose: true},
+		{Name: "cpu_%softirq", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent handling software interrupts", Verbose: true},
+		{Name: "cpu_%guest", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent running guests", Verbose: true},
+		{Name: "cpu_%guestnice", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent running niced guests", Verbose: true},
+	})
+
+	// esxtop style: %USED (vCPU time), %RDY (run queue wait), %SYS (other QEMU threads)
+	models.RegisterFields("cpu", models.ScopeDomain, []models.FieldDef{
+		{Name: "cpu_cores", Kind: models.KindInfo, Description: "vCPUs of the VM"},
+		{Name: "cpu_%used", Kind: models.KindRate, Unit: models.UnitPercent, Description: "CPU time of the vCPU threads, average per vCPU"},
+		{Name: "cpu_%rdy", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Run queue wait of the vCPU threads, average per vCPU"},
+		{Name: "cpu_%sys", Kind: models.KindRate, Unit: models.UnitPercent, Description: "CPU time of the other threads summed: emulator, iothreads, vhost (%emu + %iothr + %vhost)"},
+		{Name: "cpu_%emu", Kind: models.KindRate, Unit: models.UnitPercent, Description: "CPU time of the QEMU emulator threads"},
+		{Name: "cpu_%iothr", Kind: models.KindRate, Unit: models.UnitPercent, Description: "CPU time of the QEMU iothreads"},
+		{Name: "cpu_%vhost", Kind: models.KindRate, Unit: models.UnitPercent, Description: "CPU time of the vhost-net workers"},
+		{Name: "cpu_%othrdy", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Run queue wait of the other threads summed", Verbose: true},
+		{Name: "cpu_%emurdy", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Run queue wait of the emulator threads", Verbose: true},
+		{Name: "cpu_%iothrrdy", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Run queue wait of the iothreads", Verbose: true},
+		{Name: "cpu_%vhostrdy", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Run queue wait of the vhost-net workers", Verbose: true},
+	})
+
+	// core view 'e', percentages are relative to the time of the single core
+	models.RegisterDeviceFields("cpu", []string{models.DevicesCores}, []models.FieldDef{
+		{Name: "cpu_CORE", Kind: models.KindInfo, Description: "Logical CPU number"},
+		{Name: "cpu_MHZ", Kind: models.KindGauge, Unit: models.UnitMegahertz, Description: "Current frequency of the core"},
+		{Name: "cpu_%USR", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent in user space, including guest time"},
+		{Name: "cpu_%SYS", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent in kernel space"},
+		{Name: "cpu_%STL", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time stolen by a hypervisor below the host"},
+		{Name: "cpu_%IRQ", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent handling hardware interrupts"},
+		{Name: "cpu_%SIRQ", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent handling software interrupts"},
+		{Name: "cpu_%IDLE", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent idle"},
+		{Name: "cpu_VCPUS", Kind: models.KindInfo, Description: "vCPUs busy during the last interval which last ran on the core, not all cores they ran on"},
+		{Name: "cpu_%NICE", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent in niced user processes", Verbose: true},
+		{Name: "cpu_%IOWAIT", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Idle time waiting for I/O completion", Verbose: true},
+		{Name: "cpu_%GUEST", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent running guests", Verbose: true},
+	})
+
+	// vCPU rows of the VMs (vcpuFields), %USED and %RDY are the VM fields of the single vCPU
+	models.RegisterDeviceFields("cpu", []string{models.DomainDeviceView(models.DomainDevicesVCPU)}, []models.FieldDef{
+		{Name: "cpu_CORE", Kind: models.KindInfo, Description: "Logical CPU the vCPU thread last ran on at collection time"},
+	})
+
+	// raw counters for exporters, /proc/stat counts USER_HZ ticks and schedstat nanoseconds
+	models.RegisterCounters("cpu", models.ScopeHost, []models.CounterDef{
+		{Metric: "cpu_user", Name: "cpu_user", BaseUnit: "seconds", Scale: 0.01, Description: "CPU time in user mode"},
+		{Metric: "cpu_nice", Name: "cpu_nice", BaseUnit: "seconds", Scale: 0.01, Description: "CPU time in user mode with low priority"},
+		{Metric: "cpu_system", Name: "cpu_system", BaseUnit: "seconds", Scale: 0.01, Description: "CPU time in kernel mode"},
+		{Metric: "cpu_idle", Name: "cpu_idle", BaseUnit: "seconds", Scale: 0.01, Description: "Idle CPU time"},
+		{Metric: "cpu_iowait", Name: "cpu_iowait", BaseUnit: "seconds", Scale: 0.01, Description: "Idle CPU time waiting for I/O completion"},
+		{Metric: "cpu_irq", Name: "cpu_irq", BaseUnit: "seconds", Scale: 0.01, Description: "CPU time servicing interrupts"},
+		{Metric: "cpu_softirq", Name: "cpu_softirq", BaseUnit: "seconds", Scale: 0.01, Description: "CPU time servicing softirqs"},
+		{Metric: "cpu_steal", Name: "cpu_steal", BaseUnit: "seconds", Scale: 0.01, Description: "CPU time stolen by the hypervisor of the host"},
+		{Metric: "cpu_guest", Name: "cpu_guest", BaseUnit: "seconds", Scale: 0.01, Description: "CPU time running guests"},
+	})
+	models.RegisterCounters("cpu", models.ScopeDomain, []models.CounterDef{
+		{Metric: "cpu_times_*", Name: "cpu_vcpu_run", BaseUnit: "seconds", Scale: 1e-9, Description: "Time the vCPU thread ran on a CPU, by thread ID"},
+		{Metric: "cpu_runqueues_*", Name: "cpu_vcpu_wait", BaseUnit: "seconds", Scale: 1e-9, Description: "Time the vCPU thread waited for a CPU, by thread ID"},
+	})
+}
@@ -1,8 +1,6 @@
 package cpucollector
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
 	"strconv"
 
@@ -57,6 +55,9 @@ func cpuCollectHost(host *models.Host) {
 	host.AddMetricMeasurement("cpu_steal", models.CreateMeasurement(maincpuStat.Steal))
 	host.AddMetricMeasurement("cpu_guest", models.CreateMeasurement(maincpuStat.Guest))
 	host.AddMetricMeasurement("cpu_guestnice", models.CreateMeasurement(maincpuStat.GuestNice))
+
+	// per-core lines for the per-core view
+	cpuCollectCores(host, stats)
 }
 
 func cpuPrintHost(host *models.Host) []string {
@@ -86,41 +87,16 @@ func cpuPrintHost(host *models.Host) []string {
 }
 
 func toPercent(host *models.Host, metricName string, cores int) string {
-	perTime := true
-	var output string
-	var percent float64
-	if metric, ok := host.GetMetric(metricName); ok {
-		if len(metric.Values) >= 2 {
-			// get first value
-			byteValue1 := metric.Values[0].Value
-			reader1 := bytes.NewReader(byteValue1)
-			decoder1 := gob.NewDecoder(reader1)
-			var value1 uint64
-			decoder1.Decode(&value1)
-
-			// get second value
-			byteValue2 := metric.Values[1].Value
-			reader2 := bytes.NewReader(byteValue2)
-			decoder2 := gob.NewDecoder(reader2)
-			var value2 uint64
-			decoder2.Decode(&value2)
-
-			// calculate value diff per time
-			value := float64(value1 - value2)
-
-			// get time diff
-			if perTime {
-				ts1 := metric.Values[0].Timestamp
-				ts2 := metric.Values[1].Timestamp
-				diffSeconds := ts1.Sub(ts2).Seconds()
-				valuePerSecond := value / 100 // since value is in Hz
-				ratio := valuePerSecond / diffSeconds
-				ratio = ratio / float64(cores)
-				percent = ratio * 100 // compute it as percent
-			}
-			output = fmt.Sprintf("%.0f", percent)
-		}
+	// calculate value diff per time
+	value, err := host.GetMetricCounterDiff(metricName, true)
+	if err == models.ErrCounterReset {
+		return models.ResetMarker
 	}
-	return output
-
+	if err != nil {
+		return ""
+	}
+	valuePerSecond := value / 100 // since value is in Hz
+	ratio := valuePerSecond / float64(cores)
+	percent := ratio * 100 // compute it as percent
+	return fmt.Sprintf("%.0f", percent)
 }
@@ -0,0 +1,76 @@
+package cpucollector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// lookupVCPUIndices stores the vCPU index for each vCPU thread (same order as cpu_threadIDs).
+// QEMU names vCPU threads "CPU <n>/KVM", the thread order is used as fallback.
+func lookupVCPUIndices(domain *models.Domain, coreThreadIDs []int) {
+	indices := make([]int, len(coreThreadIDs))
+	for i, threadID := range coreThreadIDs {
+		indices[i] = i
+		comm := util.GetProcPIDComm(threadID)
+		if strings.HasPrefix(comm, "CPU ") && strings.HasSuffix(comm, "/KVM") {
+			if index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(comm, "CPU "), "/KVM")); err == nil {
+				indices[i] = index
+			}
+		}
+	}
+	domain.AddMetricMeasurement("cpu_vcpuIndices", models.CreateMeasurement(indices))
+}
+
+// cpuCollectPlacement stores the host CPU each vCPU thread last ran on
+func cpuCollectPlacement(domain *models.Domain) {
+	for _, threadID := range domain.GetMetricIntArray("cpu_threadIDs") {
+		stat := util.GetProcPIDStat(threadID)
+		domain.AddMetricMeasurement(fmt.Sprint("cpu_lastcpu_", threadID), models.CreateMeasurement(uint64(stat.Processor)))
+	}
+}
+
+// VCPUPlacement returns the vCPUs ("<vm name>/vcpu<n>") per logical host CPU, based on the
+// CPU each vCPU thread last ran on at collection time (field 39 of /proc/<tid>/stat). A thread
+// moved between CPUs during the interval is only listed for the last one. With activeOnly set, only vCPUs that
+// consumed CPU time during the last interval are included.
+func VCPUPlacement(activeOnly bool) map[int][]string {
+	placement := make(map[int][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		domain := value.(models.Domain)
+		threadIDs := domain.GetMetricIntArray("cpu_threadIDs")
+		indices := domain.GetMetricIntArray("cpu_vcpuIndices")
+		for i, threadID := range threadIDs {
+			lastCPU, err := domain.GetMetricUint64Raw(fmt.Sprint("cpu_lastcpu_", threadID), 0)
+			if err != nil {
+				continue
+			}
+			if activeOnly && domain.GetMetricDiffUint64AsFloat(fmt.Sprint("cpu_times_", threadID), false) <= 0 {
+				continue
+			}
+			index := i
+			if i < len(indices) {
+				index = indices[i]
+			}
+			placement[int(lastCPU)] = append(placement[int(lastCPU)], fmt.Sprintf("%s/vcpu%d", domain.Name, index))
+		}
+		return true
+	})
+	for cpu := range placement {
+		sort.Strings(placement[cpu])
+	}
+	return placement
+}
+
+// FormatVCPUPlacement joins the vCPUs placed on a host CPU for display
+func FormatVCPUPlacement(placement map[int][]string, cpu int) string {
+	vcpus, ok := placement[cpu]
+	if !ok || len(vcpus) == 0 {
+		return "-"
+	}
+	return strings.Join(vcpus, ",")
+}
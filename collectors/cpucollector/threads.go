@@ -0,0 +1,66 @@
+package cpucollector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"proxtop/config"
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// lookupOtherThreads classifies the non vCPU threads of a domain into emulator,
+// iothread and vhost worker threads and stores their IDs. vhost workers are
+// kernel threads "vhost-<qemu pid>" (or QEMU tasks of that name since Linux 6.4),
+// their CPU time is part of the VM's %sys although they are no QEMU threads.
+// Returns all other thread IDs.
+func lookupOtherThreads(domain *models.Domain, coreThreadIDs []int, ioThreadIDs []int, vhostWorkers []int) []int {
+	emulatorThreadIDs := make([]int, 0)
+	iothreadIDs := make([]int, 0)
+	vhostThreadIDs := make([]int, 0)
+
+	// get thread IDs from /proc/<pid>/task
+	tasksFolder := fmt.Sprint(config.Options.ProcFS, "/", domain.PID, "/task")
+	files, _ := ioutil.ReadDir(tasksFolder)
+	for _, f := range files {
+		taskID, err := strconv.Atoi(f.Name())
+		if err != nil || containsInt(coreThreadIDs, taskID) {
+			// taskID is for vCPU core. skip.
+			continue
+		}
+		if containsInt(ioThreadIDs, taskID) {
+			iothreadIDs = append(iothreadIDs, taskID)
+		} else if util.GetVhostOwner(util.GetProcPIDComm(taskID)) == domain.PID {
+			vhostThreadIDs = append(vhostThreadIDs, taskID)
+		} else {
+			emulatorThreadIDs = append(emulatorThreadIDs, taskID)
+		}
+	}
+	for _, workerID := range vhostWorkers {
+		if !containsInt(vhostThreadIDs, workerID) {
+			vhostThreadIDs = append(vhostThreadIDs, workerID)
+		}
+	}
+
+	otherThreadIDs := make([]int, 0, len(emulatorThreadIDs)+len(iothreadIDs)+len(vhostThreadIDs))
+	otherThreadIDs = append(otherThreadIDs, emulatorThreadIDs...)
+	otherThreadIDs = append(otherThreadIDs, iothreadIDs...)
+	otherThreadIDs = append(otherThreadIDs, vhostThreadIDs...)
+
+	domain.AddMetricMeasurement("cpu_emulatorThreadIDs", models.CreateMeasurement(emulatorThreadIDs))
+	domain.AddMetricMeasurement("cpu_iothreadIDs", models.CreateMeasurement(iothreadIDs))
+	domain.AddMetricMeasurement("cpu_vhostThreadIDs", models.CreateMeasurement(vhostThreadIDs))
+	domain.AddMetricMeasurement("cpu_otherThreadIDs", models.CreateMeasurement(otherThreadIDs))
+
+	return otherThreadIDs
+}
+
+func containsInt(s []int, n int) bool {
+	for _, v := range s {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package cpucollector
+
+import (
+	"fmt"
+	"strconv"
+
+	"proxtop/models"
+)
+
+// vcpuFields are the fields of the per vCPU rows of a VM (DomainPrintPerVCPU): %USED and %RDY of the
+// single vCPU instead of the average, and the core it last ran on
+var vcpuFields = []string{"cpu_%used", "cpu_%rdy", "cpu_CORE"}
+
+// DomainPrintPerVCPU returns per-vCPU stats for a domain
+// Returns a map of vCPU index -> []string (in the order of vcpuFields)
+func DomainPrintPerVCPU(domain *models.Domain) map[string][]string {
+	result := make(map[string][]string)
+	threadIDs := domain.GetMetricIntArray("cpu_threadIDs")
+	indices := domain.GetMetricIntArray("cpu_vcpuIndices")
+
+	for i, threadID := range threadIDs {
+		index := i
+		if i < len(indices) {
+			index = indices[i]
+		}
+		// counters are nanoseconds, per second of the interval in percent
+		used := domain.GetMetricDiffUint64AsFloat(fmt.Sprint("cpu_times_", threadID), true) / 10000000
+		ready := domain.GetMetricDiffUint64AsFloat(fmt.Sprint("cpu_runqueues_", threadID), true) / 10000000
+		core, err := domain.GetMetricUint64(fmt.Sprint("cpu_lastcpu_", threadID), 0)
+		if err != nil {
+			core = "-"
+		}
+		result[strconv.Itoa(index)] = []string{fmt.Sprintf("%.0f", used), fmt.Sprintf("%.0f", ready), core}
+	}
+	return result
+}
@@ -1,10 +1,10 @@
 package cpucollector
 
 import (
-	"path"
-	"path/filepath"
+	"encoding/json"
 	"regexp"
 	"strconv"
+	"time"
 
 	"fmt"
 
@@ -15,9 +15,11 @@ import (
 	libvirt "github.com/libvirt/libvirt-go"
 )
 
-func cpuLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
+func cpuLookup(domain *models.Domain, libvirtDomain libvirt.Domain, vhostWorkers map[int][]int) {
 	// get amount of cores
+	start := time.Now()
 	vcpus, err := libvirtDomain.GetVcpus()
+	models.RecordCall(models.CallLibvirt, start, err)
 	if err != nil {
 		return
 	}
@@ -31,7 +33,9 @@ func cpuLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
 	oldThreadIds = append(oldThreadIds, domain.GetMetricIntArray("cpu_otherThreadIDs")...)
 
 	// get core thread IDs
+	start = time.Now()
 	vCPUThreads, err := libvirtDomain.QemuMonitorCommand("info cpus", libvirt.DOMAIN_QEMU_MONITOR_COMMAND_HMP)
+	models.RecordCall(models.CallLibvirt, start, err)
 	if err != nil {
 		return
 	}
@@ -45,40 +49,34 @@ func cpuLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
 	}
 	newMeasurementThreads := models.CreateMeasurement(coreThreadIDs)
 	domain.AddMetricMeasurement("cpu_threadIDs", newMeasurementThreads)
-
-	// get thread IDs
-	tasksFolder := fmt.Sprint(config.Options.ProcFS, "/", domain.PID, "/task/*")
-	files, err := filepath.Glob(tasksFolder)
-	if err != nil {
-		return
-	}
-	otherThreadIDs := make([]int, 0)
-	i := 0
-	for _, f := range files {
-		taskID, _ := strconv.Atoi(path.Base(f))
-		found := false
-		for _, n := range coreThreadIDs {
-			if taskID == n {
-				// taskID is for vCPU core. skip.
-				found = true
-				break
-			}
+	lookupVCPUIndices(domain, coreThreadIDs)
+
+	// get iothread IDs via QMP
+	var ioThreadIDs []int
+	start = time.Now()
+	ioThreadsRaw, err := libvirtDomain.QemuMonitorCommand(`{"execute": "query-iothreads"}`, libvirt.DOMAIN_QEMU_MONITOR_COMMAND_DEFAULT)
+	models.RecordCall(models.CallLibvirt, start, err)
+	if err == nil {
+		var ioThreads struct {
+			Return []connector.QMPIOThread `json:"return"`
 		}
-		if found {
-			// taskID is for vCPU core. skip.
-			continue
+		json.Unmarshal([]byte(ioThreadsRaw), &ioThreads)
+		for _, ioThread := range ioThreads.Return {
+			ioThreadIDs = append(ioThreadIDs, ioThread.ThreadID)
 		}
-		// taskID is not for a vCPU core
-		otherThreadIDs = append(otherThreadIDs, taskID)
-		oldThreadIds = removeFromArray(oldThreadIds, taskID)
-		i++
 	}
-	domain.AddMetricMeasurement("cpu_otherThreadIDs", models.CreateMeasurement(otherThreadIDs))
+
+	// get other thread IDs (emulator, iothreads, vhost workers)
+	otherThreadIDs := lookupOtherThreads(domain, coreThreadIDs, ioThreadIDs, vhostWorkers[domain.PID])
+	for _, threadID := range otherThreadIDs {
+		oldThreadIds = removeFromArray(oldThreadIds, threadID)
+	}
 
 	// remove cached but not existent thread IDs
 	for _, id := range oldThreadIds {
 		domain.DelMetricMeasurement(fmt.Sprint("cpu_times_", id))
 		domain.DelMetricMeasurement(fmt.Sprint("cpu_runqueues_", id))
+		domain.DelMetricMeasurement(fmt.Sprint("cpu_lastcpu_", id))
 		domain.DelMetricMeasurement(fmt.Sprint("cpu_other_times_", id))
 		domain.DelMetricMeasurement(fmt.Sprint("cpu_other_runqueues_", id))
 	}
@@ -87,6 +85,7 @@ func cpuLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
 func cpuCollect(domain *models.Domain) {
 	// PART A: stats for VCORES from threadIDs
 	cpuCollectMeasurements(domain, "cpu_threadIDs", "cpu_")
+	cpuCollectPlacement(domain)
 	// PART B: stats for other threads (i/o or emulation)
 	cpuCollectMeasurements(domain, "cpu_otherThreadIDs", "cpu_other_")
 }
@@ -108,19 +107,48 @@ func cpuPrint(domain *models.Domain) []string {
 	// queue time is similar to %RDY (ready/steal time)
 	queuetimeAllCores := CpuPrintThreadMetric(domain, "cpu_threadIDs", "cpu_runqueues")
 
-	// cpu util for other threads (i/o or emulation) - similar to %SYS in esxtop
-	otherCputimeAllCores := CpuPrintThreadMetric(domain, "cpu_otherThreadIDs", "cpu_other_times")
-	otherQueuetimeAllCores := CpuPrintThreadMetric(domain, "cpu_otherThreadIDs", "cpu_other_runqueues")
+	// cpu util for other threads (emulator, iothreads, vhost workers) - similar to %SYS in esxtop,
+	// summed over the threads like the system worlds of esxtop
+	otherCputimeAllCores := CpuPrintThreadMetricSum(domain, "cpu_otherThreadIDs", "cpu_other_times")
+	otherQueuetimeAllCores := CpuPrintThreadMetricSum(domain, "cpu_otherThreadIDs", "cpu_other_runqueues")
+
+	// other threads split into emulator, iothreads and vhost workers, adding up to %sys
+	emuCputime := CpuPrintThreadMetricSum(domain, "cpu_emulatorThreadIDs", "cpu_other_times")
+	ioThreadCputime := CpuPrintThreadMetricSum(domain, "cpu_iothreadIDs", "cpu_other_times")
+	vhostCputime := CpuPrintThreadMetricSum(domain, "cpu_vhostThreadIDs", "cpu_other_times")
+	emuQueuetime := CpuPrintThreadMetricSum(domain, "cpu_emulatorThreadIDs", "cpu_other_runqueues")
+	ioThreadQueuetime := CpuPrintThreadMetricSum(domain, "cpu_iothreadIDs", "cpu_other_runqueues")
+	vhostQueuetime := CpuPrintThreadMetricSum(domain, "cpu_vhostThreadIDs", "cpu_other_runqueues")
 
 	// put results together - include %sys (other threads) by default (esxtop style)
-	result := append([]string{cores}, cputimeAllCores, queuetimeAllCores, otherCputimeAllCores)
+	result := append([]string{cores}, cputimeAllCores, queuetimeAllCores, otherCputimeAllCores, emuCputime, ioThreadCputime, vhostCputime)
 	if config.Options.Verbose {
-		result = append(result, otherQueuetimeAllCores)
+		result = append(result, otherQueuetimeAllCores, emuQueuetime, ioThreadQueuetime, vhostQueuetime)
 	}
 	return result
 }
 
 func CpuPrintThreadMetric(domain *models.Domain, lookupMetric string, metric string) string {
+	measurementSum, measurementCount := threadMetricSeconds(domain, lookupMetric, metric)
+
+	var avg float64
+	if measurementCount > 0 {
+		avg = float64(measurementSum) / float64(measurementCount)
+	}
+	percent := avg * 100
+	return fmt.Sprintf("%.0f", percent)
+}
+
+// CpuPrintThreadMetricSum returns the sum over the threads instead of the average, in percent of one CPU,
+// so the sums of disjoint thread classes add up to the sum of all of them
+func CpuPrintThreadMetricSum(domain *models.Domain, lookupMetric string, metric string) string {
+	measurementSum, _ := threadMetricSeconds(domain, lookupMetric, metric)
+	return fmt.Sprintf("%.0f", measurementSum*100)
+}
+
+// threadMetricSeconds returns the per second sum of a nanosecond counter over the threads of lookupMetric
+// and the number of threads measured
+func threadMetricSeconds(domain *models.Domain, lookupMetric string, metric string) (float64, int) {
 	threadIDs := domain.GetMetricIntArray(lookupMetric)
 	var measurementSum float64
 	var measurementCount int
@@ -138,13 +166,7 @@ func CpuPrintThreadMetric(domain *models.Domain, lookupMetric string, metric str
 		measurementSum += measurementSeconds
 		measurementCount++
 	}
-
-	var avg float64
-	if measurementCount > 0 {
-		avg = float64(measurementSum) / float64(measurementCount)
-	}
-	percent := avg * 100
-	return fmt.Sprintf("%.0f", percent)
+	return measurementSum, measurementCount
 }
 
 func removeFromArray(s []int, r int) []int {
@@ -157,7 +179,7 @@ func removeFromArray(s []int, r int) []int {
 }
 
 // cpuLookupProxmox handles CPU lookup for Proxmox VMs
-func cpuLookupProxmox(domain *models.Domain, vmInfo connector.VMInfo) {
+func cpuLookupProxmox(domain *models.Domain, vmInfo connector.VMInfo, vhostWorkers map[int][]int) {
 	// Get cores from VM info or config
 	var cores int
 	if vmInfo.Cores > 0 {
@@ -198,35 +220,30 @@ func cpuLookupProxmox(domain *models.Domain, vmInfo connector.VMInfo) {
 	}
 	newMeasurementThreads := models.CreateMeasurement(coreThreadIDs)
 	domain.AddMetricMeasurement("cpu_threadIDs", newMeasurementThreads)
+	lookupVCPUIndices(domain, coreThreadIDs)
 
-	// get other thread IDs from /proc/<pid>/task
-	tasksFolder := fmt.Sprint(config.Options.ProcFS, "/", domain.PID, "/task/*")
-	files, err := filepath.Glob(tasksFolder)
-	if err != nil {
-		return
-	}
-	otherThreadIDs := make([]int, 0)
-	for _, f := range files {
-		taskID, _ := strconv.Atoi(path.Base(f))
-		found := false
-		for _, n := range coreThreadIDs {
-			if taskID == n {
-				found = true
-				break
+	// get iothread IDs via QMP
+	var ioThreadIDs []int
+	if ok {
+		ioThreads, err := proxmoxConn.GetIOThreads(vmInfo)
+		if err == nil {
+			for _, ioThread := range ioThreads {
+				ioThreadIDs = append(ioThreadIDs, ioThread.ThreadID)
 			}
 		}
-		if found {
-			continue
-		}
-		otherThreadIDs = append(otherThreadIDs, taskID)
-		oldThreadIds = removeFromArray(oldThreadIds, taskID)
 	}
-	domain.AddMetricMeasurement("cpu_otherThreadIDs", models.CreateMeasurement(otherThreadIDs))
+
+	// get other thread IDs (emulator, iothreads, vhost workers)
+	otherThreadIDs := lookupOtherThreads(domain, coreThreadIDs, ioThreadIDs, vhostWorkers[domain.PID])
+	for _, threadID := range otherThreadIDs {
+		oldThreadIds = removeFromArray(oldThreadIds, threadID)
+	}
 
 	// remove cached but not existent thread IDs
 	for _, id := range oldThreadIds {
 		domain.DelMetricMeasurement(fmt.Sprint("cpu_times_", id))
 		domain.DelMetricMeasurement(fmt.Sprint("cpu_runqueues_", id))
+		domain.DelMetricMeasurement(fmt.Sprint("cpu_lastcpu_", id))
 		domain.DelMetricMeasurement(fmt.Sprint("cpu_other_times_", id))
 		domain.DelMetricMeasurement(fmt.Sprint("cpu_other_runqueues_", id))
 	}
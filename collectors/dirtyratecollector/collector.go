@@ -0,0 +1,67 @@
+package dirtyratecollector
+
+import (
+	"time"
+
+	"proxtop/config"
+	"proxtop/connector"
+	"proxtop/models"
+)
+
+// Collector describes the dirty page rate collector
+type Collector struct {
+	models.Collector
+}
+
+// Lookup dirty rate collector data
+func (collector *Collector) Lookup() {
+	// forget measurements of VMs which are gone
+	forgetVanishedStates()
+}
+
+// Collect dirty rate collector data
+func (collector *Collector) Collect() {
+	scheduler := newScheduler(time.Now())
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		if connector.IsProxmox() {
+			vmInfo, ok := connector.ProxmoxVMStore.Load(uuid)
+			proxmoxConn, isProxmox := connector.CurrentConnector.(*connector.ProxmoxConnector)
+			if ok && isProxmox {
+				domainCollect(&domain, proxmoxConn.QMPExecutor(vmInfo.VMID), scheduler)
+			}
+		} else {
+			libvirtDomain, ok := models.Collection.LibvirtDomains.Load(uuid)
+			if ok {
+				domainCollect(&domain, connector.LibvirtQMPExecutor(libvirtDomain), scheduler)
+			}
+		}
+		return true
+	})
+}
+
+// Print returns the collectors measurements in a Printable struct
+func (collector *Collector) Print() models.Printable {
+	// fields are declared in fields.go
+	printable := models.Printable{
+		HostFields:   []string{},
+		DomainFields: models.FieldNames("dirtyrate", models.ScopeDomain, config.Options.Verbose),
+	}
+
+	// lookup for each domain
+	printable.DomainValues = make(map[string][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		printable.DomainValues[uuid] = domainPrint(&domain)
+		return true
+	})
+
+	return printable
+}
+
+// CreateCollector creates a new dirty rate collector
+func CreateCollector() Collector {
+	return Collector{}
+}
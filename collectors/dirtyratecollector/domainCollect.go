@@ -0,0 +1,180 @@
+package dirtyratecollector
+
+import (
+	"sync"
+	"time"
+
+	"proxtop/config"
+	"proxtop/connector"
+	"proxtop/models"
+)
+
+// pages sampled per GiB of guest memory (QEMU default)
+const dirtyRateSamplePages = 512
+
+// minimum time between two measurements of a VM, also for on-demand requests
+const dirtyRateMinGap = 30 * time.Second
+
+// maximum number of measurements running at the same time
+const dirtyRateMaxParallel = 2
+
+// a measurement without result this long after its window ended is given up
+const dirtyRateTimeout = 60 * time.Second
+
+// dirtyRateState is the measurement state of a VM
+type dirtyRateState struct {
+	measuring bool
+	failed    bool
+	started   time.Time
+	window    int64 // seconds
+	rate      int64 // MB/s
+	measured  time.Time
+}
+
+// collectMu serializes collect cycles, states are only used within
+var collectMu sync.Mutex
+var states = make(map[string]*dirtyRateState)
+
+var requestedMu sync.Mutex
+var requested time.Time
+
+// RequestMeasurement requests a measurement of all VMs, started with the next collect
+// cycles as far as the rate limits allow
+func RequestMeasurement() {
+	requestedMu.Lock()
+	requested = time.Now()
+	requestedMu.Unlock()
+}
+
+// scheduler decides which VMs start a measurement in a collect cycle
+type scheduler struct {
+	now       time.Time
+	requested time.Time
+	// running counts the measurements of all VMs, not only of those visited in the cycle so far
+	running int
+}
+
+// newScheduler returns the scheduler of a collect cycle, counting the measurements running before any VM is visited
+func newScheduler(now time.Time) *scheduler {
+	requestedMu.Lock()
+	newScheduler := &scheduler{now: now, requested: requested}
+	requestedMu.Unlock()
+
+	collectMu.Lock()
+	defer collectMu.Unlock()
+	for _, state := range states {
+		if state.measuring {
+			newScheduler.running++
+		}
+	}
+	return newScheduler
+}
+
+// window returns the configured measurement window, QEMU accepts 1 to 60 seconds
+func window() int64 {
+	window := int64(config.Options.DirtyRateWindow)
+	if window < 1 {
+		return 1
+	}
+	if window > 60 {
+		return 60
+	}
+	return window
+}
+
+// due returns true if a measurement of the VM should start now
+func (s *scheduler) due(state *dirtyRateState) bool {
+	if state.measuring || s.running >= dirtyRateMaxParallel {
+		return false
+	}
+	if !state.started.IsZero() {
+		gap := dirtyRateMinGap
+		if minGap := time.Duration(2*window()) * time.Second; minGap > gap {
+			gap = minGap
+		}
+		if s.now.Sub(state.started) < gap {
+			return false
+		}
+	}
+	// on-demand request
+	if state.started.Before(s.requested) {
+		return true
+	}
+	// periodic measurement, interval 0 measures on demand only
+	interval := time.Duration(config.Options.DirtyRateInterval) * time.Second
+	if interval <= 0 {
+		return false
+	}
+	return state.started.IsZero() || s.now.Sub(state.started) >= interval
+}
+
+func domainCollect(domain *models.Domain, execute connector.QMPExecutor, scheduler *scheduler) {
+	collectMu.Lock()
+	defer collectMu.Unlock()
+
+	state, ok := states[domain.UUID]
+	if !ok {
+		state = &dirtyRateState{}
+		states[domain.UUID] = state
+	}
+	windowEnd := state.started.Add(time.Duration(state.window) * time.Second)
+	if state.measuring && !scheduler.now.Before(windowEnd) {
+		result, err := connector.GetDirtyRate(execute)
+		if err == nil && result.Status == "measured" {
+			state.rate = result.DirtyRate
+			state.measured = scheduler.now
+			state.measuring = false
+			state.failed = false
+			scheduler.running--
+		} else if err != nil || scheduler.now.Sub(windowEnd) > dirtyRateTimeout {
+			state.measuring = false
+			state.failed = true
+			scheduler.running--
+		}
+	} else if scheduler.due(state) {
+		state.started = scheduler.now
+		state.window = window()
+		if err := connector.StartDirtyRate(execute, state.window, dirtyRateSamplePages); err != nil {
+			state.failed = true
+		} else {
+			state.measuring = true
+			scheduler.running++
+		}
+	}
+
+	storeState(domain, state)
+}
+
+// storeState stores the last result and the measurement state of a VM
+func storeState(domain *models.Domain, state *dirtyRateState) {
+	status := "-"
+	switch {
+	case state.measuring:
+		status = "measuring"
+	case state.failed:
+		status = "failed"
+	case !state.measured.IsZero():
+		status = "measured"
+	}
+	var measured uint64
+	var rate uint64
+	if !state.measured.IsZero() {
+		measured = uint64(state.measured.Unix())
+		rate = uint64(state.rate)
+	}
+	domain.AddMetricMeasurement("dirty_status", models.CreateMeasurement(status))
+	domain.AddMetricMeasurement("dirty_rate", models.CreateMeasurement(rate))
+	domain.AddMetricMeasurement("dirty_window", models.CreateMeasurement(uint64(state.window)))
+	domain.AddMetricMeasurement("dirty_measured", models.CreateMeasurement(measured))
+}
+
+// forgetVanishedStates removes the states of VMs which are gone
+func forgetVanishedStates() {
+	collectMu.Lock()
+	defer collectMu.Unlock()
+	for uuid := range states {
+		if _, ok := models.Collection.Domains.Load(uuid); !ok {
+			delete(states, uuid)
+		}
+	}
+}
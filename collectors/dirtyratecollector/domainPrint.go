@@ -0,0 +1,33 @@
+package dirtyratecollector
+
+import (
+	"fmt"
+	"time"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+func domainPrint(domain *models.Domain) []string {
+	rate, window, age := "-", "-", "-"
+	if measured, _ := domain.GetMetricUint64Raw("dirty_measured", 0); measured > 0 {
+		rate, _ = domain.GetMetricUint64("dirty_rate", 0)
+		windowSeconds, _ := domain.GetMetricUint64Raw("dirty_window", 0)
+		window = fmt.Sprintf("%ds", windowSeconds)
+		if now := uint64(time.Now().Unix()); now > measured {
+			age = fmt.Sprintf("%d", now-measured)
+		} else {
+			age = "0"
+		}
+	}
+
+	result := []string{rate, window}
+	if config.Options.Verbose {
+		status := domain.GetMetricString("dirty_status", 0)
+		if status == "" {
+			status = "-"
+		}
+		result = append(result, status, age)
+	}
+	return result
+}
@@ -0,0 +1,12 @@
+package dirtyratecollector
+
+import "proxtop/models"
+
+func init() {
+	models.RegisterFields("dirtyrate", models.ScopeDomain, []models.FieldDef{
+		{Name: "dirty_MB/s", Kind: models.KindRate, Unit: models.UnitMBPerSecond, Description: "Memory dirtied by the guest, - before the first result"},
+		{Name: "dirty_WIN", Kind: models.KindInfo, Unit: models.UnitSeconds, Description: "Measurement window"},
+		{Name: "dirty_STATE", Kind: models.KindInfo, Description: "Measurement state: measuring, measured, failed or -", Verbose: true},
+		{Name: "dirty_AGE", Kind: models.KindGauge, Unit: models.UnitSeconds, Description: "Time since the last result", Verbose: true},
+	})
+}
@@ -17,7 +17,7 @@ type Collector struct {
 func (collector *Collector) Lookup() {
 	hostDiskSources := ""
 
-	models.Collection.Domains.Range(func(key, value interface{}) bool {
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
 		uuid := key.(string)
 		domain := value.(models.Domain)
 
@@ -53,7 +53,7 @@ func (collector *Collector) Lookup() {
 // Collect disk collector data
 func (collector *Collector) Collect() {
 	// lookup for each domain
-	models.Collection.Domains.Range(func(key, value interface{}) bool {
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
 		// uuid := key.(string)
 		domain := value.(models.Domain)
 		diskCollect(&domain, &models.Collection.Host)
@@ -64,64 +64,10 @@ func (collector *Collector) Collect() {
 
 // Print returns the collectors measurements in a Printable struct
 func (collector *Collector) Print() models.Printable {
-	// Host fields - esxtop style with key storage metrics
-	// %UTIL = device busy percentage (time spent doing I/Os)
-	// QDEPTH = current I/O queue depth
-	// QLEN = average queue length
-	// SVCTM = average service time per I/O (ms)
-	// AWAIT = average wait time per I/O (ms)
-	hostFields := []string{
-		"dsk_READS/s",
-		"dsk_WRITES/s",
-		"dsk_MBRD/s",
-		"dsk_MBWR/s",
-		"dsk_%UTIL",
-		"dsk_QDEPTH",
-		"dsk_QLEN",
-		"dsk_SVCTM",
-		"dsk_AWAIT",
-	}
-	// Domain fields - esxtop style with latency breakout
-	// LAT/rd, LAT/wr, LAT/fl = average latency for read/write/flush operations (ms)
-	// LAT/avg = overall average latency combining all operation types (ms)
-	domainFields := []string{
-		"dsk_SIZE",
-		"dsk_ALLOC",
-		"dsk_%UTIL",
-		"dsk_READS/s",
-		"dsk_WRITES/s",
-		"dsk_MBRD/s",
-		"dsk_MBWR/s",
-		"dsk_LAT/rd",
-		"dsk_LAT/wr",
-		"dsk_LAT/fl",
-		"dsk_LAT/avg",
-	}
-	if config.Options.Verbose {
-		hostFields = append(hostFields,
-			"dsk_rdmerged",
-			"dsk_sectorsrd",
-			"dsk_timerd",
-			"dsk_wrmerged",
-			"dsk_sectorswr",
-			"dsk_timewr",
-			"dsk_timeforops",
-			"dsk_weightedtime",
-			"dsk_count",
-		)
-		// Verbose adds: physical size, flush ops/s, and total time breakdown (rd/wr/flush in ms)
-		domainFields = append(domainFields,
-			"dsk_PHYSICAL",
-			"dsk_FLUSH/s",
-			"dsk_RDTM",    // Total read time (ms)
-			"dsk_WRTM",    // Total write time (ms)
-			"dsk_FLTM",    // Total flush time (ms)
-			"dsk_BLKIO",
-		)
-	}
+	// fields are declared in fields.go
 	printable := models.Printable{
-		HostFields:   hostFields,
-		DomainFields: domainFields,
+		HostFields:   models.FieldNames("disk", models.ScopeHost, config.Options.Verbose),
+		DomainFields: models.FieldNames("disk", models.ScopeDomain, config.Options.Verbose),
 	}
 
 	// lookup for each domain
@@ -130,11 +76,16 @@ func (collector *Collector) Print() models.Printable {
 		uuid := key.(string)
 		domain := value.(models.Domain)
 		printable.DomainValues[uuid] = diskPrint(&domain)
+		printable.AddDomainDevices(uuid, models.DomainDevicesDisk, diskPerDeviceFields, DiskPrintPerDevice(&domain))
 		return true
 	})
 
 	// lookup for host
 	printable.HostValues = diskPrintHost(&models.Collection.Host)
+	categorized := HostPrintPerDeviceCategorized()
+	printable.AddDevices(models.DevicesDisk, HostDiskFields(), categorized.Physical)
+	printable.AddDevices(models.DevicesLVM, HostDiskFields(), categorized.LVM)
+	printable.AddDevices(models.DevicesMpath, HostDiskFields(), categorized.Mpath)
 
 	return printable
 }
@@ -0,0 +1,95 @@
+package diskcollector
+
+import "proxtop/models"
+
+func init() {
+	// esxtop style: %UTIL (device busy), QDEPTH (I/Os in flight), QLEN (average queue), SVCTM and AWAIT per I/O
+	models.RegisterFields("disk", models.ScopeHost, []models.FieldDef{
+		{Name: "dsk_READS/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Read requests of the storage device"},
+		{Name: "dsk_WRITES/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Write requests of the storage device"},
+		{Name: "dsk_MBRD/s", Kind: models.KindRate, Unit: models.UnitMBPerSecond, Description: "Read throughput of the storage device"},
+		{Name: "dsk_MBWR/s", Kind: models.KindRate, Unit: models.UnitMBPerSecond, Description: "Write throughput of the storage device"},
+		{Name: "dsk_%UTIL", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time the storage device was busy"},
+		{Name: "dsk_QDEPTH", Kind: models.KindGauge, Description: "I/Os in flight on the storage device"},
+		{Name: "dsk_QLEN", Kind: models.KindGauge, Description: "Average queue length of the storage device"},
+		{Name: "dsk_SVCTM", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Average service time per I/O"},
+		{Name: "dsk_AWAIT", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Average wait time per I/O, including queueing"},
+		{Name: "dsk_rdmerged", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Merged read requests", Verbose: true},
+		{Name: "dsk_sectorsrd", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Sectors read", Verbose: true},
+		{Name: "dsk_timerd", Kind: models.KindRate, Unit: models.UnitMilliseconds, Description: "Time spent reading per second", Verbose: true},
+		{Name: "dsk_wrmerged", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Merged write requests", Verbose: true},
+		{Name: "dsk_sectorswr", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Sectors written", Verbose: true},
+		{Name: "dsk_timewr", Kind: models.KindRate, Unit: models.UnitMilliseconds, Description: "Time spent writing per second", Verbose: true},
+		{Name: "dsk_timeforops", Kind: models.KindRate, Unit: models.UnitMilliseconds, Description: "Time spent doing I/O per second", Verbose: true},
+		{Name: "dsk_weightedtime", Kind: models.KindRate, Unit: models.UnitMilliseconds, Description: "Time spent doing I/O weighted by I/Os in flight, per second", Verbose: true},
+		{Name: "dsk_count", Kind: models.KindGauge, Description: "I/Os in flight", Verbose: true},
+	})
+
+	// esxtop style latency breakout: LAT/rd, LAT/wr, LAT/fl and LAT/avg per operation
+	models.RegisterFields("disk", models.ScopeDomain, []models.FieldDef{
+		{Name: "dsk_SIZE", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Capacity of the virtual disks"},
+		{Name: "dsk_ALLOC", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Allocated size of the virtual disks"},
+		{Name: "dsk_%UTIL", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Estimated share of the storage device busy time caused by the VM"},
+		{Name: "dsk_READS/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Read requests of the VM"},
+		{Name: "dsk_WRITES/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Write requests of the VM"},
+		{Name: "dsk_MBRD/s", Kind: models.KindRate, Unit: models.UnitMBPerSecond, Description: "Read throughput of the VM"},
+		{Name: "dsk_MBWR/s", Kind: models.KindRate, Unit: models.UnitMBPerSecond, Description: "Write throughput of the VM"},
+		{Name: "dsk_LAT/rd", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Average read latency"},
+		{Name: "dsk_LAT/wr", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Average write latency"},
+		{Name: "dsk_LAT/fl", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Average flush latency"},
+		{Name: "dsk_LAT/avg", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Average latency of all operations"},
+		{Name: "dsk_PHYSICAL", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Physical size of the disk images", Verbose: true},
+		{Name: "dsk_FLUSH/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Flush requests of the VM", Verbose: true},
+		{Name: "dsk_RDTM", Kind: models.KindRate, Unit: models.UnitMilliseconds, Description: "Time spent reading per second", Verbose: true},
+		{Name: "dsk_WRTM", Kind: models.KindRate, Unit: models.UnitMilliseconds, Description: "Time spent writing per second", Verbose: true},
+		{Name: "dsk_FLTM", Kind: models.KindRate, Unit: models.UnitMilliseconds, Description: "Time spent flushing per second", Verbose: true},
+		{Name: "dsk_BLKIO", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Block I/O delay of the QEMU process in clock ticks", Verbose: true},
+	})
+
+	// physical disk, LVM and multipath views 's', 'l', 'x'
+	models.RegisterDeviceFields("disk", []string{models.DevicesDisk, models.DevicesLVM, models.DevicesMpath}, []models.FieldDef{
+		{Name: "dsk_DEVICE", Kind: models.KindInfo, Description: "Block device, dm devices by their mapper name"},
+		{Name: "dsk_READS/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Read requests"},
+		{Name: "dsk_WRITES/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Write requests"},
+		{Name: "dsk_MBRD/s", Kind: models.KindRate, Unit: models.UnitMBPerSecond, Description: "Read throughput"},
+		{Name: "dsk_MBWR/s", Kind: models.KindRate, Unit: models.UnitMBPerSecond, Description: "Write throughput"},
+		{Name: "dsk_%UTIL", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time the device was busy"},
+		{Name: "dsk_QDEPTH", Kind: models.KindGauge, Description: "I/Os in flight"},
+		{Name: "dsk_SVCTM", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Average service time per I/O"},
+		{Name: "dsk_AWAIT", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Average wait time per I/O, including queueing"},
+		{Name: "dsk_rdmerged", Kind: models.KindCounter, Description: "Merged read requests since boot", Verbose: true},
+		{Name: "dsk_sectorsrd", Kind: models.KindCounter, Description: "Sectors read since boot", Verbose: true},
+		{Name: "dsk_timerd", Kind: models.KindCounter, Unit: models.UnitMilliseconds, Description: "Time spent reading since boot", Verbose: true},
+		{Name: "dsk_wrmerged", Kind: models.KindCounter, Description: "Merged write requests since boot", Verbose: true},
+		{Name: "dsk_sectorswr", Kind: models.KindCounter, Description: "Sectors written since boot", Verbose: true},
+		{Name: "dsk_timewr", Kind: models.KindCounter, Unit: models.UnitMilliseconds, Description: "Time spent writing since boot", Verbose: true},
+		{Name: "dsk_timeforops", Kind: models.KindCounter, Unit: models.UnitMilliseconds, Description: "Time spent doing I/O since boot", Verbose: true},
+		{Name: "dsk_weightedtime", Kind: models.KindCounter, Unit: models.UnitMilliseconds, Description: "Time spent doing I/O weighted by I/Os in flight, since boot", Verbose: true},
+	})
+
+	// raw counters for exporters, /proc/diskstats sectors are 512 bytes and times milliseconds,
+	// the block statistics of the VM disks count nanoseconds
+	models.RegisterCounters("disk", models.ScopeHost, []models.CounterDef{
+		{Metric: "disk_physdev_*_reads", Name: "disk_reads", Description: "Completed reads of the physical disk"},
+		{Metric: "disk_physdev_*_writes", Name: "disk_writes", Description: "Completed writes of the physical disk"},
+		{Metric: "disk_physdev_*_sectorsread", Name: "disk_read", BaseUnit: "bytes", Scale: 512, Description: "Bytes read from the physical disk"},
+		{Metric: "disk_physdev_*_sectorswritten", Name: "disk_written", BaseUnit: "bytes", Scale: 512, Description: "Bytes written to the physical disk"},
+		{Metric: "disk_physdev_*_timeforops", Name: "disk_io_time", BaseUnit: "seconds", Scale: 1e-3, Description: "Time the physical disk was busy"},
+		{Metric: "disk_physdev_*_weightedtimeforops", Name: "disk_io_weighted_time", BaseUnit: "seconds", Scale: 1e-3, Description: "Time spent on I/O of the physical disk weighted by the queue length"},
+	})
+	models.RegisterCounters("disk", models.ScopeDomain, []models.CounterDef{
+		{Metric: "disk_stats_rdreq_*", Name: "disk_reads", Description: "Read requests of the virtual disk"},
+		{Metric: "disk_stats_wrreq_*", Name: "disk_writes", Description: "Write requests of the virtual disk"},
+		{Metric: "disk_stats_flushreq_*", Name: "disk_flushes", Description: "Flush requests of the virtual disk"},
+		{Metric: "disk_stats_rdbytes_*", Name: "disk_read", BaseUnit: "bytes", Description: "Bytes read from the virtual disk"},
+		{Metric: "disk_stats_wrbytes_*", Name: "disk_written", BaseUnit: "bytes", Description: "Bytes written to the virtual disk"},
+		{Metric: "disk_stats_rdtotaltimes_*", Name: "disk_read_time", BaseUnit: "seconds", Scale: 1e-9, Description: "Time spent on read requests of the virtual disk"},
+		{Metric: "disk_stats_wrtotaltimes_*", Name: "disk_write_time", BaseUnit: "seconds", Scale: 1e-9, Description: "Time spent on write requests of the virtual disk"},
+		{Metric: "disk_stats_flushtotaltimes_*", Name: "disk_flush_time", BaseUnit: "seconds", Scale: 1e-9, Description: "Time spent on flush requests of the virtual disk"},
+	})
+}
+
+// diskPerDeviceFields are the fields of the per disk rows of a VM (DiskPrintPerDevice)
+var diskPerDeviceFields = []string{
+	"dsk_READS/s", "dsk_WRITES/s", "dsk_MBRD/s", "dsk_MBWR/s", "dsk_LAT/rd", "dsk_LAT/wr", "dsk_LAT/fl", "dsk_LAT/avg",
+}
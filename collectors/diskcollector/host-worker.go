@@ -3,8 +3,6 @@ package diskcollector
 // cf. https://www.percona.com/doc/percona-toolkit/LATEST/pt-diskstats.html#description
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
 	"strings"
 
@@ -208,43 +206,21 @@ func diskPrintHost(host *models.Host) []string {
 }
 
 func diffInMilliseconds(host *models.Host, metricName string, inPercent bool) string {
-	var output string
-	var percent float64
-	if metric, ok := host.GetMetric(metricName); ok {
-		if len(metric.Values) >= 2 {
-			// get first value
-			byteValue1 := metric.Values[0].Value
-			reader1 := bytes.NewReader(byteValue1)
-			decoder1 := gob.NewDecoder(reader1)
-			var value1 uint64
-			decoder1.Decode(&value1)
-
-			// get second value
-			byteValue2 := metric.Values[1].Value
-			reader2 := bytes.NewReader(byteValue2)
-			decoder2 := gob.NewDecoder(reader2)
-			var value2 uint64
-			decoder2.Decode(&value2)
-
-			// calculate value diff per time
-			value := float64(value1 - value2)
-
-			// get time diff
-			ts1 := metric.Values[0].Timestamp
-			ts2 := metric.Values[1].Timestamp
-			diffSeconds := ts1.Sub(ts2).Seconds()
-			valuePerSecond := value / 1000 // since value is in ms
-			ratio := valuePerSecond / diffSeconds
-
-			if inPercent {
-				percent = ratio * 100 // compute it as percent
-				output = fmt.Sprintf("%.0f", percent)
-			} else {
-				output = fmt.Sprintf("%.0f", ratio)
-			}
-		}
+	// calculate value diff per time
+	value, err := host.GetMetricCounterDiff(metricName, true)
+	if err == models.ErrCounterReset {
+		return models.ResetMarker
+	}
+	if err != nil {
+		return ""
+	}
+	ratio := value / 1000 // since value is in ms
+
+	if inPercent {
+		percent := ratio * 100 // compute it as percent
+		return fmt.Sprintf("%.0f", percent)
 	}
-	return output
+	return fmt.Sprintf("%.0f", ratio)
 }
 
 func getTimes(host *models.Host) (string, string) {
@@ -319,30 +295,7 @@ func clearDuplicateDevices(diskstats map[string]util.ProcDiskstat) map[string]ut
 
 // HostDiskFields returns the field names for host physical disk view
 func HostDiskFields() []string {
-	fields := []string{
-		"dsk_DEVICE",
-		"dsk_READS/s",
-		"dsk_WRITES/s",
-		"dsk_MBRD/s",
-		"dsk_MBWR/s",
-		"dsk_%UTIL",
-		"dsk_QDEPTH",
-		"dsk_SVCTM",
-		"dsk_AWAIT",
-	}
-	if config.Options.Verbose {
-		fields = append(fields,
-			"dsk_rdmerged",
-			"dsk_sectorsrd",
-			"dsk_timerd",
-			"dsk_wrmerged",
-			"dsk_sectorswr",
-			"dsk_timewr",
-			"dsk_timeforops",
-			"dsk_weightedtime",
-		)
-	}
-	return fields
+	return models.DeviceFieldNames(models.DevicesDisk, config.Options.Verbose)
 }
 
 // CategorizedDiskStats holds disk stats organized by device type
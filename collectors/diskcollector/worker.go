@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"proxtop/config"
 	"proxtop/connector"
@@ -40,7 +41,9 @@ type diskstats struct {
 
 func diskLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
 
-	xmldoc, _ := libvirtDomain.GetXMLDesc(0)
+	start := time.Now()
+	xmldoc, err := libvirtDomain.GetXMLDesc(0)
+	models.RecordCall(models.CallLibvirt, start, err)
 	domcfg := &libvirtxml.Domain{}
 	domcfg.Unmarshal(xmldoc)
 
@@ -61,7 +64,9 @@ func diskLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
 			continue
 		}
 		dev := disk.Target.Dev
+		start := time.Now()
 		ioStats, err := libvirtDomain.BlockStats(dev)
+		models.RecordCall(models.CallLibvirt, start, err)
 
 		if ioStats != nil && err == nil {
 			// ioStats.ErrsSet - works only for xen
@@ -111,7 +116,9 @@ func diskLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
 			}
 		}
 
+		start = time.Now()
 		sizeStats, err := libvirtDomain.GetBlockInfo(dev, 0)
+		models.RecordCall(models.CallLibvirt, start, err)
 		// sizes
 		if sizeStats != nil && err == nil {
 			sums.Capacity += sizeStats.Capacity
@@ -164,10 +171,11 @@ func diskCollect(domain *models.Domain, host *models.Host) {
 
 // formatDiskSize formats a disk size value, optionally in human-readable format
 func formatDiskSize(value string) string {
-	if config.Options.HumanReadable {
-		return util.FormatBytesFromString(value)
+	size, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return value
 	}
-	return value
+	return models.UnitBytes.Format(size)
 }
 
 func diskPrint(domain *models.Domain) []string {
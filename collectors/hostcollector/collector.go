@@ -36,22 +36,10 @@ func (collector *Collector) Collect() {
 
 // Print returns the collectors measurements in a Printable struct
 func (collector *Collector) Print() models.Printable {
-	hostFields := []string{
-		"host_name",
-	}
-	domainFields := []string{
-		"host_name",
-	}
-
-	if config.Options.Verbose {
-		hostFields = append(hostFields,
-			"host_uuid",
-		)
-	}
-
+	// fields are declared in fields.go
 	printable := models.Printable{
-		HostFields:   hostFields,
-		DomainFields: domainFields,
+		HostFields:   models.FieldNames("host", models.ScopeHost, config.Options.Verbose),
+		DomainFields: models.FieldNames("host", models.ScopeDomain, config.Options.Verbose),
 	}
 
 	// lookup for each domain
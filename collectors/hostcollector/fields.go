@@ -0,0 +1,13 @@
+package hostcollector
+
+import "proxtop/models"
+
+func init() {
+	models.RegisterFields("host", models.ScopeHost, []models.FieldDef{
+		{Name: "host_name", Kind: models.KindInfo, Description: "Hostname of the hypervisor"},
+		{Name: "host_uuid", Kind: models.KindInfo, Description: "UUID of the hypervisor", Verbose: true},
+	})
+	models.RegisterFields("host", models.ScopeDomain, []models.FieldDef{
+		{Name: "host_name", Kind: models.KindInfo, Description: "Hostname of the hypervisor running the VM"},
+	})
+}
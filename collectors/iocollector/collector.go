@@ -34,23 +34,10 @@ func (collector *Collector) Collect() {
 
 // Print returns the collectors measurements in a Printable struct
 func (collector *Collector) Print() models.Printable {
-	// Domain fields - esxtop style: MBRD/s, MBWR/s, IOPS (syscalls)
-	domainFields := []string{
-		"io_MBRD/s",
-		"io_MBWR/s",
-		"io_RDOPS",
-		"io_WROPS",
-	}
-	if config.Options.Verbose {
-		domainFields = append(domainFields,
-			"io_rchar",
-			"io_wchar",
-			"io_cancelled",
-		)
-	}
+	// fields are declared in fields.go
 	printable := models.Printable{
 		HostFields:   []string{},
-		DomainFields: domainFields,
+		DomainFields: models.FieldNames("io", models.ScopeDomain, config.Options.Verbose),
 	}
 
 	// lookup for each domain
@@ -0,0 +1,26 @@
+package iocollector
+
+import "proxtop/models"
+
+func init() {
+	// esxtop style: MBRD/s, MBWR/s of the storage layer, RDOPS and WROPS as read and write syscalls of QEMU
+	models.RegisterFields("io", models.ScopeDomain, []models.FieldDef{
+		{Name: "io_MBRD/s", Kind: models.KindRate, Unit: models.UnitMBPerSecond, Description: "Bytes read from storage by the QEMU process"},
+		{Name: "io_MBWR/s", Kind: models.KindRate, Unit: models.UnitMBPerSecond, Description: "Bytes written to storage by the QEMU process"},
+		{Name: "io_RDOPS", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Read syscalls of the QEMU process"},
+		{Name: "io_WROPS", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Write syscalls of the QEMU process"},
+		{Name: "io_rchar", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Bytes read by syscalls, including page cache hits", Verbose: true},
+		{Name: "io_wchar", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Bytes written by syscalls, including page cache", Verbose: true},
+		{Name: "io_cancelled", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Written bytes cancelled by truncation before writeback", Verbose: true},
+	})
+
+	// raw counters of /proc/<pid>/io for exporters
+	models.RegisterCounters("io", models.ScopeDomain, []models.CounterDef{
+		{Metric: "io_read_bytes", Name: "io_storage_read", BaseUnit: "bytes", Description: "Bytes read from storage by the QEMU process"},
+		{Metric: "io_write_bytes", Name: "io_storage_written", BaseUnit: "bytes", Description: "Bytes written to storage by the QEMU process"},
+		{Metric: "io_rchar", Name: "io_read", BaseUnit: "bytes", Description: "Bytes read by syscalls of the QEMU process"},
+		{Metric: "io_wchar", Name: "io_written", BaseUnit: "bytes", Description: "Bytes written by syscalls of the QEMU process"},
+		{Metric: "io_syscr", Name: "io_read_syscalls", Description: "Read syscalls of the QEMU process"},
+		{Metric: "io_syscw", Name: "io_write_syscalls", Description: "Write syscalls of the QEMU process"},
+	})
+}
@@ -0,0 +1,46 @@
+package irqcollector
+
+import (
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// Collector describes the interrupt and softirq collector
+type Collector struct {
+	models.Collector
+}
+
+// Lookup irq collector data
+func (collector *Collector) Lookup() {
+	hostLookup(&models.Collection.Host)
+}
+
+// Collect irq collector data
+func (collector *Collector) Collect() {
+	hostCollect(&models.Collection.Host)
+}
+
+// Print returns the collectors measurements in a Printable struct
+func (collector *Collector) Print() models.Printable {
+	// fields are declared in fields.go
+	printable := models.Printable{
+		HostFields:   models.FieldNames("irq", models.ScopeHost, config.Options.Verbose),
+		DomainFields: []string{},
+	}
+
+	// lookup for host
+	printable.HostValues = hostPrint(&models.Collection.Host)
+	printable.AddDevices(models.DevicesIRQ, HostIRQFields(), HostPrintPerCPU())
+
+	return printable
+}
+
+// CreateCollector creates a new irq collector
+func CreateCollector() Collector {
+	// /proc/interrupts and /proc/softirqs count in unsigned int
+	models.DeclareCounter("irq_group_", 32)
+	models.DeclareCounter("irq_total_", 32)
+	models.DeclareCounter("irq_class_", 32)
+	models.DeclareCounter("irq_softirq_", 32)
+	return Collector{}
+}
@@ -0,0 +1,38 @@
+package irqcollector
+
+import "proxtop/models"
+
+func init() {
+	// totals over all CPUs, details are shown in the per-CPU view
+	models.RegisterFields("irq", models.ScopeHost, []models.FieldDef{
+		{Name: "irq_IRQ/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Hardware interrupts of all CPUs"},
+		{Name: "irq_NETRX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "NET_RX softirqs of all CPUs"},
+		{Name: "irq_NETTX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "NET_TX softirqs of all CPUs"},
+		{Name: "irq_BLOCK/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "BLOCK softirqs of all CPUs"},
+		{Name: "irq_MAX%SI", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Highest irq and softirq time of a CPU"},
+		{Name: "irq_SATCPUS", Kind: models.KindGauge, Description: "CPUs saturated by interrupt handling"},
+	})
+
+	// interrupt view 'o'
+	models.RegisterDeviceFields("irq", []string{models.DevicesIRQ}, []models.FieldDef{
+		{Name: "irq_CPU", Kind: models.KindInfo, Description: "Logical CPU"},
+		{Name: "irq_%IRQ", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent handling hardware interrupts"},
+		{Name: "irq_%SIRQ", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Time spent handling softirqs"},
+		{Name: "irq_SAT", Kind: models.KindInfo, Description: "* if the CPU is saturated by interrupt handling"},
+		{Name: "irq_IRQ/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Hardware interrupts"},
+		{Name: "irq_NIC/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Interrupts of network cards"},
+		{Name: "irq_NVME/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Interrupts of NVMe devices"},
+		{Name: "irq_VFIO/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Interrupts of passthrough devices"},
+		{Name: "irq_NETRX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "NET_RX softirqs"},
+		{Name: "irq_NETTX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "NET_TX softirqs"},
+		{Name: "irq_BLOCK/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "BLOCK softirqs"},
+		{Name: "irq_TOPDEV", Kind: models.KindInfo, Description: "Device group with the most interrupts and its rate"},
+		{Name: "irq_VCPUS", Kind: models.KindInfo, Description: "vCPUs which last ran on the CPU at collection time"},
+		{Name: "irq_OTHER/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Interrupts of other devices", Verbose: true},
+		{Name: "irq_TIMER/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "TIMER softirqs", Verbose: true},
+		{Name: "irq_SCHED/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "SCHED softirqs", Verbose: true},
+		{Name: "irq_RCU/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "RCU softirqs", Verbose: true},
+		{Name: "irq_TASKLET/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "TASKLET softirqs", Verbose: true},
+		{Name: "irq_HRTIMER/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "HRTIMER softirqs", Verbose: true},
+	})
+}
@@ -0,0 +1,68 @@
+package irqcollector
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"proxtop/util"
+)
+
+// interrupt classes used for the per-CPU columns
+const (
+	classNIC    = "nic"
+	classNVMe   = "nvme"
+	classVFIO   = "vfio"
+	classOther  = "other"
+	classSystem = "system"
+)
+
+// nvme0q3
+var nvmeRegexp = regexp.MustCompile(`^(nvme\d+)q\d+$`)
+
+// eth0-TxRx-3, ens1f0-rx-0, enp65s0f0np0-0, mlx5_comp3@pci:0000:3b:00.0
+var nicQueueRegexp = regexp.MustCompile(`^(.+?)[-_](?i:txrx|rx|tx|fp|comp|queue|input|output|combined)?[-_]?\d+(@.*)?$`)
+
+// interruptGroup maps an interrupt to its device group (queues of the same device
+// are merged) and class. Named interrupts (LOC, RES, ...) are system interrupts.
+func interruptGroup(irq string, device string, netDevices map[string]bool) (string, string) {
+	if _, err := strconv.Atoi(irq); err != nil {
+		return irq, classSystem
+	}
+
+	// shared IRQs list several devices, use the first one
+	device = strings.TrimSpace(strings.Split(device, ",")[0])
+	if device == "" {
+		return "irq" + irq, classOther
+	}
+
+	if address := util.GetVFIOInterruptAddress(device); address != "" {
+		return "vfio-" + address, classVFIO
+	}
+	if match := nvmeRegexp.FindStringSubmatch(device); match != nil {
+		return match[1], classNVMe
+	}
+	if match := nicQueueRegexp.FindStringSubmatch(device); match != nil {
+		// some drivers prefix the queue name with the driver name (i40e-eth0-TxRx-0)
+		name := match[1]
+		if dash := strings.LastIndex(name, "-"); dash >= 0 && netDevices[name[dash+1:]] {
+			name = name[dash+1:]
+		}
+		if isNetDevice(name, device, netDevices) {
+			return name, classNIC
+		}
+	}
+	if isNetDevice(device, device, netDevices) {
+		return device, classNIC
+	}
+	return device, classOther
+}
+
+// isNetDevice checks if the interrupt belongs to a network device
+func isNetDevice(name string, device string, netDevices map[string]bool) bool {
+	if netDevices[name] {
+		return true
+	}
+	// mlx5 and similar drivers name their queues after the driver and PCI address
+	return strings.Contains(device, "@pci:")
+}
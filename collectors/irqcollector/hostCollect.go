@@ -0,0 +1,99 @@
+package irqcollector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// classes with a per-CPU counter, system interrupts are only part of the total
+var deviceClasses = []string{classNIC, classNVMe, classVFIO, classOther}
+
+func hostCollect(host *models.Host) {
+	netDevices := make(map[string]bool)
+	for _, name := range host.GetMetricStringArray("irq_netdevices") {
+		netDevices[name] = true
+	}
+
+	// hard interrupts, summed per device group and class
+	cpuSet := make(map[int]bool)
+	totals := make(map[int]uint64)
+	groupSums := make(map[string]map[int]uint64)
+	classSums := make(map[string]map[int]uint64)
+	for _, class := range deviceClasses {
+		classSums[class] = make(map[int]uint64)
+	}
+	for _, interrupt := range util.GetProcInterrupts() {
+		if interrupt.IRQ == "ERR" || interrupt.IRQ == "MIS" {
+			// not per CPU
+			continue
+		}
+		group, class := interruptGroup(interrupt.IRQ, interrupt.Device, netDevices)
+		for cpu, count := range interrupt.Counts {
+			cpuSet[cpu] = true
+			totals[cpu] += count
+			if class == classSystem {
+				continue
+			}
+			if _, ok := groupSums[group]; !ok {
+				groupSums[group] = make(map[int]uint64)
+			}
+			groupSums[group][cpu] += count
+			classSums[class][cpu] += count
+		}
+	}
+
+	cpus := []int{}
+	for cpu := range cpuSet {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+	host.AddMetricMeasurement("irq_cpus", models.CreateMeasurement(cpus))
+
+	groups := []string{}
+	for group, counts := range groupSums {
+		groups = append(groups, group)
+		for cpu, count := range counts {
+			host.AddMetricMeasurement(fmt.Sprintf("irq_group_%s_cpu%d", group, cpu), models.CreateMeasurement(count))
+		}
+	}
+	sort.Strings(groups)
+	host.AddMetricMeasurement("irq_groups", models.CreateMeasurement(groups))
+
+	for _, cpu := range cpus {
+		host.AddMetricMeasurement(fmt.Sprintf("irq_total_cpu%d", cpu), models.CreateMeasurement(totals[cpu]))
+		for _, class := range deviceClasses {
+			host.AddMetricMeasurement(fmt.Sprintf("irq_class_%s_cpu%d", class, cpu), models.CreateMeasurement(classSums[class][cpu]))
+		}
+	}
+
+	// softirqs per CPU
+	softirqNames := []string{}
+	for _, softirq := range util.GetProcSoftIRQs() {
+		softirqNames = append(softirqNames, softirq.Name)
+		for cpu, count := range softirq.Counts {
+			host.AddMetricMeasurement(fmt.Sprintf("irq_softirq_%s_cpu%d", softirq.Name, cpu), models.CreateMeasurement(count))
+		}
+	}
+	host.AddMetricMeasurement("irq_softirqs", models.CreateMeasurement(softirqNames))
+
+	// time spent in interrupt handling per CPU from /proc/stat
+	for _, stat := range util.GetProcStatCPU() {
+		if !strings.HasPrefix(stat.Name, "cpu") || stat.Name == "cpu" {
+			continue
+		}
+		cpu, err := strconv.Atoi(strings.TrimPrefix(stat.Name, "cpu"))
+		if err != nil {
+			continue
+		}
+		// guest time is already accounted in user time
+		total := stat.User + stat.Nice + stat.System + stat.Idle + stat.IOWait + stat.IRQ + stat.SoftIRQ + stat.Steal
+		host.AddMetricMeasurement(fmt.Sprintf("irq_time_irq_cpu%d", cpu), models.CreateMeasurement(stat.IRQ))
+		host.AddMetricMeasurement(fmt.Sprintf("irq_time_softirq_cpu%d", cpu), models.CreateMeasurement(stat.SoftIRQ))
+		host.AddMetricMeasurement(fmt.Sprintf("irq_time_total_cpu%d", cpu), models.CreateMeasurement(total))
+	}
+}
@@ -0,0 +1,18 @@
+package irqcollector
+
+import (
+	"sort"
+
+	"proxtop/models"
+	"proxtop/util"
+)
+
+func hostLookup(host *models.Host) {
+	// network device names are used to group NIC queue interrupts
+	netDevices := []string{}
+	for name := range util.GetAllNetDevices() {
+		netDevices = append(netDevices, name)
+	}
+	sort.Strings(netDevices)
+	host.AddMetricMeasurement("irq_netdevices", models.CreateMeasurement(netDevices))
+}
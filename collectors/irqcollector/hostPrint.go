@@ -0,0 +1,125 @@
+package irqcollector
+
+import (
+	"fmt"
+
+	"proxtop/collectors/cpucollector"
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// SaturatedThreshold is the share of CPU time (in %) spent in irq and softirq
+// handling above which a core is considered saturated by interrupts
+const SaturatedThreshold = 50.0
+
+// HostIRQFields returns the field names for the per-CPU interrupt view
+func HostIRQFields() []string {
+	return models.DeviceFieldNames(models.DevicesIRQ, config.Options.Verbose)
+}
+
+// HostPrintPerCPU returns per-CPU interrupt stats for the interrupt view
+// Returns a map of CPU name -> []string (field values in same order as HostIRQFields)
+func HostPrintPerCPU() map[string][]string {
+	host := &models.Collection.Host
+	result := make(map[string][]string)
+	groups := host.GetMetricStringArray("irq_groups")
+
+	// vCPUs that last ran on each core
+	placement := cpucollector.VCPUPlacement(false)
+
+	for _, cpu := range host.GetMetricIntArray("irq_cpus") {
+		irqPct, softirqPct := interruptTimePercent(host, cpu)
+		saturated := "-"
+		if irqPct+softirqPct >= SaturatedThreshold {
+			saturated = "*"
+		}
+
+		// device group with the highest interrupt rate on this core
+		topDevice := "-"
+		var topRate float64
+		for _, group := range groups {
+			rate := host.GetMetricDiffUint64AsFloat(fmt.Sprintf("irq_group_%s_cpu%d", group, cpu), true)
+			if rate > topRate {
+				topRate = rate
+				topDevice = fmt.Sprintf("%s:%.0f", group, rate)
+			}
+		}
+
+		values := []string{
+			fmt.Sprintf("cpu%d", cpu),
+			fmt.Sprintf("%.1f", irqPct),
+			fmt.Sprintf("%.1f", softirqPct),
+			saturated,
+			cpuRate(host, "irq_total", cpu),
+			cpuRate(host, "irq_class_"+classNIC, cpu),
+			cpuRate(host, "irq_class_"+classNVMe, cpu),
+			cpuRate(host, "irq_class_"+classVFIO, cpu),
+			cpuRate(host, "irq_softirq_NET_RX", cpu),
+			cpuRate(host, "irq_softirq_NET_TX", cpu),
+			cpuRate(host, "irq_softirq_BLOCK", cpu),
+			topDevice,
+			cpucollector.FormatVCPUPlacement(placement, cpu),
+		}
+		if config.Options.Verbose {
+			values = append(values,
+				cpuRate(host, "irq_class_"+classOther, cpu),
+				cpuRate(host, "irq_softirq_TIMER", cpu),
+				cpuRate(host, "irq_softirq_SCHED", cpu),
+				cpuRate(host, "irq_softirq_RCU", cpu),
+				cpuRate(host, "irq_softirq_TASKLET", cpu),
+				cpuRate(host, "irq_softirq_HRTIMER", cpu),
+			)
+		}
+
+		result[fmt.Sprintf("cpu%d", cpu)] = values
+	}
+
+	return result
+}
+
+func hostPrint(host *models.Host) []string {
+	var irqRate, netRxRate, netTxRate, blockRate, maxPct float64
+	saturated := 0
+	for _, cpu := range host.GetMetricIntArray("irq_cpus") {
+		irqRate = models.AddRate(irqRate, cpuRateFloat(host, "irq_total", cpu))
+		netRxRate = models.AddRate(netRxRate, cpuRateFloat(host, "irq_softirq_NET_RX", cpu))
+		netTxRate = models.AddRate(netTxRate, cpuRateFloat(host, "irq_softirq_NET_TX", cpu))
+		blockRate = models.AddRate(blockRate, cpuRateFloat(host, "irq_softirq_BLOCK", cpu))
+
+		irqPct, softirqPct := interruptTimePercent(host, cpu)
+		if irqPct+softirqPct > maxPct {
+			maxPct = irqPct + softirqPct
+		}
+		if irqPct+softirqPct >= SaturatedThreshold {
+			saturated++
+		}
+	}
+
+	return []string{
+		fmt.Sprintf("%.0f", irqRate),
+		fmt.Sprintf("%.0f", netRxRate),
+		fmt.Sprintf("%.0f", netTxRate),
+		fmt.Sprintf("%.0f", blockRate),
+		fmt.Sprintf("%.1f", maxPct),
+		fmt.Sprintf("%d", saturated),
+	}
+}
+
+// interruptTimePercent returns the share of CPU time spent in irq and softirq handling
+func interruptTimePercent(host *models.Host, cpu int) (float64, float64) {
+	total := host.GetMetricDiffUint64AsFloat(fmt.Sprintf("irq_time_total_cpu%d", cpu), false)
+	if total <= 0 {
+		return 0, 0
+	}
+	irq := host.GetMetricDiffUint64AsFloat(fmt.Sprintf("irq_time_irq_cpu%d", cpu), false)
+	softirq := host.GetMetricDiffUint64AsFloat(fmt.Sprintf("irq_time_softirq_cpu%d", cpu), false)
+	return irq / total * 100, softirq / total * 100
+}
+
+func cpuRateFloat(host *models.Host, prefix string, cpu int) float64 {
+	return host.GetMetricDiffUint64AsFloat(fmt.Sprintf("%s_cpu%d", prefix, cpu), true)
+}
+
+func cpuRate(host *models.Host, prefix string, cpu int) string {
+	return fmt.Sprintf("%.0f", cpuRateFloat(host, prefix, cpu))
+}
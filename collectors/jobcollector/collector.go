@@ -0,0 +1,92 @@
+package jobcollector
+
+import (
+	"proxtop/config"
+	"proxtop/connector"
+	"proxtop/models"
+)
+
+// Collector describes the backup and block job collector
+type Collector struct {
+	models.Collector
+}
+
+// Lookup job collector data
+func (collector *Collector) Lookup() {
+	if !connector.IsProxmox() {
+		models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
+			uuid := key.(string)
+			domain := value.(models.Domain)
+			libvirtDomain, ok := models.Collection.LibvirtDomains.Load(uuid)
+			if ok {
+				domainLookup(&domain, libvirtDomain)
+			}
+			return true
+		})
+		return
+	}
+
+	// read the task list once for all domains
+	proxmoxConn, ok := connector.CurrentConnector.(*connector.ProxmoxConnector)
+	if !ok {
+		return
+	}
+	tasks, _ := proxmoxConn.GetActiveTasks()
+
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		vmInfo, ok := connector.ProxmoxVMStore.Load(uuid)
+		if ok {
+			domainLookupProxmox(&domain, vmInfo, proxmoxConn, tasks)
+		}
+		return true
+	})
+}
+
+// Collect job collector data
+func (collector *Collector) Collect() {
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		if connector.IsProxmox() {
+			vmInfo, ok := connector.ProxmoxVMStore.Load(uuid)
+			proxmoxConn, isProxmox := connector.CurrentConnector.(*connector.ProxmoxConnector)
+			if ok && isProxmox {
+				domainCollectProxmox(&domain, proxmoxConn.QMPExecutor(vmInfo.VMID))
+			}
+		} else {
+			libvirtDomain, ok := models.Collection.LibvirtDomains.Load(uuid)
+			if ok {
+				domainCollect(&domain, libvirtDomain)
+			}
+		}
+		return true
+	})
+}
+
+// Print returns the collectors measurements in a Printable struct
+func (collector *Collector) Print() models.Printable {
+	// fields are declared in fields.go
+	printable := models.Printable{
+		HostFields:   []string{},
+		DomainFields: models.FieldNames("jobs", models.ScopeDomain, config.Options.Verbose),
+	}
+
+	// lookup for each domain
+	printable.DomainValues = make(map[string][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		printable.DomainValues[uuid] = domainPrint(&domain)
+		return true
+	})
+	printable.AddDevices(models.DevicesJobs, DomainJobFields(), DomainPrintPerJob())
+
+	return printable
+}
+
+// CreateCollector creates a new job collector
+func CreateCollector() Collector {
+	return Collector{}
+}
@@ -0,0 +1,167 @@
+package jobcollector
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"proxtop/connector"
+	"proxtop/models"
+	"proxtop/util"
+
+	libvirt "github.com/libvirt/libvirt-go"
+)
+
+// job is a running backup, mirror, stream or commit job of a VM, progress in bytes
+type job struct {
+	key    string
+	kind   string
+	device string
+	status string
+	done   uint64
+	total  uint64
+	// throttle in bytes/s, 0 for unlimited
+	limit uint64
+}
+
+// libvirtBlockJobTypes maps libvirt block job types to the QEMU job types
+var libvirtBlockJobTypes = map[libvirt.DomainBlockJobType]string{
+	libvirt.DOMAIN_BLOCK_JOB_TYPE_PULL:          "stream",
+	libvirt.DOMAIN_BLOCK_JOB_TYPE_COPY:          "mirror",
+	libvirt.DOMAIN_BLOCK_JOB_TYPE_COMMIT:        "commit",
+	libvirt.DOMAIN_BLOCK_JOB_TYPE_ACTIVE_COMMIT: "commit",
+}
+
+// domainCollectProxmox reads block jobs, jobs and the Proxmox backup status via QMP
+func domainCollectProxmox(domain *models.Domain, execute connector.QMPExecutor) {
+	jobs := []job{}
+
+	devices := []string{}
+	if blockJobs, err := connector.GetBlockJobs(execute); err == nil {
+		for _, blockJob := range blockJobs {
+			devices = append(devices, blockJob.Device)
+			jobs = append(jobs, job{
+				key:    fmt.Sprint("block-", blockJob.Device),
+				kind:   blockJob.Type,
+				device: blockJob.Device,
+				status: blockJobStatus(blockJob),
+				done:   blockJob.Offset,
+				total:  blockJob.Len,
+				limit:  blockJob.Speed,
+			})
+		}
+	}
+
+	// query-jobs also lists the block jobs and keeps concluded jobs until they are dismissed
+	if qmpJobs, err := connector.GetJobs(execute); err == nil {
+		for _, qmpJob := range qmpJobs {
+			if util.ContainsString(devices, qmpJob.ID) || qmpJob.Status == "concluded" || qmpJob.Status == "null" {
+				continue
+			}
+			jobs = append(jobs, job{
+				key:    fmt.Sprint("job-", qmpJob.ID),
+				kind:   qmpJob.Type,
+				device: qmpJob.ID,
+				status: qmpJob.Status,
+				done:   qmpJob.CurrentProgress,
+				total:  qmpJob.TotalProgress,
+			})
+		}
+	}
+
+	// vzdump backups run as Proxmox backup job, not as block job
+	if domain.GetMetricString("job_pvebackup", 0) == "yes" {
+		if backup, err := connector.GetBackupStatus(execute); err == nil && backup.Status == "active" {
+			jobs = append(jobs, job{
+				key:    "vzdump",
+				kind:   "backup",
+				device: path.Base(backup.BackupFile),
+				status: "running",
+				done:   backup.Transferred,
+				total:  backup.Total,
+			})
+		}
+	}
+
+	// running task without QEMU job yet, e.g. vzdump while freezing the guest or a migration preparing disks
+	if taskType := domain.GetMetricString("job_tasktype", 0); len(jobs) == 0 && taskType != "" && taskType != "-" {
+		jobs = append(jobs, job{
+			key:    "task",
+			kind:   taskType,
+			device: "-",
+			status: "running",
+		})
+	}
+
+	storeJobs(domain, jobs)
+}
+
+// domainCollect reads the block jobs of the VM disks via libvirt
+func domainCollect(domain *models.Domain, libvirtDomain libvirt.Domain) {
+	jobs := []job{}
+	for _, disk := range domain.GetMetricStringArray("job_disks") {
+		start := time.Now()
+		info, err := libvirtDomain.GetBlockJobInfo(disk, libvirt.DOMAIN_BLOCK_JOB_INFO_BANDWIDTH_BYTES)
+		models.RecordCall(models.CallLibvirt, start, err)
+		if err != nil || info.Type == libvirt.DOMAIN_BLOCK_JOB_TYPE_UNKNOWN {
+			continue
+		}
+		kind, ok := libvirtBlockJobTypes[info.Type]
+		if !ok {
+			kind = "other"
+		}
+		status := "running"
+		if info.End > 0 && info.Cur == info.End && info.Type != libvirt.DOMAIN_BLOCK_JOB_TYPE_PULL {
+			// mirror and active commit wait for pivot
+			status = "ready"
+		}
+		jobs = append(jobs, job{
+			key:    fmt.Sprint("block-", disk),
+			kind:   kind,
+			device: disk,
+			status: status,
+			done:   info.Cur,
+			total:  info.End,
+			limit:  info.Bandwidth,
+		})
+	}
+	storeJobs(domain, jobs)
+}
+
+// blockJobStatus returns the job status, older QEMU versions only report busy/paused/ready
+func blockJobStatus(blockJob connector.QMPBlockJob) string {
+	switch {
+	case blockJob.Status != "":
+		return blockJob.Status
+	case blockJob.Paused:
+		return "paused"
+	case blockJob.Ready:
+		return "ready"
+	}
+	return "running"
+}
+
+// storeJobs stores the running jobs and removes the metrics of finished jobs
+func storeJobs(domain *models.Domain, jobs []job) {
+	finished := domain.GetMetricStringArray("job_keys")
+	keys := []string{}
+	for _, job := range jobs {
+		keys = append(keys, job.key)
+		finished = util.RemoveFromArray(finished, job.key)
+		domain.AddMetricMeasurement(fmt.Sprint("job_kind_", job.key), models.CreateMeasurement(job.kind))
+		domain.AddMetricMeasurement(fmt.Sprint("job_device_", job.key), models.CreateMeasurement(job.device))
+		domain.AddMetricMeasurement(fmt.Sprint("job_status_", job.key), models.CreateMeasurement(job.status))
+		domain.AddMetricMeasurement(fmt.Sprint("job_done_", job.key), models.CreateMeasurement(job.done))
+		domain.AddMetricMeasurement(fmt.Sprint("job_total_", job.key), models.CreateMeasurement(job.total))
+		domain.AddMetricMeasurement(fmt.Sprint("job_limit_", job.key), models.CreateMeasurement(job.limit))
+	}
+	for _, key := range finished {
+		domain.DelMetricMeasurement(fmt.Sprint("job_kind_", key))
+		domain.DelMetricMeasurement(fmt.Sprint("job_device_", key))
+		domain.DelMetricMeasurement(fmt.Sprint("job_status_", key))
+		domain.DelMetricMeasurement(fmt.Sprint("job_done_", key))
+		domain.DelMetricMeasurement(fmt.Sprint("job_total_", key))
+		domain.DelMetricMeasurement(fmt.Sprint("job_limit_", key))
+	}
+	domain.AddMetricMeasurement("job_keys", models.CreateMeasurement(keys))
+}
@@ -0,0 +1,60 @@
+package jobcollector
+
+import (
+	"time"
+
+	"proxtop/connector"
+	"proxtop/models"
+
+	libvirt "github.com/libvirt/libvirt-go"
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+)
+
+// domainLookup stores the disk targets to ask for block jobs
+func domainLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
+	disks := []string{}
+	start := time.Now()
+	xmldoc, err := libvirtDomain.GetXMLDesc(0)
+	models.RecordCall(models.CallLibvirt, start, err)
+	domcfg := &libvirtxml.Domain{}
+	domcfg.Unmarshal(xmldoc)
+
+	if domcfg.Devices != nil {
+		for _, disk := range domcfg.Devices.Disks {
+			if disk.Target == nil || disk.Target.Dev == "" {
+				continue
+			}
+			disks = append(disks, disk.Target.Dev)
+		}
+	}
+	domain.AddMetricMeasurement("job_disks", models.CreateMeasurement(disks))
+}
+
+// domainLookupProxmox assigns the running Proxmox task to the VM and checks for Proxmox backup support
+func domainLookupProxmox(domain *models.Domain, vmInfo connector.VMInfo, proxmoxConn *connector.ProxmoxConnector, tasks []connector.ProxmoxTask) {
+	taskType, taskUser := "-", "-"
+	var taskStart uint64
+	for _, task := range tasks {
+		// vzdump of several guests has no ID, the VM currently backed up holds the backup lock
+		if task.ID == vmInfo.VMID || (task.ID == "" && task.Type == "vzdump" && vmInfo.Lock == "backup") {
+			taskType = task.Type
+			taskUser = task.User
+			taskStart = uint64(task.Start.Unix())
+			break
+		}
+	}
+	domain.AddMetricMeasurement("job_tasktype", models.CreateMeasurement(taskType))
+	domain.AddMetricMeasurement("job_taskuser", models.CreateMeasurement(taskUser))
+	domain.AddMetricMeasurement("job_taskstart", models.CreateMeasurement(taskStart))
+
+	// query-backup needs the Proxmox QEMU patches, checked once per QEMU process
+	checkedPID, err := domain.GetMetricUint64Raw("job_supportpid", 0)
+	if err != nil || checkedPID != uint64(domain.PID) {
+		pveBackup := "no"
+		if _, err := connector.GetProxmoxSupport(proxmoxConn.QMPExecutor(vmInfo.VMID)); err == nil {
+			pveBackup = "yes"
+		}
+		domain.AddMetricMeasurement("job_pvebackup", models.CreateMeasurement(pveBackup))
+		domain.AddMetricMeasurement("job_supportpid", models.CreateMeasurement(uint64(domain.PID)))
+	}
+}
@@ -0,0 +1,120 @@
+package jobcollector
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"proxtop/config"
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// formatBytes formats a value in bytes
+func formatBytes(valueBytes uint64) string {
+	return models.UnitBytes.Format(valueBytes)
+}
+
+// jobSpeed returns the progress of a job in bytes/s, 0 if the job restarted
+func jobSpeed(domain *models.Domain, key string) float64 {
+	speed, err := domain.GetMetricCounterDiff(fmt.Sprint("job_done_", key), true)
+	if err != nil {
+		return 0
+	}
+	return speed
+}
+
+// jobPercent returns the progress in percent, "-" if the job size is not known
+func jobPercent(done uint64, total uint64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f", float64(done)/float64(total)*100)
+}
+
+// jobTask returns the Proxmox task of the VM as type@user, "-" without task
+func jobTask(domain *models.Domain) string {
+	taskType := domain.GetMetricString("job_tasktype", 0)
+	if taskType == "" || taskType == "-" {
+		return "-"
+	}
+	return fmt.Sprint(taskType, "@", domain.GetMetricString("job_taskuser", 0))
+}
+
+func domainPrint(domain *models.Domain) []string {
+	keys := domain.GetMetricStringArray("job_keys")
+	kinds := []string{}
+	var done, total uint64
+	var speed float64
+	for _, key := range keys {
+		kind := domain.GetMetricString(fmt.Sprint("job_kind_", key), 0)
+		if !util.ContainsString(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+		jobDone, _ := domain.GetMetricUint64Raw(fmt.Sprint("job_done_", key), 0)
+		jobTotal, _ := domain.GetMetricUint64Raw(fmt.Sprint("job_total_", key), 0)
+		done += jobDone
+		total += jobTotal
+		speed += jobSpeed(domain, key)
+	}
+
+	// VMs with a running job are marked by the job types
+	jobs, percent := "-", "-"
+	if len(kinds) > 0 {
+		jobs = strings.Join(kinds, ",")
+		percent = jobPercent(done, total)
+	}
+	result := []string{jobs, percent}
+	if config.Options.Verbose {
+		result = append(result, formatBytes(uint64(speed)), jobTask(domain))
+	}
+	return result
+}
+
+// DomainJobFields returns the field names for the jobs view
+func DomainJobFields() []string {
+	return models.DeviceFieldNames(models.DevicesJobs, config.Options.Verbose)
+}
+
+// DomainPrintPerJob returns the running jobs of all VMs for the jobs view
+// Returns a map of UUID/job -> []string (field values in same order as DomainJobFields)
+func DomainPrintPerJob() map[string][]string {
+	result := make(map[string][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+
+		task := jobTask(&domain)
+		age := "-"
+		if start, _ := domain.GetMetricUint64Raw("job_taskstart", 0); start > 0 && task != "-" {
+			if now := uint64(time.Now().Unix()); now > start {
+				age = fmt.Sprintf("%d", now-start)
+			}
+		}
+
+		for _, jobKey := range domain.GetMetricStringArray("job_keys") {
+			done, _ := domain.GetMetricUint64Raw(fmt.Sprint("job_done_", jobKey), 0)
+			total, _ := domain.GetMetricUint64Raw(fmt.Sprint("job_total_", jobKey), 0)
+			limit, _ := domain.GetMetricUint64Raw(fmt.Sprint("job_limit_", jobKey), 0)
+			limitFmt := "-"
+			if limit > 0 {
+				limitFmt = formatBytes(limit)
+			}
+			result[fmt.Sprint(uuid, "/", jobKey)] = []string{
+				domain.Name,
+				domain.GetMetricString(fmt.Sprint("job_kind_", jobKey), 0),
+				domain.GetMetricString(fmt.Sprint("job_device_", jobKey), 0),
+				domain.GetMetricString(fmt.Sprint("job_status_", jobKey), 0),
+				jobPercent(done, total),
+				formatBytes(done),
+				formatBytes(total),
+				formatBytes(uint64(jobSpeed(&domain, jobKey))),
+				limitFmt,
+				task,
+				age,
+			}
+		}
+		return true
+	})
+	return result
+}
@@ -0,0 +1,27 @@
+package jobcollector
+
+import "proxtop/models"
+
+func init() {
+	models.RegisterFields("jobs", models.ScopeDomain, []models.FieldDef{
+		{Name: "job_JOB", Kind: models.KindInfo, Description: "Types of the running jobs, - without job"},
+		{Name: "job_%DONE", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Progress of all jobs"},
+		{Name: "job_SPEED", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Bytes processed by all jobs", Verbose: true},
+		{Name: "job_TASK", Kind: models.KindInfo, Description: "Running Proxmox task as type@user", Verbose: true},
+	})
+
+	// jobs view 'b'
+	models.RegisterDeviceFields("jobs", []string{models.DevicesJobs}, []models.FieldDef{
+		{Name: "job_VM", Kind: models.KindInfo, Description: "VM of the job"},
+		{Name: "job_TYPE", Kind: models.KindInfo, Description: "Job type: backup, mirror, stream, commit, ..."},
+		{Name: "job_DEVICE", Kind: models.KindInfo, Description: "Block device or backup archive"},
+		{Name: "job_STATUS", Kind: models.KindInfo, Description: "Job status: running, paused, ready, ..."},
+		{Name: "job_%DONE", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Progress"},
+		{Name: "job_DONE", Kind: models.KindCounter, Unit: models.UnitBytes, Description: "Bytes processed"},
+		{Name: "job_TOTAL", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Bytes to process"},
+		{Name: "job_SPEED", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Processing speed"},
+		{Name: "job_LIMIT", Kind: models.KindInfo, Unit: models.UnitBytesPerSecond, Description: "Throttle, - for unlimited"},
+		{Name: "job_TASK", Kind: models.KindInfo, Description: "Proxmox task as type@user"},
+		{Name: "job_AGE", Kind: models.KindGauge, Unit: models.UnitSeconds, Description: "Time since the task started"},
+	})
+}
@@ -1,13 +1,17 @@
 package memcollector
 
 import (
+	"time"
+
 	"proxtop/connector"
 	"proxtop/models"
 	libvirt "github.com/libvirt/libvirt-go"
 )
 
 func domainLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
+	start := time.Now()
 	memStats, err := libvirtDomain.MemoryStats(uint32(libvirt.DOMAIN_MEMORY_STAT_NR), 0)
+	models.RecordCall(models.CallLibvirt, start, err)
 	if err != nil {
 		return
 	}
@@ -26,6 +30,7 @@ func domainLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
 	newMeasurementUsed := models.CreateMeasurement(used)
 	domain.AddMetricMeasurement("ram_used", newMeasurementUsed)
 
+	lookupGuestStatsLibvirt(domain, libvirtDomain, memStats)
 }
 
 // domainLookupProxmox handles memory lookup for Proxmox VMs
@@ -49,6 +54,20 @@ func domainLookupProxmox(domain *models.Domain, vmInfo connector.VMInfo) {
 			swapOut = extStats.SwappedOut
 			activePct = extStats.ActivePct
 		}
+
+		// query-balloon misses total_mem/free_mem on newer QEMU, use the guest stats instead
+		guestStats := lookupGuestStatsQMP(domain, proxmoxConn.QMPExecutor(vmInfo.VMID))
+		guestTotal, guestFree := guestStats["total"], guestStats["free"]
+		if total == 0 && guestTotal > 0 && guestTotal >= guestFree {
+			total = guestTotal / 1024
+			freeMem = guestFree / 1024
+			used = total - freeMem
+			activePct = float64(used) / float64(total) * 100
+		}
+		if swapIn == 0 && swapOut == 0 {
+			swapIn = guestStats["swapin"]
+			swapOut = guestStats["swapout"]
+		}
 	}
 
 	// Fallback to VM config memory if not available from qm status
@@ -2,26 +2,27 @@ package memcollector
 
 import (
 	"fmt"
+	"math"
 	"proxtop/config"
 	"proxtop/models"
-	"proxtop/util"
 )
 
 // formatMemKB formats a memory value in KB, converting to bytes for human-readable format
 func formatMemKB(valueKB uint64) string {
-	if config.Options.HumanReadable {
-		// Convert KB to bytes for formatting
-		return util.FormatBytes(valueKB * 1024)
-	}
-	return fmt.Sprintf("%d", valueKB)
+	return models.UnitKibibytes.Format(valueKB)
 }
 
 // formatMemBytes formats a memory value in bytes
 func formatMemBytes(valueBytes uint64) string {
-	if config.Options.HumanReadable {
-		return util.FormatBytes(valueBytes)
+	return models.UnitBytes.Format(valueBytes)
+}
+
+// formatMemRate formats a rate in bytes/s, the reset marker across a counter reset
+func formatMemRate(rate float64) string {
+	if math.IsNaN(rate) {
+		return models.ResetMarker
 	}
-	return fmt.Sprintf("%d", valueBytes)
+	return formatMemBytes(uint64(rate))
 }
 
 func domainPrint(domain *models.Domain) []string {
@@ -67,12 +68,33 @@ func domainPrint(domain *models.Domain) []string {
 	rssFmt := formatMemBytes(rssBytes)
 	actualMemFmt := formatMemKB(actualMemKB)
 
-	// Default fields: MEMSZ, GRANT, FREE, %ACTV, RSS, MCTL, MINFLT, MAJFLT
-	result := append([]string{totalFmt}, usedFmt, freeMemFmt, activePct, rssFmt, actualMemFmt, minflt, majflt)
+	// Guest statistics of the balloon driver, "-" if the guest does not report them
+	guestState, guestAge := guestStatsState(domain)
+	guestAvail, guestCache := "-", "-"
+	guestSwapIn, guestSwapOut, guestMinflt, guestMajflt := "-", "-", "-", "-"
+	guestHtlbAlloc, guestHtlbFail, guestAgeFmt := "-", "-", "-"
+	if guestState != "-" {
+		availBytes, _ := domain.GetMetricUint64Raw("ram_gs_available", 0)
+		cacheBytes, _ := domain.GetMetricUint64Raw("ram_gs_diskcaches", 0)
+		guestAvail = formatMemBytes(availBytes)
+		guestCache = formatMemBytes(cacheBytes)
+		guestSwapIn = formatMemRate(domain.GetMetricDiffUint64AsFloat("ram_gs_swapin", true))
+		guestSwapOut = formatMemRate(domain.GetMetricDiffUint64AsFloat("ram_gs_swapout", true))
+		guestMinflt = domain.GetMetricDiffUint64("ram_gs_minflt", false)
+		guestMajflt = domain.GetMetricDiffUint64("ram_gs_majflt", false)
+		guestHtlbAlloc, _ = domain.GetMetricUint64("ram_gs_htlballoc", 0)
+		guestHtlbFail, _ = domain.GetMetricUint64("ram_gs_htlbfail", 0)
+		guestAgeFmt = fmt.Sprintf("%d", guestAge)
+	}
+
+	// Default fields: MEMSZ, GRANT, FREE, %ACTV, RSS, MCTL, MINFLT, MAJFLT, AVAIL, CACHE, GSTATS
+	result := append([]string{totalFmt}, usedFmt, freeMemFmt, activePct, rssFmt, actualMemFmt, minflt, majflt,
+		guestAvail, guestCache, guestState)
 	if config.Options.Verbose {
 		maxMemFmt := formatMemKB(maxMemKB)
 		vsizeFmt := formatMemBytes(vsizeBytes)
-		result = append(result, maxMemFmt, vsizeFmt, swapIn, swapOut, cminflt, cmajflt)
+		result = append(result, maxMemFmt, vsizeFmt, swapIn, swapOut, cminflt, cmajflt,
+			guestSwapIn, guestSwapOut, guestMinflt, guestMajflt, guestHtlbAlloc, guestHtlbFail, guestAgeFmt)
 	}
 
 	return result
@@ -0,0 +1,91 @@
+package memcollector
+
+import "proxtop/models"
+
+func init() {
+	// all /proc/meminfo sizes are in KiB
+	models.RegisterFields("mem", models.ScopeHost, []models.FieldDef{
+		{Name: "mem_Total", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Physical RAM of the host"},
+		{Name: "mem_Free", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Completely unused RAM"},
+		{Name: "mem_Avail", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "RAM available for new allocations"},
+		{Name: "mem_Cached", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Page cache"},
+		{Name: "mem_Active", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Recently used memory"},
+		{Name: "mem_SwapUsed", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Used swap space"},
+		{Name: "mem_Buffers", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Kernel buffers", Verbose: true},
+		{Name: "mem_SwapCached", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Swapped out memory also in RAM", Verbose: true},
+		{Name: "mem_Inactive", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Less recently used memory", Verbose: true},
+		{Name: "mem_ActiveAnon", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Active anonymous memory", Verbose: true},
+		{Name: "mem_InactiveAnon", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Inactive anonymous memory", Verbose: true},
+		{Name: "mem_ActiveFile", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Active file-backed memory", Verbose: true},
+		{Name: "mem_InactiveFile", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Inactive file-backed memory", Verbose: true},
+		{Name: "mem_Unevictable", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory that cannot be reclaimed", Verbose: true},
+		{Name: "mem_Mlocked", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory locked with mlock()", Verbose: true},
+		{Name: "mem_SwapTotal", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Total swap space", Verbose: true},
+		{Name: "mem_SwapFree", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Unused swap space", Verbose: true},
+		{Name: "mem_Dirty", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory waiting to be written back", Verbose: true},
+		{Name: "mem_Writeback", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory being written back", Verbose: true},
+		{Name: "mem_AnonPages", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Anonymous mapped memory", Verbose: true},
+		{Name: "mem_Mapped", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Files mapped into memory", Verbose: true},
+		{Name: "mem_Shmem", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Shared memory and tmpfs", Verbose: true},
+		{Name: "mem_Slab", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Kernel slab allocator memory", Verbose: true},
+		{Name: "mem_SReclaimable", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Reclaimable slab memory", Verbose: true},
+		{Name: "mem_SUnreclaim", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Non-reclaimable slab memory", Verbose: true},
+		{Name: "mem_KernelStack", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Kernel stacks", Verbose: true},
+		{Name: "mem_PageTables", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Page tables", Verbose: true},
+		{Name: "mem_NFSUnstable", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "NFS pages not yet committed", Verbose: true},
+		{Name: "mem_Bounce", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Bounce buffers", Verbose: true},
+		{Name: "mem_WritebackTmp", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Temporary writeback buffers of FUSE", Verbose: true},
+		{Name: "mem_CommitLimit", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory that can be committed", Verbose: true},
+		{Name: "mem_CommittedAS", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory committed", Verbose: true},
+		{Name: "mem_VmallocTotal", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Total vmalloc address space", Verbose: true},
+		{Name: "mem_VmallocUsed", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Used vmalloc address space", Verbose: true},
+		{Name: "mem_VmallocChunk", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Largest free vmalloc block", Verbose: true},
+		{Name: "mem_HardwareCorrupted", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory with hardware errors", Verbose: true},
+		{Name: "mem_AnonHugePages", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Anonymous transparent huge pages", Verbose: true},
+		{Name: "mem_ShmemHugePages", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Shared memory in huge pages", Verbose: true},
+		{Name: "mem_ShmemPmdMapped", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Shared memory mapped with huge pages", Verbose: true},
+		{Name: "mem_HugePagesTotal", Kind: models.KindGauge, Description: "Huge pages configured", Verbose: true},
+		{Name: "mem_HugePagesFree", Kind: models.KindGauge, Description: "Free huge pages", Verbose: true},
+		{Name: "mem_HugePagesRsvd", Kind: models.KindGauge, Description: "Reserved huge pages", Verbose: true},
+		{Name: "mem_HugePagesSurp", Kind: models.KindGauge, Description: "Surplus huge pages", Verbose: true},
+		{Name: "mem_Hugepagesize", Kind: models.KindInfo, Unit: models.UnitKibibytes, Description: "Size of a huge page", Verbose: true},
+		{Name: "mem_Hugetlb", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory in huge pages of all sizes", Verbose: true},
+		{Name: "mem_DirectMap4k", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Kernel direct map in 4k pages", Verbose: true},
+		{Name: "mem_DirectMap2M", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Kernel direct map in 2M pages", Verbose: true},
+		{Name: "mem_DirectMap1G", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Kernel direct map in 1G pages", Verbose: true},
+	})
+
+	// esxtop style: MEMSZ (configured), GRANT (used), RSS (resident), guest statistics of the balloon driver
+	models.RegisterFields("mem", models.ScopeDomain, []models.FieldDef{
+		{Name: "mem_MEMSZ", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory configured for the VM"},
+		{Name: "mem_GRANT", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory used by the VM"},
+		{Name: "mem_FREE", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory unused by the guest"},
+		{Name: "mem_%ACTV", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Share of the VM memory in use"},
+		{Name: "mem_RSS", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Resident memory of the QEMU process"},
+		{Name: "mem_MCTL", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Memory currently assigned by the balloon driver"},
+		{Name: "mem_MINFLT", Kind: models.KindRate, Description: "Minor page faults of the QEMU process per interval"},
+		{Name: "mem_MAJFLT", Kind: models.KindRate, Description: "Major page faults of the QEMU process per interval"},
+		{Name: "mem_AVAIL", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Memory available to the guest for new allocations"},
+		{Name: "mem_CACHE", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Reclaimable page cache of the guest"},
+		{Name: "mem_GSTATS", Kind: models.KindInfo, Description: "Freshness of the guest statistics: ok, stale or -"},
+		{Name: "mem_MAXSZ", Kind: models.KindGauge, Unit: models.UnitKibibytes, Description: "Maximum memory of the VM", Verbose: true},
+		{Name: "mem_VSIZE", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Virtual memory size of the QEMU process", Verbose: true},
+		{Name: "mem_SWAPIN", Kind: models.KindCounter, Unit: models.UnitBytes, Description: "Memory swapped in by the guest", Verbose: true},
+		{Name: "mem_SWAPOUT", Kind: models.KindCounter, Unit: models.UnitBytes, Description: "Memory swapped out by the guest", Verbose: true},
+		{Name: "mem_CMINFLT", Kind: models.KindRate, Description: "Minor page faults including children per interval", Verbose: true},
+		{Name: "mem_CMAJFLT", Kind: models.KindRate, Description: "Major page faults including children per interval", Verbose: true},
+		{Name: "mem_GSWIN/s", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Memory swapped in by the guest", Verbose: true},
+		{Name: "mem_GSWOUT/s", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Memory swapped out by the guest", Verbose: true},
+		{Name: "mem_GMINFLT", Kind: models.KindRate, Description: "Minor page faults inside the guest per interval", Verbose: true},
+		{Name: "mem_GMAJFLT", Kind: models.KindRate, Description: "Major page faults inside the guest per interval", Verbose: true},
+		{Name: "mem_HTLBALLOC", Kind: models.KindCounter, Description: "Successful hugetlb page allocations in the guest", Verbose: true},
+		{Name: "mem_HTLBFAIL", Kind: models.KindCounter, Description: "Failed hugetlb page allocations in the guest", Verbose: true},
+		{Name: "mem_GSAGE", Kind: models.KindGauge, Unit: models.UnitSeconds, Description: "Age of the last guest statistics report", Verbose: true},
+	})
+
+	// raw counters for exporters
+	models.RegisterCounters("mem", models.ScopeDomain, []models.CounterDef{
+		{Metric: "ram_minflt", Name: "mem_minor_faults", Description: "Minor page faults of the QEMU process"},
+		{Metric: "ram_majflt", Name: "mem_major_faults", Description: "Major page faults of the QEMU process"},
+	})
+}
@@ -0,0 +1,145 @@
+package memcollector
+
+import (
+	"fmt"
+	"time"
+
+	"proxtop/connector"
+	"proxtop/models"
+
+	libvirt "github.com/libvirt/libvirt-go"
+)
+
+// guestStatsInterval is the polling interval (seconds) of the balloon driver's guest statistics
+const guestStatsInterval = 2
+
+// guest statistics are stale if the guest did not report for this many polling intervals
+const guestStatsStaleIntervals = 3
+
+// guest statistics names, all memory values are stored in bytes
+var guestStatsNames = []string{
+	"available",
+	"diskcaches",
+	"free",
+	"total",
+	"swapin",
+	"swapout",
+	"majflt",
+	"minflt",
+	"htlballoc",
+	"htlbfail",
+}
+
+// qmpGuestStats maps guest statistics to the names of qom-get guest-stats
+var qmpGuestStats = map[string]string{
+	"available":  "stat-available-memory",
+	"diskcaches": "stat-disk-caches",
+	"free":       "stat-free-memory",
+	"total":      "stat-total-memory",
+	"swapin":     "stat-swap-in",
+	"swapout":    "stat-swap-out",
+	"majflt":     "stat-major-faults",
+	"minflt":     "stat-minor-faults",
+	"htlballoc":  "stat-htlb-pgalloc",
+	"htlbfail":   "stat-htlb-pgfail",
+}
+
+// libvirtGuestStats maps guest statistics to libvirt memory stat tags (memory values in KiB)
+var libvirtGuestStats = map[string]libvirt.DomainMemoryStatTags{
+	"available":  libvirt.DOMAIN_MEMORY_STAT_USABLE,
+	"diskcaches": libvirt.DOMAIN_MEMORY_STAT_DISK_CACHES,
+	"free":       libvirt.DOMAIN_MEMORY_STAT_UNUSED,
+	"total":      libvirt.DOMAIN_MEMORY_STAT_AVAILABLE,
+	"swapin":     libvirt.DOMAIN_MEMORY_STAT_SWAP_IN,
+	"swapout":    libvirt.DOMAIN_MEMORY_STAT_SWAP_OUT,
+	"majflt":     libvirt.DOMAIN_MEMORY_STAT_MAJOR_FAULT,
+	"minflt":     libvirt.DOMAIN_MEMORY_STAT_MINOR_FAULT,
+	"htlballoc":  libvirt.DOMAIN_MEMORY_STAT_HUGETLB_PGALLOC,
+	"htlbfail":   libvirt.DOMAIN_MEMORY_STAT_HUGETLB_PGFAIL,
+}
+
+// libvirt reports these in KiB
+var libvirtGuestStatsKiB = map[string]bool{
+	"available":  true,
+	"diskcaches": true,
+	"free":       true,
+	"total":      true,
+	"swapin":     true,
+	"swapout":    true,
+}
+
+// lookupGuestStatsQMP enables guest stats polling via qom-set and reads qom-get guest-stats
+func lookupGuestStatsQMP(domain *models.Domain, execute connector.QMPExecutor) map[string]uint64 {
+	values := make(map[string]uint64)
+	stats, err := connector.GetGuestStats(fmt.Sprint(domain.UUID, "/", domain.PID), execute, guestStatsInterval)
+	if err != nil {
+		storeGuestStats(domain, values, 0, 0)
+		return values
+	}
+	for name, qmpName := range qmpGuestStats {
+		if value, ok := stats.Get(qmpName); ok {
+			values[name] = value
+		}
+	}
+	storeGuestStats(domain, values, stats.LastUpdate, stats.Interval)
+	return values
+}
+
+// lookupGuestStatsLibvirt enables guest stats polling via libvirt, which uses the same balloon
+// properties, and reads the statistics from the memory stats
+func lookupGuestStatsLibvirt(domain *models.Domain, libvirtDomain libvirt.Domain, memStats []libvirt.DomainMemoryStat) {
+	values := make(map[string]uint64)
+	tags := make(map[int32]uint64)
+	for _, stat := range memStats {
+		tags[stat.Tag] = stat.Val
+	}
+
+	lastUpdate, polled := tags[int32(libvirt.DOMAIN_MEMORY_STAT_LAST_UPDATE)]
+	if !polled {
+		start := time.Now()
+		err := libvirtDomain.SetMemoryStatsPeriod(guestStatsInterval, libvirt.DOMAIN_MEM_LIVE)
+		models.RecordCall(models.CallLibvirt, start, err)
+		storeGuestStats(domain, values, 0, 0)
+		return
+	}
+
+	for name, tag := range libvirtGuestStats {
+		if value, ok := tags[int32(tag)]; ok {
+			if libvirtGuestStatsKiB[name] {
+				value *= 1024
+			}
+			values[name] = value
+		}
+	}
+	storeGuestStats(domain, values, int64(lastUpdate), guestStatsInterval)
+}
+
+// storeGuestStats stores the guest statistics, missing values are stored as 0
+func storeGuestStats(domain *models.Domain, values map[string]uint64, lastUpdate int64, interval int64) {
+	for _, name := range guestStatsNames {
+		domain.AddMetricMeasurement(fmt.Sprint("ram_gs_", name), models.CreateMeasurement(values[name]))
+	}
+	if lastUpdate < 0 {
+		lastUpdate = 0
+	}
+	domain.AddMetricMeasurement("ram_gs_lastupdate", models.CreateMeasurement(uint64(lastUpdate)))
+	domain.AddMetricMeasurement("ram_gs_interval", models.CreateMeasurement(uint64(interval)))
+}
+
+// guestStatsState returns the freshness of the guest statistics: "ok", "stale" if the guest
+// stopped reporting, or "-" if the guest never reported
+func guestStatsState(domain *models.Domain) (string, uint64) {
+	lastUpdate, _ := domain.GetMetricUint64Raw("ram_gs_lastupdate", 0)
+	interval, _ := domain.GetMetricUint64Raw("ram_gs_interval", 0)
+	if lastUpdate == 0 || interval == 0 {
+		return "-", 0
+	}
+	var age uint64
+	if now := uint64(time.Now().Unix()); now > lastUpdate {
+		age = now - lastUpdate
+	}
+	if age > guestStatsStaleIntervals*interval {
+		return "stale", age
+	}
+	return "ok", age
+}
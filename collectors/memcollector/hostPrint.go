@@ -5,15 +5,11 @@ import (
 
 	"proxtop/config"
 	"proxtop/models"
-	"proxtop/util"
 )
 
 // formatHostMemKB formats a host memory value (all /proc/meminfo values are in KB)
 func formatHostMemKB(valueKB uint64) string {
-	if config.Options.HumanReadable {
-		return util.FormatBytes(valueKB * 1024)
-	}
-	return fmt.Sprintf("%d", valueKB)
+	return models.UnitKibibytes.Format(valueKB)
 }
 
 func hostPrint(host *models.Host) []string {
@@ -0,0 +1,64 @@
+package migratecollector
+
+import (
+	"proxtop/config"
+	"proxtop/connector"
+	"proxtop/models"
+)
+
+// Collector describes the live migration collector
+type Collector struct {
+	models.Collector
+}
+
+// Lookup migration collector data
+func (collector *Collector) Lookup() {
+	// migrations of VMs which are gone (source VM stopped after hand over) are finished
+	finishVanishedMigrations()
+}
+
+// Collect migration collector data
+func (collector *Collector) Collect() {
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		if connector.IsProxmox() {
+			vmInfo, ok := connector.ProxmoxVMStore.Load(uuid)
+			if ok {
+				domainCollectProxmox(&domain, vmInfo)
+			}
+		} else {
+			libvirtDomain, ok := models.Collection.LibvirtDomains.Load(uuid)
+			if ok {
+				domainCollect(&domain, libvirtDomain)
+			}
+		}
+		return true
+	})
+}
+
+// Print returns the collectors measurements in a Printable struct
+func (collector *Collector) Print() models.Printable {
+	// fields are declared in fields.go
+	printable := models.Printable{
+		HostFields:   []string{},
+		DomainFields: models.FieldNames("migrate", models.ScopeDomain, config.Options.Verbose),
+	}
+
+	// lookup for each domain
+	printable.DomainValues = make(map[string][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		printable.DomainValues[uuid] = domainPrint(&domain)
+		return true
+	})
+	printable.AddDevices(models.DevicesMigrate, DomainMigrationFields(), DomainPrintPerMigration())
+
+	return printable
+}
+
+// CreateCollector creates a new migration collector
+func CreateCollector() Collector {
+	return Collector{}
+}
@@ -0,0 +1,153 @@
+package migratecollector
+
+import (
+	"time"
+
+	"proxtop/connector"
+	"proxtop/models"
+
+	libvirt "github.com/libvirt/libvirt-go"
+)
+
+// migrationStats holds the statistics of an outgoing live migration, times are in ms
+type migrationStats struct {
+	status           string
+	transferred      uint64
+	remaining        uint64
+	total            uint64
+	dirtyRate        uint64 // pages/s
+	pageSize         uint64
+	bps              uint64 // bytes/s
+	expectedDowntime uint64
+	downtime         uint64
+	setupTime        uint64
+	totalTime        uint64
+	iterations       uint64
+	errorDesc        string
+}
+
+// isActive returns true for the QMP migration states of a running migration
+// (setup, active, postcopy-*, pre-switchover, device, wait-unplug, cancelling)
+func isActive(status string) bool {
+	switch status {
+	case "", "none", "completed", "failed", "cancelled":
+		return false
+	}
+	return true
+}
+
+// domainCollectProxmox polls query-migrate for VMs locked for migration or with a migration in progress
+func domainCollectProxmox(domain *models.Domain, vmInfo connector.VMInfo) {
+	if vmInfo.Lock != "migrate" && !isTracked(domain.UUID) {
+		storeMigration(domain, migrationStats{})
+		return
+	}
+	proxmoxConn, ok := connector.CurrentConnector.(*connector.ProxmoxConnector)
+	if !ok {
+		return
+	}
+
+	info, err := connector.GetMigrationInfo(proxmoxConn.QMPExecutor(vmInfo.VMID))
+	if err != nil {
+		// QEMU may be stopping after the hand over, keep the last values
+		return
+	}
+
+	stats := migrationStats{
+		status:           info.Status,
+		expectedDowntime: info.ExpectedDowntime,
+		downtime:         info.Downtime,
+		setupTime:        info.SetupTime,
+		totalTime:        info.TotalTime,
+		errorDesc:        info.ErrorDesc,
+	}
+	if info.RAM != nil {
+		stats.transferred = info.RAM.Transferred
+		stats.remaining = info.RAM.Remaining
+		stats.total = info.RAM.Total
+		stats.dirtyRate = info.RAM.DirtyPagesRate
+		stats.pageSize = info.RAM.PageSize
+		stats.iterations = info.RAM.DirtySyncCount
+		// mbps is in megabit (10^6) per second
+		stats.bps = uint64(info.RAM.Mbps * 1000 * 1000 / 8)
+	}
+	updateMigration(domain, stats)
+}
+
+// domainCollect reads the job statistics of outgoing libvirt migrations (virDomainGetJobStats)
+func domainCollect(domain *models.Domain, libvirtDomain libvirt.Domain) {
+	start := time.Now()
+	jobStats, err := libvirtDomain.GetJobStats(0)
+	models.RecordCall(models.CallLibvirt, start, err)
+	if err == nil && jobStats.Type != libvirt.DOMAIN_JOB_NONE &&
+		jobStats.OperationSet && jobStats.Operation == libvirt.DOMAIN_JOB_OPERATION_MIGRATION_OUT {
+		updateMigration(domain, libvirtMigrationStats(jobStats, "active"))
+		return
+	}
+
+	last, tracked := lastMigrationStats(domain.UUID)
+	if !tracked {
+		storeMigration(domain, migrationStats{})
+		return
+	}
+
+	// job ended while the VM is still running here, the completed job tells the result
+	stats := last
+	stats.status = "failed"
+	start = time.Now()
+	completed, err := libvirtDomain.GetJobStats(libvirt.DOMAIN_JOB_STATS_COMPLETED)
+	models.RecordCall(models.CallLibvirt, start, err)
+	if err == nil {
+		switch completed.Type {
+		case libvirt.DOMAIN_JOB_COMPLETED:
+			stats = libvirtMigrationStats(completed, "completed")
+		case libvirt.DOMAIN_JOB_CANCELLED:
+			stats = libvirtMigrationStats(completed, "cancelled")
+		case libvirt.DOMAIN_JOB_FAILED:
+			stats = libvirtMigrationStats(completed, "failed")
+		}
+	}
+	updateMigration(domain, stats)
+}
+
+// libvirtMigrationStats converts libvirt job statistics, Downtime is the expected downtime while active
+func libvirtMigrationStats(jobStats *libvirt.DomainJobInfo, status string) migrationStats {
+	stats := migrationStats{
+		status:      status,
+		transferred: jobStats.MemProcessed,
+		remaining:   jobStats.MemRemaining,
+		total:       jobStats.MemTotal,
+		dirtyRate:   jobStats.MemDirtyRate,
+		pageSize:    jobStats.MemPageSize,
+		bps:         jobStats.MemBps,
+		setupTime:   jobStats.SetupTime,
+		totalTime:   jobStats.TimeElapsed,
+		iterations:  jobStats.MemIteration,
+	}
+	if status == "active" {
+		stats.expectedDowntime = jobStats.Downtime
+	} else {
+		stats.downtime = jobStats.Downtime
+	}
+	return stats
+}
+
+// storeMigration stores the migration statistics, mig_status is "-" for VMs without migration
+func storeMigration(domain *models.Domain, stats migrationStats) {
+	status := stats.status
+	if status == "" || status == "none" {
+		status = "-"
+	}
+	domain.AddMetricMeasurement("mig_status", models.CreateMeasurement(status))
+	domain.AddMetricMeasurement("mig_transferred", models.CreateMeasurement(stats.transferred))
+	domain.AddMetricMeasurement("mig_remaining", models.CreateMeasurement(stats.remaining))
+	domain.AddMetricMeasurement("mig_total", models.CreateMeasurement(stats.total))
+	domain.AddMetricMeasurement("mig_dirtyrate", models.CreateMeasurement(stats.dirtyRate))
+	domain.AddMetricMeasurement("mig_pagesize", models.CreateMeasurement(stats.pageSize))
+	domain.AddMetricMeasurement("mig_bps", models.CreateMeasurement(stats.bps))
+	domain.AddMetricMeasurement("mig_expdowntime", models.CreateMeasurement(stats.expectedDowntime))
+	domain.AddMetricMeasurement("mig_downtime", models.CreateMeasurement(stats.downtime))
+	domain.AddMetricMeasurement("mig_setuptime", models.CreateMeasurement(stats.setupTime))
+	domain.AddMetricMeasurement("mig_totaltime", models.CreateMeasurement(stats.totalTime))
+	domain.AddMetricMeasurement("mig_iterations", models.CreateMeasurement(stats.iterations))
+}
@@ -0,0 +1,100 @@
+package migratecollector
+
+import (
+	"fmt"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// formatBytes formats a value in bytes
+func formatBytes(valueBytes uint64) string {
+	return models.UnitBytes.Format(valueBytes)
+}
+
+// migrationStatus returns the migration state of the domain, "-" without migration
+func migrationStatus(domain *models.Domain) string {
+	status := domain.GetMetricString("mig_status", 0)
+	if status == "" {
+		return "-"
+	}
+	return status
+}
+
+// migrationPercent returns the share of RAM already sent
+func migrationPercent(domain *models.Domain) string {
+	total, _ := domain.GetMetricUint64Raw("mig_total", 0)
+	remaining, _ := domain.GetMetricUint64Raw("mig_remaining", 0)
+	if total == 0 || remaining > total {
+		return "0"
+	}
+	return fmt.Sprintf("%.0f", float64(total-remaining)/float64(total)*100)
+}
+
+func domainPrint(domain *models.Domain) []string {
+	status := migrationStatus(domain)
+	if status == "-" {
+		result := []string{"-", "-"}
+		if config.Options.Verbose {
+			result = append(result, "-", "-")
+		}
+		return result
+	}
+
+	result := []string{status, migrationPercent(domain)}
+	if config.Options.Verbose {
+		remaining, _ := domain.GetMetricUint64Raw("mig_remaining", 0)
+		expectedDowntime, _ := domain.GetMetricUint64("mig_expdowntime", 0)
+		result = append(result, formatBytes(remaining), expectedDowntime)
+	}
+	return result
+}
+
+// DomainMigrationFields returns the field names for the migration view
+func DomainMigrationFields() []string {
+	return models.DeviceFieldNames(models.DevicesMigrate, config.Options.Verbose)
+}
+
+// DomainPrintPerMigration returns the VMs with a current or finished migration for the migration view
+// Returns a map of UUID -> []string (field values in same order as DomainMigrationFields)
+func DomainPrintPerMigration() map[string][]string {
+	result := make(map[string][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		status := migrationStatus(&domain)
+		if status == "-" {
+			return true
+		}
+
+		transferred, _ := domain.GetMetricUint64Raw("mig_transferred", 0)
+		remaining, _ := domain.GetMetricUint64Raw("mig_remaining", 0)
+		total, _ := domain.GetMetricUint64Raw("mig_total", 0)
+		dirtyRate, _ := domain.GetMetricUint64Raw("mig_dirtyrate", 0)
+		pageSize, _ := domain.GetMetricUint64Raw("mig_pagesize", 0)
+		bps, _ := domain.GetMetricUint64Raw("mig_bps", 0)
+		expectedDowntime, _ := domain.GetMetricUint64("mig_expdowntime", 0)
+		downtime, _ := domain.GetMetricUint64("mig_downtime", 0)
+		setupTime, _ := domain.GetMetricUint64("mig_setuptime", 0)
+		totalTime, _ := domain.GetMetricUint64Raw("mig_totaltime", 0)
+		iterations, _ := domain.GetMetricUint64("mig_iterations", 0)
+
+		result[uuid] = []string{
+			domain.Name,
+			status,
+			migrationPercent(&domain),
+			formatBytes(transferred),
+			formatBytes(remaining),
+			formatBytes(total),
+			formatBytes(dirtyRate * pageSize),
+			formatBytes(bps),
+			expectedDowntime,
+			downtime,
+			setupTime,
+			fmt.Sprintf("%.1f", float64(totalTime)/1000),
+			iterations,
+		}
+		return true
+	})
+	return result
+}
@@ -0,0 +1,127 @@
+package migratecollector
+
+import (
+	"sync"
+	"time"
+
+	"proxtop/models"
+)
+
+// migration is a migration in progress
+type migration struct {
+	name  string
+	stats migrationStats
+}
+
+var migrationsMu sync.Mutex
+var migrations = make(map[string]*migration)
+
+// migrationEvent is the summary event emitted when a migration completed or failed
+type migrationEvent struct {
+	Event              string `json:"event"`
+	Time               string `json:"time"`
+	UUID               string `json:"uuid"`
+	Name               string `json:"name"`
+	Status             string `json:"status"`
+	Error              string `json:"error,omitempty"`
+	Detail             string `json:"detail,omitempty"`
+	TotalTimeMs        uint64 `json:"total_time_ms"`
+	SetupTimeMs        uint64 `json:"setup_time_ms"`
+	DowntimeMs         uint64 `json:"downtime_ms"`
+	ExpectedDowntimeMs uint64 `json:"expected_downtime_ms"`
+	RAMTotal           uint64 `json:"ram_total_bytes"`
+	RAMTransferred     uint64 `json:"ram_transferred_bytes"`
+	RAMRemaining       uint64 `json:"ram_remaining_bytes"`
+	Throughput         uint64 `json:"throughput_bytes_per_second"`
+	Iterations         uint64 `json:"dirty_sync_count"`
+}
+
+// isTracked returns true if a migration of the VM is in progress
+func isTracked(uuid string) bool {
+	_, tracked := lastMigrationStats(uuid)
+	return tracked
+}
+
+// lastMigrationStats returns the last statistics of the migration in progress
+func lastMigrationStats(uuid string) (migrationStats, bool) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+	if tracked, ok := migrations[uuid]; ok {
+		return tracked.stats, true
+	}
+	return migrationStats{}, false
+}
+
+// updateMigration stores the statistics and tracks the migration while active,
+// the summary event is emitted when a tracked migration ends
+func updateMigration(domain *models.Domain, stats migrationStats) {
+	migrationsMu.Lock()
+	tracked, ok := migrations[domain.UUID]
+	finished := false
+	if isActive(stats.status) {
+		if !ok {
+			tracked = &migration{}
+			migrations[domain.UUID] = tracked
+		}
+		tracked.name = domain.Name
+		tracked.stats = stats
+	} else if ok {
+		delete(migrations, domain.UUID)
+		finished = true
+	}
+	migrationsMu.Unlock()
+
+	storeMigration(domain, stats)
+	if finished {
+		emitMigrationEvent(domain.UUID, domain.Name, stats, "")
+	}
+}
+
+// finishVanishedMigrations ends migrations of VMs which are gone. The source VM is stopped after a
+// successful hand over, but may also have been stopped or crashed, so the result is unknown unless
+// the last status read was completed.
+func finishVanishedMigrations() {
+	migrationsMu.Lock()
+	vanished := make(map[string]migration)
+	for uuid, tracked := range migrations {
+		if _, ok := models.Collection.Domains.Load(uuid); !ok {
+			vanished[uuid] = *tracked
+			delete(migrations, uuid)
+		}
+	}
+	migrationsMu.Unlock()
+
+	for uuid, tracked := range vanished {
+		stats := tracked.stats
+		if stats.status != "completed" {
+			stats.status = "unknown"
+		}
+		emitMigrationEvent(uuid, tracked.name, stats, "source VM gone before the final state was read")
+	}
+}
+
+// emitMigrationEvent writes the summary event of a finished migration
+func emitMigrationEvent(uuid string, name string, stats migrationStats, detail string) {
+	event := migrationEvent{
+		Event:              "migration",
+		Time:               time.Now().Format(time.RFC3339),
+		UUID:               uuid,
+		Name:               name,
+		Status:             stats.status,
+		Error:              stats.errorDesc,
+		Detail:             detail,
+		TotalTimeMs:        stats.totalTime,
+		SetupTimeMs:        stats.setupTime,
+		DowntimeMs:         stats.downtime,
+		ExpectedDowntimeMs: stats.expectedDowntime,
+		RAMTotal:           stats.total,
+		RAMTransferred:     stats.transferred,
+		RAMRemaining:       stats.remaining,
+		Iterations:         stats.iterations,
+	}
+	// average throughput over the whole migration
+	if stats.totalTime > 0 {
+		event.Throughput = stats.transferred * 1000 / stats.totalTime
+	}
+	models.RecordEvent(event)
+}
@@ -0,0 +1,29 @@
+package migratecollector
+
+import "proxtop/models"
+
+func init() {
+	models.RegisterFields("migrate", models.ScopeDomain, []models.FieldDef{
+		{Name: "mig_STATE", Kind: models.KindInfo, Description: "Migration state, - without migration"},
+		{Name: "mig_%DONE", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Share of RAM already sent"},
+		{Name: "mig_REMAIN", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "RAM still to send", Verbose: true},
+		{Name: "mig_EXPDT", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Expected downtime", Verbose: true},
+	})
+
+	// migration view 'g'
+	models.RegisterDeviceFields("migrate", []string{models.DevicesMigrate}, []models.FieldDef{
+		{Name: "mig_VM", Kind: models.KindInfo, Description: "Migrating VM"},
+		{Name: "mig_STATE", Kind: models.KindInfo, Description: "Migration state"},
+		{Name: "mig_%DONE", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Share of RAM already sent"},
+		{Name: "mig_XFER", Kind: models.KindCounter, Unit: models.UnitBytes, Description: "RAM sent, including pages sent again"},
+		{Name: "mig_REMAIN", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "RAM still to send"},
+		{Name: "mig_TOTAL", Kind: models.KindInfo, Unit: models.UnitBytes, Description: "RAM of the VM"},
+		{Name: "mig_DIRTY/s", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Memory dirtied by the guest"},
+		{Name: "mig_XFER/s", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Migration throughput"},
+		{Name: "mig_EXPDT", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Expected downtime while active"},
+		{Name: "mig_DOWNTIME", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Final downtime once completed"},
+		{Name: "mig_SETUP", Kind: models.KindGauge, Unit: models.UnitMilliseconds, Description: "Setup time"},
+		{Name: "mig_TIME", Kind: models.KindGauge, Unit: models.UnitSeconds, Description: "Time since start"},
+		{Name: "mig_ITER", Kind: models.KindCounter, Description: "Dirty memory sync rounds"},
+	})
+}
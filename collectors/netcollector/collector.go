@@ -13,7 +13,7 @@ type Collector struct {
 
 // Lookup network collector data
 func (collector *Collector) Lookup() {
-	models.Collection.Domains.Range(func(key, value interface{}) bool {
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
 		uuid := key.(string)
 		domain := value.(models.Domain)
 		if connector.IsProxmox() {
@@ -34,7 +34,7 @@ func (collector *Collector) Lookup() {
 // Collect network collector data
 func (collector *Collector) Collect() {
 	// lookup for each domain
-	models.Collection.Domains.Range(func(key, value interface{}) bool {
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
 		// uuid := key.(string)
 		domain := value.(models.Domain)
 		domainCollect(&domain)
@@ -45,55 +45,10 @@ func (collector *Collector) Collect() {
 
 // Print returns the collectors measurements in a Printable struct
 func (collector *Collector) Print() models.Printable {
-	// Host fields - esxtop style
-	hostFields := []string{
-		"net_MbRX/s",
-		"net_MbTX/s",
-		"net_PKTRX/s",
-		"net_PKTTX/s",
-		"net_speed",
-	}
-	// Domain fields - esxtop style: MbRX/s, MbTX/s, PKTRX/s, PKTTX/s, %DRPRX, %DRPTX
-	domainFields := []string{
-		"net_MbRX/s",
-		"net_MbTX/s",
-		"net_PKTRX/s",
-		"net_PKTTX/s",
-		"net_%DRPRX",
-		"net_%DRPTX",
-	}
-	if config.Options.Verbose {
-		hostFields = append(hostFields,
-			"net_host_errsRX",
-			"net_host_dropRX",
-			"net_host_fifoRX",
-			"net_host_frameRX",
-			"net_host_compRX",
-			"net_host_mcastRX",
-			"net_host_errsTX",
-			"net_host_dropTX",
-			"net_host_fifoTX",
-			"net_host_collsTX",
-			"net_host_carrierTX",
-			"net_host_compTX",
-		)
-		domainFields = append(domainFields,
-			"net_errsRX",
-			"net_fifoRX",
-			"net_frameRX",
-			"net_compRX",
-			"net_mcastRX",
-			"net_errsTX",
-			"net_fifoTX",
-			"net_collsTX",
-			"net_carrierTX",
-			"net_compTX",
-			"net_interfaces",
-		)
-	}
+	// fields are declared in fields.go
 	printable := models.Printable{
-		HostFields:   hostFields,
-		DomainFields: domainFields,
+		HostFields:   models.FieldNames("net", models.ScopeHost, config.Options.Verbose),
+		DomainFields: DomainNetFields(),
 	}
 
 	// lookup for each domain
@@ -102,11 +57,13 @@ func (collector *Collector) Print() models.Printable {
 		uuid := key.(string)
 		domain := value.(models.Domain)
 		printable.DomainValues[uuid] = domainPrint(&domain)
+		printable.AddDomainDevices(uuid, models.DomainDevicesNet, printable.DomainFields, DomainPrintPerInterface(&domain))
 		return true
 	})
 
 	// lookup for host
 	printable.HostValues = hostPrint(&models.Collection.Host)
+	printable.AddDevices(models.DevicesNet, HostNetFields(), HostPrintPerDevice())
 
 	return printable
 }
@@ -2,6 +2,7 @@ package netcollector
 
 import (
 	"fmt"
+	"hash/fnv"
 
 	"proxtop/models"
 	"proxtop/util"
@@ -18,26 +19,30 @@ func domainCollect(domain *models.Domain) {
 	// get stats from net/dev for domain interfaces
 	ifs := domain.GetMetricStringArray("net_interfaces")
 	statsSum := util.ProcPIDNetDev{}
+	// the totals start again when an interface is added, removed or recreated
+	sumIdentity := fnv.New64a()
 	for _, devname := range ifs {
 		devStats := util.GetProcPIDNetDev(domain.PID, devname)
+		ifindex := util.GetSysNetIfindex(devname)
+		fmt.Fprintf(sumIdentity, "%s:%d;", devname, ifindex)
 
 		// Store per-interface stats with device name suffix
-		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedBytes_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedBytes)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedPackets_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedPackets)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedErrs_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedErrs)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedDrop_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedDrop)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedFifo_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedFifo)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedFrame_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedFrame)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedCompressed_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedCompressed)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedMulticast_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedMulticast)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedBytes_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedBytes)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedPackets_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedPackets)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedErrs_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedErrs)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedDrop_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedDrop)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedFifo_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedFifo)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedColls_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedColls)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedCarrier_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedCarrier)))
-		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedCompressed_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedCompressed)))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedBytes_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedBytes)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedPackets_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedPackets)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedErrs_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedErrs)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedDrop_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedDrop)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedFifo_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedFifo)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedFrame_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedFrame)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedCompressed_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedCompressed)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_ReceivedMulticast_%s", devname), models.CreateMeasurement(uint64(devStats.ReceivedMulticast)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedBytes_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedBytes)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedPackets_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedPackets)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedErrs_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedErrs)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedDrop_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedDrop)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedFifo_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedFifo)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedColls_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedColls)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedCarrier_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedCarrier)).WithIdentity(ifindex))
+		domain.AddMetricMeasurement(fmt.Sprintf("net_TransmittedCompressed_%s", devname), models.CreateMeasurement(uint64(devStats.TransmittedCompressed)).WithIdentity(ifindex))
 
 		// Sum for totals
 		statsSum.ReceivedBytes += devStats.ReceivedBytes
@@ -58,21 +63,23 @@ func domainCollect(domain *models.Domain) {
 		statsSum.TransmittedCarrier += devStats.TransmittedCarrier
 		statsSum.TransmittedCompressed += devStats.TransmittedCompressed
 	}
+	identity := sumIdentity.Sum64()
+
 	// Store totals (for backward compatibility)
-	domain.AddMetricMeasurement("net_ReceivedBytes", models.CreateMeasurement(uint64(statsSum.ReceivedBytes)))
-	domain.AddMetricMeasurement("net_ReceivedPackets", models.CreateMeasurement(uint64(statsSum.ReceivedPackets)))
-	domain.AddMetricMeasurement("net_ReceivedErrs", models.CreateMeasurement(uint64(statsSum.ReceivedErrs)))
-	domain.AddMetricMeasurement("net_ReceivedDrop", models.CreateMeasurement(uint64(statsSum.ReceivedDrop)))
-	domain.AddMetricMeasurement("net_ReceivedFifo", models.CreateMeasurement(uint64(statsSum.ReceivedFifo)))
-	domain.AddMetricMeasurement("net_ReceivedFrame", models.CreateMeasurement(uint64(statsSum.ReceivedFrame)))
-	domain.AddMetricMeasurement("net_ReceivedCompressed", models.CreateMeasurement(uint64(statsSum.ReceivedCompressed)))
-	domain.AddMetricMeasurement("net_ReceivedMulticast", models.CreateMeasurement(uint64(statsSum.ReceivedMulticast)))
-	domain.AddMetricMeasurement("net_TransmittedBytes", models.CreateMeasurement(uint64(statsSum.TransmittedBytes)))
-	domain.AddMetricMeasurement("net_TransmittedPackets", models.CreateMeasurement(uint64(statsSum.TransmittedPackets)))
-	domain.AddMetricMeasurement("net_TransmittedErrs", models.CreateMeasurement(uint64(statsSum.TransmittedErrs)))
-	domain.AddMetricMeasurement("net_TransmittedDrop", models.CreateMeasurement(uint64(statsSum.TransmittedDrop)))
-	domain.AddMetricMeasurement("net_TransmittedFifo", models.CreateMeasurement(uint64(statsSum.TransmittedFifo)))
-	domain.AddMetricMeasurement("net_TransmittedColls", models.CreateMeasurement(uint64(statsSum.TransmittedColls)))
-	domain.AddMetricMeasurement("net_TransmittedCarrier", models.CreateMeasurement(uint64(statsSum.TransmittedCarrier)))
-	domain.AddMetricMeasurement("net_TransmittedCompressed", models.CreateMeasurement(uint64(statsSum.TransmittedCompressed)))
+	domain.AddMetricMeasurement("net_ReceivedBytes", models.CreateMeasurement(uint64(statsSum.ReceivedBytes)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_ReceivedPackets", models.CreateMeasurement(uint64(statsSum.ReceivedPackets)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_ReceivedErrs", models.CreateMeasurement(uint64(statsSum.ReceivedErrs)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_ReceivedDrop", models.CreateMeasurement(uint64(statsSum.ReceivedDrop)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_ReceivedFifo", models.CreateMeasurement(uint64(statsSum.ReceivedFifo)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_ReceivedFrame", models.CreateMeasurement(uint64(statsSum.ReceivedFrame)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_ReceivedCompressed", models.CreateMeasurement(uint64(statsSum.ReceivedCompressed)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_ReceivedMulticast", models.CreateMeasurement(uint64(statsSum.ReceivedMulticast)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_TransmittedBytes", models.CreateMeasurement(uint64(statsSum.TransmittedBytes)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_TransmittedPackets", models.CreateMeasurement(uint64(statsSum.TransmittedPackets)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_TransmittedErrs", models.CreateMeasurement(uint64(statsSum.TransmittedErrs)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_TransmittedDrop", models.CreateMeasurement(uint64(statsSum.TransmittedDrop)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_TransmittedFifo", models.CreateMeasurement(uint64(statsSum.TransmittedFifo)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_TransmittedColls", models.CreateMeasurement(uint64(statsSum.TransmittedColls)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_TransmittedCarrier", models.CreateMeasurement(uint64(statsSum.TransmittedCarrier)).WithIdentity(identity))
+	domain.AddMetricMeasurement("net_TransmittedCompressed", models.CreateMeasurement(uint64(statsSum.TransmittedCompressed)).WithIdentity(identity))
 }
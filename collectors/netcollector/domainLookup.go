@@ -2,6 +2,7 @@ package netcollector
 
 import (
 	"fmt"
+	"time"
 
 	"proxtop/connector"
 	"proxtop/models"
@@ -22,7 +23,9 @@ func domainLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
 	}*/
 
 	var ifs []string
-	xmldoc, _ := libvirtDomain.GetXMLDesc(libvirt.DOMAIN_XML_SECURE)
+	start := time.Now()
+	xmldoc, err := libvirtDomain.GetXMLDesc(libvirt.DOMAIN_XML_SECURE)
+	models.RecordCall(models.CallLibvirt, start, err)
 	domcfg := &libvirtxml.Domain{}
 	domcfg.Unmarshal(xmldoc)
 
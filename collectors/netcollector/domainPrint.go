@@ -8,6 +8,11 @@ import (
 	"proxtop/models"
 )
 
+// DomainNetFields returns the domain field names of the network collector
+func DomainNetFields() []string {
+	return models.FieldNames("net", models.ScopeDomain, config.Options.Verbose)
+}
+
 func domainPrint(domain *models.Domain) []string {
 	// Get raw float values for calculations
 	receivedBytesFloat := domain.GetMetricDiffUint64AsFloat("net_ReceivedBytes", true)
@@ -58,7 +63,7 @@ func domainPrint(domain *models.Domain) []string {
 }
 
 // DomainPrintPerInterface returns per-interface stats for a domain
-// Returns a map of interface name -> []string (same format as domainPrint)
+// Returns a map of interface name -> []string (field values in same order as DomainNetFields)
 func DomainPrintPerInterface(domain *models.Domain) map[string][]string {
 	ifsRaw := domain.GetMetricStringArray("net_interfaces")
 	result := make(map[string][]string)
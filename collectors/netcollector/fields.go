@@ -0,0 +1,90 @@
+package netcollector
+
+import "proxtop/models"
+
+func init() {
+	// esxtop style: MbRX/s, MbTX/s, PKTRX/s, PKTTX/s, summed over the physical interfaces of the virtual traffic
+	models.RegisterFields("net", models.ScopeHost, []models.FieldDef{
+		{Name: "net_MbRX/s", Kind: models.KindRate, Unit: models.UnitMbitPerSecond, Description: "Received traffic of the host interfaces"},
+		{Name: "net_MbTX/s", Kind: models.KindRate, Unit: models.UnitMbitPerSecond, Description: "Transmitted traffic of the host interfaces"},
+		{Name: "net_PKTRX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Received packets of the host interfaces"},
+		{Name: "net_PKTTX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Transmitted packets of the host interfaces"},
+		{Name: "net_speed", Kind: models.KindInfo, Unit: models.UnitMbitPerSecond, Description: "Link speed of the host interfaces"},
+		{Name: "net_host_errsRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Receive errors", Verbose: true},
+		{Name: "net_host_dropRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Dropped received packets", Verbose: true},
+		{Name: "net_host_fifoRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "FIFO buffer errors on receive", Verbose: true},
+		{Name: "net_host_frameRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Framing errors on receive", Verbose: true},
+		{Name: "net_host_compRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Compressed packets received", Verbose: true},
+		{Name: "net_host_mcastRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Multicast frames received", Verbose: true},
+		{Name: "net_host_errsTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Transmit errors", Verbose: true},
+		{Name: "net_host_dropTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Dropped transmitted packets", Verbose: true},
+		{Name: "net_host_fifoTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "FIFO buffer errors on transmit", Verbose: true},
+		{Name: "net_host_collsTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Collisions on transmit", Verbose: true},
+		{Name: "net_host_carrierTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Carrier losses on transmit", Verbose: true},
+		{Name: "net_host_compTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Compressed packets transmitted", Verbose: true},
+	})
+
+	// esxtop style: MbRX/s, MbTX/s, PKTRX/s, PKTTX/s, %DRPRX, %DRPTX, directions as seen from the VM
+	models.RegisterFields("net", models.ScopeDomain, []models.FieldDef{
+		{Name: "net_MbRX/s", Kind: models.KindRate, Unit: models.UnitMbitPerSecond, Description: "Received traffic of the VM interfaces"},
+		{Name: "net_MbTX/s", Kind: models.KindRate, Unit: models.UnitMbitPerSecond, Description: "Transmitted traffic of the VM interfaces"},
+		{Name: "net_PKTRX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Received packets of the VM interfaces"},
+		{Name: "net_PKTTX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Transmitted packets of the VM interfaces"},
+		{Name: "net_%DRPRX", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Share of received packets dropped"},
+		{Name: "net_%DRPTX", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Share of transmitted packets dropped"},
+		{Name: "net_errsRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Receive errors", Verbose: true},
+		{Name: "net_fifoRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "FIFO buffer errors on receive", Verbose: true},
+		{Name: "net_frameRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Framing errors on receive", Verbose: true},
+		{Name: "net_compRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Compressed packets received", Verbose: true},
+		{Name: "net_mcastRX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Multicast frames received", Verbose: true},
+		{Name: "net_errsTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Transmit errors", Verbose: true},
+		{Name: "net_fifoTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "FIFO buffer errors on transmit", Verbose: true},
+		{Name: "net_collsTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Collisions on transmit", Verbose: true},
+		{Name: "net_carrierTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Carrier losses on transmit", Verbose: true},
+		{Name: "net_compTX", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Compressed packets transmitted", Verbose: true},
+		{Name: "net_interfaces", Kind: models.KindInfo, Description: "Tap interfaces of the VM", Verbose: true},
+	})
+
+	// physical network view 'p', counters since the interface came up
+	models.RegisterDeviceFields("net", []string{models.DevicesNet}, []models.FieldDef{
+		{Name: "net_DEVICE", Kind: models.KindInfo, Description: "Physical network interface"},
+		{Name: "net_MbRX/s", Kind: models.KindRate, Unit: models.UnitMbitPerSecond, Description: "Received traffic"},
+		{Name: "net_MbTX/s", Kind: models.KindRate, Unit: models.UnitMbitPerSecond, Description: "Transmitted traffic"},
+		{Name: "net_PKTRX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Received packets"},
+		{Name: "net_PKTTX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Transmitted packets"},
+		{Name: "net_RX-Bytes", Kind: models.KindCounter, Unit: models.UnitBytes, Description: "Bytes received"},
+		{Name: "net_RX-Pkts", Kind: models.KindCounter, Description: "Packets received"},
+		{Name: "net_RX-Errs", Kind: models.KindCounter, Description: "Receive errors"},
+		{Name: "net_RX-Drop", Kind: models.KindCounter, Description: "Dropped received packets"},
+		{Name: "net_TX-Bytes", Kind: models.KindCounter, Unit: models.UnitBytes, Description: "Bytes transmitted"},
+		{Name: "net_TX-Pkts", Kind: models.KindCounter, Description: "Packets transmitted"},
+		{Name: "net_TX-Errs", Kind: models.KindCounter, Description: "Transmit errors"},
+		{Name: "net_TX-Drop", Kind: models.KindCounter, Description: "Dropped transmitted packets"},
+		{Name: "net_RX-Fifo", Kind: models.KindCounter, Description: "FIFO buffer errors on receive", Verbose: true},
+		{Name: "net_RX-Frame", Kind: models.KindCounter, Description: "Framing errors on receive", Verbose: true},
+		{Name: "net_RX-Compressed", Kind: models.KindCounter, Description: "Compressed packets received", Verbose: true},
+		{Name: "net_RX-Multicast", Kind: models.KindCounter, Description: "Multicast frames received", Verbose: true},
+		{Name: "net_TX-Fifo", Kind: models.KindCounter, Description: "FIFO buffer errors on transmit", Verbose: true},
+		{Name: "net_TX-Colls", Kind: models.KindCounter, Description: "Collisions on transmit", Verbose: true},
+		{Name: "net_TX-Carrier", Kind: models.KindCounter, Description: "Carrier losses on transmit", Verbose: true},
+		{Name: "net_TX-Compressed", Kind: models.KindCounter, Description: "Compressed packets transmitted", Verbose: true},
+	})
+
+	// raw counters of the physical interfaces and the VM interfaces for exporters, directions as in the printed fields
+	models.RegisterCounters("net", models.ScopeHost, []models.CounterDef{
+		{Metric: "net_physdev_*_ReceivedBytes", Name: "net_received", BaseUnit: "bytes", Description: "Bytes received by the physical interface"},
+		{Metric: "net_physdev_*_ReceivedPackets", Name: "net_received_packets", Description: "Packets received by the physical interface"},
+		{Metric: "net_physdev_*_TransmittedBytes", Name: "net_transmitted", BaseUnit: "bytes", Description: "Bytes transmitted by the physical interface"},
+		{Metric: "net_physdev_*_TransmittedPackets", Name: "net_transmitted_packets", Description: "Packets transmitted by the physical interface"},
+	})
+	models.RegisterCounters("net", models.ScopeDomain, []models.CounterDef{
+		{Metric: "net_ReceivedBytes_*", Name: "net_received", BaseUnit: "bytes", Description: "Bytes received by the VM interface"},
+		{Metric: "net_ReceivedPackets_*", Name: "net_received_packets", Description: "Packets received by the VM interface"},
+		{Metric: "net_ReceivedErrs_*", Name: "net_received_errors", Description: "Receive errors of the VM interface"},
+		{Metric: "net_ReceivedDrop_*", Name: "net_received_drops", Description: "Dropped received packets of the VM interface"},
+		{Metric: "net_TransmittedBytes_*", Name: "net_transmitted", BaseUnit: "bytes", Description: "Bytes transmitted by the VM interface"},
+		{Metric: "net_TransmittedPackets_*", Name: "net_transmitted_packets", Description: "Packets transmitted by the VM interface"},
+		{Metric: "net_TransmittedErrs_*", Name: "net_transmitted_errors", Description: "Transmit errors of the VM interface"},
+		{Metric: "net_TransmittedDrop_*", Name: "net_transmitted_drops", Description: "Dropped transmitted packets of the VM interface"},
+	})
+}
@@ -2,6 +2,7 @@ package netcollector
 
 import (
 	"fmt"
+	"time"
 
 	"proxtop/config"
 	"proxtop/connector"
@@ -29,7 +30,9 @@ func getHostBridges() []string {
 	models.Collection.LibvirtDomains.Map.Range(func(key, value interface{}) bool {
 		libvirtDomain := value.(libvirt.Domain)
 
-		xmldoc, _ := libvirtDomain.GetXMLDesc(libvirt.DOMAIN_XML_SECURE)
+		start := time.Now()
+		xmldoc, err := libvirtDomain.GetXMLDesc(libvirt.DOMAIN_XML_SECURE)
+		models.RecordCall(models.CallLibvirt, start, err)
 		domcfg := &libvirtxml.Domain{}
 		domcfg.Unmarshal(xmldoc)
 
@@ -58,7 +61,9 @@ func getHostBridges() []string {
 
 	// lookup bridges of networks
 	for networkName := range networks {
-		libvirtNetwork, _ := connector.Libvirt.Connection.LookupNetworkByName(networkName)
+		start := time.Now()
+		libvirtNetwork, err := connector.Libvirt.Connection.LookupNetworkByName(networkName)
+		models.RecordCall(models.CallLibvirt, start, err)
 		bridge, _ := libvirtNetwork.GetBridgeName()
 		bridges[bridge] = bridge
 	}
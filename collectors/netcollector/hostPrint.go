@@ -45,34 +45,7 @@ func hostPrint(host *models.Host) []string {
 
 // HostNetFields returns the field names for host physical network view
 func HostNetFields() []string {
-	fields := []string{
-		"net_DEVICE",
-		"net_MbRX/s",
-		"net_MbTX/s",
-		"net_PKTRX/s",
-		"net_PKTTX/s",
-		"net_RX-Bytes",
-		"net_RX-Pkts",
-		"net_RX-Errs",
-		"net_RX-Drop",
-		"net_TX-Bytes",
-		"net_TX-Pkts",
-		"net_TX-Errs",
-		"net_TX-Drop",
-	}
-	if config.Options.Verbose {
-		fields = append(fields,
-			"net_RX-Fifo",
-			"net_RX-Frame",
-			"net_RX-Compressed",
-			"net_RX-Multicast",
-			"net_TX-Fifo",
-			"net_TX-Colls",
-			"net_TX-Carrier",
-			"net_TX-Compressed",
-		)
-	}
-	return fields
+	return models.DeviceFieldNames(models.DevicesNet, config.Options.Verbose)
 }
 
 // HostPrintPerDevice returns per-device network stats for physical network view
@@ -0,0 +1,42 @@
+package overheadcollector
+
+import (
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// Collector describes the hypervisor overhead collector
+type Collector struct {
+	models.Collector
+}
+
+// Lookup overhead collector data
+func (collector *Collector) Lookup() {
+	// processes are scanned in every collect cycle
+}
+
+// Collect overhead collector data
+func (collector *Collector) Collect() {
+	hostCollect()
+}
+
+// Print returns the collectors measurements in a Printable struct
+func (collector *Collector) Print() models.Printable {
+	// fields are declared in fields.go
+	printable := models.Printable{
+		HostFields:   models.FieldNames("overhead", models.ScopeHost, config.Options.Verbose),
+		DomainFields: []string{},
+	}
+
+	// lookup for host
+	printable.HostValues = hostPrint()
+	printable.AddDevices(models.DevicesOverheadSummary, HostOverheadFields(), HostPrintOverheadSummary())
+	printable.AddDevices(models.DevicesOverhead, HostOverheadFields(), HostPrintPerProcess())
+
+	return printable
+}
+
+// CreateCollector creates a new overhead collector
+func CreateCollector() Collector {
+	return Collector{}
+}
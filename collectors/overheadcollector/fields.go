@@ -0,0 +1,29 @@
+package overheadcollector
+
+import "proxtop/models"
+
+func init() {
+	// totals of processes outside and inside of guests, CPU in % of one core
+	models.RegisterFields("overhead", models.ScopeHost, []models.FieldDef{
+		{Name: "ovh_%CPU", Kind: models.KindRate, Unit: models.UnitPercent, Description: "CPU usage of all processes outside of VMs"},
+		{Name: "ovh_GUEST%CPU", Kind: models.KindRate, Unit: models.UnitPercent, Description: "CPU usage of the guest processes"},
+		{Name: "ovh_RSS", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Resident memory of all processes outside of VMs"},
+		{Name: "ovh_GUESTRSS", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Resident memory of the guest processes"},
+		{Name: "ovh_IORD/s", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Storage reads of all processes outside of VMs", Verbose: true},
+		{Name: "ovh_IOWR/s", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Storage writes of all processes outside of VMs", Verbose: true},
+		{Name: "ovh_PROCS", Kind: models.KindGauge, Description: "Processes and kernel thread groups outside of VMs", Verbose: true},
+	})
+
+	// overhead view 'w'
+	models.RegisterDeviceFields("overhead", []string{models.DevicesOverheadSummary, models.DevicesOverhead}, []models.FieldDef{
+		{Name: "ovh_PROCESS", Kind: models.KindInfo, Description: "comm:pid of a process, [name] of a kernel thread group"},
+		{Name: "ovh_TYPE", Kind: models.KindInfo, Description: "process, kthread or total"},
+		{Name: "ovh_THR", Kind: models.KindGauge, Description: "Threads, kernel threads in the group"},
+		{Name: "ovh_%CPU", Kind: models.KindRate, Unit: models.UnitPercent, Description: "CPU usage"},
+		{Name: "ovh_%USR", Kind: models.KindRate, Unit: models.UnitPercent, Description: "User CPU usage"},
+		{Name: "ovh_%SYS", Kind: models.KindRate, Unit: models.UnitPercent, Description: "System CPU usage"},
+		{Name: "ovh_READ/s", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Bytes read from storage"},
+		{Name: "ovh_WRITE/s", Kind: models.KindRate, Unit: models.UnitBytesPerSecond, Description: "Bytes written to storage"},
+		{Name: "ovh_RSS", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Resident memory"},
+	})
+}
@@ -0,0 +1,187 @@
+package overheadcollector
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// USER_HZ, clock ticks per second of /proc/<pid>/stat
+const userHZ = 100
+
+// processKey identifies a process, the start time tells reused PIDs apart
+type processKey struct {
+	pid       int
+	starttime uint64
+}
+
+// processSample holds the counters of a process
+type processSample struct {
+	utime uint64
+	stime uint64
+	read  uint64
+	write uint64
+}
+
+// overheadRow holds the usage of a process, a group of kernel threads or a total.
+// CPU is in percent of one core, I/O in bytes/s.
+type overheadRow struct {
+	name    string
+	kind    string
+	threads int
+	user    float64
+	system  float64
+	read    float64
+	write   float64
+	rss     uint64
+}
+
+// add adds the usage of another row
+func (row *overheadRow) add(other overheadRow) {
+	row.threads += other.threads
+	row.user += other.user
+	row.system += other.system
+	row.read += other.read
+	row.write += other.write
+	row.rss += other.rss
+}
+
+// overheadSnapshot is the result of one collect cycle
+type overheadSnapshot struct {
+	rows     map[string]*overheadRow
+	overhead overheadRow
+	guests   overheadRow
+}
+
+var snapshotMu sync.Mutex
+var snapshot = overheadSnapshot{rows: make(map[string]*overheadRow)}
+var previousSamples = make(map[processKey]processSample)
+var previousTime time.Time
+
+// currentSnapshot returns the result of the last collect cycle
+func currentSnapshot() overheadSnapshot {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	return snapshot
+}
+
+// hostCollect scans all processes and splits their usage into guests and hypervisor overhead
+func hostCollect() {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(previousTime).Seconds()
+	pageSize := uint64(os.Getpagesize())
+	// /proc/<pid>/io of other users' processes is only readable by root
+	readIO := os.Geteuid() == 0
+
+	guestPIDs := make(map[int]bool)
+	models.Collection.Domains.Range(func(_, value interface{}) bool {
+		domain := value.(models.Domain)
+		guestPIDs[domain.PID] = true
+		return true
+	})
+
+	current := overheadSnapshot{rows: make(map[string]*overheadRow)}
+	samples := make(map[processKey]processSample)
+	for _, pid := range util.GetProcessList() {
+		stat := util.GetProcPIDStat(pid)
+		if stat.PID == 0 {
+			continue
+		}
+		kernelThread := isKernelThread(stat)
+
+		sample := processSample{utime: uint64(stat.UTime), stime: uint64(stat.STime)}
+		if readIO {
+			io := util.GetProcPIDIO(pid)
+			sample.read = io.Read_bytes
+			sample.write = io.Write_bytes
+		}
+		key := processKey{pid: pid, starttime: stat.Starttime}
+		samples[key] = sample
+
+		row := overheadRow{
+			name:    fmt.Sprintf("%s:%d", stat.Comm, pid),
+			kind:    "process",
+			threads: stat.NumThreads,
+			rss:     uint64(stat.RSS) * pageSize,
+		}
+		if previous, ok := previousSamples[key]; ok && elapsed > 0 {
+			row.user = float64(counterDiff(sample.utime, previous.utime)) / userHZ / elapsed * 100
+			row.system = float64(counterDiff(sample.stime, previous.stime)) / userHZ / elapsed * 100
+			row.read = float64(counterDiff(sample.read, previous.read)) / elapsed
+			row.write = float64(counterDiff(sample.write, previous.write)) / elapsed
+		}
+
+		if isGuest(stat, guestPIDs) {
+			current.guests.add(row)
+			continue
+		}
+		current.overhead.add(row)
+
+		// kernel threads are grouped by name, e.g. all z_wr_iss or kworker threads
+		if kernelThread {
+			row.name = fmt.Sprintf("[%s]", kernelThreadGroup(stat.Comm))
+			row.kind = "kthread"
+			if group, ok := current.rows[row.name]; ok {
+				group.add(row)
+				continue
+			}
+		}
+		current.rows[row.name] = &row
+	}
+
+	snapshot = current
+	previousSamples = samples
+	previousTime = now
+}
+
+// counterDiff returns the increase of a counter, 0 if it went backwards
+func counterDiff(current uint64, previous uint64) uint64 {
+	if current < previous {
+		return 0
+	}
+	return current - previous
+}
+
+// isKernelThread returns true for kthreadd and its children
+func isKernelThread(stat util.ProcPIDStat) bool {
+	return stat.PID == 2 || stat.PPID == 2
+}
+
+// isGuest returns true for QEMU processes of guests and the kernel threads working for them
+// (vhost-<qemu pid>, kvm-pit/<qemu pid>)
+func isGuest(stat util.ProcPIDStat, guestPIDs map[int]bool) bool {
+	if guestPIDs[stat.PID] {
+		return true
+	}
+	if owner := util.GetVhostOwner(stat.Comm); owner != 0 && guestPIDs[owner] {
+		return true
+	}
+	var owner int
+	if _, err := fmt.Sscanf(stat.Comm, "kvm-pit/%d", &owner); err == nil && guestPIDs[owner] {
+		return true
+	}
+	return false
+}
+
+// kernelThreadGroup strips per-CPU and instance suffixes of kernel thread names:
+// kworker/3:1H -> kworker, ksoftirqd/3 -> ksoftirqd, z_wr_iss_h stays, z_rd_int_0 -> z_rd_int
+func kernelThreadGroup(comm string) string {
+	if index := strings.Index(comm, "/"); index > 0 {
+		comm = comm[:index]
+	}
+	trimmed := strings.TrimRightFunc(comm, unicode.IsDigit)
+	trimmed = strings.TrimRight(trimmed, "_-")
+	if trimmed == "" {
+		return comm
+	}
+	return trimmed
+}
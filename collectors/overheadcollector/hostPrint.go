@@ -0,0 +1,77 @@
+package overheadcollector
+
+import (
+	"fmt"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// formatBytes formats a value in bytes
+func formatBytes(valueBytes uint64) string {
+	return models.UnitBytes.Format(valueBytes)
+}
+
+func hostPrint() []string {
+	snapshot := currentSnapshot()
+	result := []string{
+		fmt.Sprintf("%.1f", snapshot.overhead.user+snapshot.overhead.system),
+		fmt.Sprintf("%.1f", snapshot.guests.user+snapshot.guests.system),
+		formatBytes(snapshot.overhead.rss),
+		formatBytes(snapshot.guests.rss),
+	}
+	if config.Options.Verbose {
+		result = append(result,
+			formatBytes(uint64(snapshot.overhead.read)),
+			formatBytes(uint64(snapshot.overhead.write)),
+			fmt.Sprintf("%d", len(snapshot.rows)),
+		)
+	}
+	return result
+}
+
+// HostOverheadFields returns the field names for the overhead view
+func HostOverheadFields() []string {
+	return models.DeviceFieldNames(models.DevicesOverhead, config.Options.Verbose)
+}
+
+// printRow returns the field values of a row in the same order as HostOverheadFields
+func printRow(row overheadRow) []string {
+	return []string{
+		row.name,
+		row.kind,
+		fmt.Sprintf("%d", row.threads),
+		fmt.Sprintf("%.1f", row.user+row.system),
+		fmt.Sprintf("%.1f", row.user),
+		fmt.Sprintf("%.1f", row.system),
+		formatBytes(uint64(row.read)),
+		formatBytes(uint64(row.write)),
+		formatBytes(row.rss),
+	}
+}
+
+// HostPrintOverheadSummary returns the totals of the hypervisor overhead and the guests for the overhead view
+// Returns a map of name -> []string (field values in same order as HostOverheadFields)
+func HostPrintOverheadSummary() map[string][]string {
+	snapshot := currentSnapshot()
+	overhead := snapshot.overhead
+	overhead.name = "hypervisor overhead"
+	overhead.kind = "total"
+	guests := snapshot.guests
+	guests.name = "guests"
+	guests.kind = "total"
+	return map[string][]string{
+		overhead.name: printRow(overhead),
+		guests.name:   printRow(guests),
+	}
+}
+
+// HostPrintPerProcess returns the host processes and kernel thread groups outside of guests for the overhead view
+// Returns a map of name -> []string (field values in same order as HostOverheadFields)
+func HostPrintPerProcess() map[string][]string {
+	result := make(map[string][]string)
+	for name, row := range currentSnapshot().rows {
+		result[name] = printRow(*row)
+	}
+	return result
+}
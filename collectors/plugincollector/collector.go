@@ -0,0 +1,95 @@
+package plugincollector
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// Collector describes the plugin collector running external executables
+type Collector struct {
+	models.Collector
+	plugins []*plugin
+}
+
+// Lookup plugin collector data
+func (collector *Collector) Lookup() {
+	for _, p := range collector.plugins {
+		if p.mode == modeDaemon {
+			p.ensureDaemon()
+		}
+	}
+	collector.runPlugins(modeLookup)
+}
+
+// Collect plugin collector data
+func (collector *Collector) Collect() {
+	collector.runPlugins(modeCollect)
+	collector.publish()
+}
+
+// runPlugins runs the plugins of mode in parallel and waits for them, each bounded by the plugin timeout
+func (collector *Collector) runPlugins(mode string) {
+	var wg sync.WaitGroup
+	for _, p := range collector.plugins {
+		if p.mode != mode {
+			continue
+		}
+		wg.Add(1)
+		go func(p *plugin) {
+			defer wg.Done()
+			// a failing plugin must not stop the other plugins and collectors
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("plugin %s: %v", p.name, r)
+				}
+			}()
+			p.run()
+		}(p)
+	}
+	wg.Wait()
+}
+
+// Print returns the collectors measurements in a Printable struct
+func (collector *Collector) Print() models.Printable {
+	// fields are registered when a plugin reports them first, see fields.go
+	printable := models.Printable{
+		HostFields:   models.FieldNames("plugin", models.ScopeHost, config.Options.Verbose),
+		DomainFields: models.FieldNames("plugin", models.ScopeDomain, config.Options.Verbose),
+	}
+
+	// lookup for host
+	printable.HostValues = printValues(models.Collection.Host.Measurable, printable.HostFields)
+
+	// lookup for each domain
+	printable.DomainValues = make(map[string][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		printable.DomainValues[uuid] = printValues(domain.Measurable, printable.DomainFields)
+		return true
+	})
+
+	return printable
+}
+
+// CreateCollector creates a new plugin collector of the plugin definitions NAME[:MODE]=COMMAND
+func CreateCollector(definitions []string) (Collector, error) {
+	collector := Collector{}
+	names := make(map[string]bool)
+	for _, definition := range definitions {
+		p, err := parsePlugin(definition)
+		if err != nil {
+			return Collector{}, err
+		}
+		if names[p.name] {
+			return Collector{}, fmt.Errorf("plugin %s: defined more than once", p.name)
+		}
+		names[p.name] = true
+		collector.plugins = append(collector.plugins, p)
+	}
+	return collector, nil
+}
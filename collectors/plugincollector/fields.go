@@ -0,0 +1,23 @@
+package plugincollector
+
+import (
+	"fmt"
+
+	"proxtop/models"
+)
+
+// registerField declares a plugin field when it is reported first, named PLUGIN_METRIC.
+// Returns false if the name is taken by a field of another collector.
+func registerField(scope models.Scope, field string, pluginName string, value interface{}) bool {
+	if def, exists := models.LookupField(scope, field); exists {
+		return def.Collector == "plugin"
+	}
+	kind := models.KindGauge
+	if _, isText := value.(string); isText {
+		kind = models.KindInfo
+	}
+	models.RegisterFields("plugin", scope, []models.FieldDef{
+		{Name: field, Kind: kind, Description: fmt.Sprintf("Reported by plugin %s", pluginName)},
+	})
+	return true
+}
@@ -0,0 +1,242 @@
+package plugincollector
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"proxtop/config"
+)
+
+// Modes of running a plugin
+const (
+	// modeCollect runs the plugin each collect cycle
+	modeCollect = "collect"
+	// modeLookup runs the plugin each lookup cycle
+	modeLookup = "lookup"
+	// modeDaemon keeps the plugin running as child, each output line updates its metrics
+	modeDaemon = "daemon"
+)
+
+// minimum time between two starts of a daemon plugin
+const daemonRestartGap = 10 * time.Second
+
+// stderr of a failed plugin is logged up to this length
+const maxLoggedStderr = 200
+
+// maximum length of an output line of a daemon plugin, a longer line kills the daemon
+const maxDaemonLine = 1024 * 1024
+
+var pluginNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// plugin is an external executable reporting metrics
+type plugin struct {
+	name    string
+	mode    string
+	command string
+
+	// running is 1 while a run is in progress, a run still in progress is not started again
+	running int32
+
+	mu sync.Mutex
+	// samples holds the metrics of the last run, of daemons the latest value of each metric
+	samples map[sampleKey]sample
+	// daemonStarted is the start time of the daemon child, zero if not running
+	daemonStarted time.Time
+	daemonAlive   bool
+}
+
+// parsePlugin parses a definition NAME[:MODE]=COMMAND
+func parsePlugin(definition string) (*plugin, error) {
+	separator := strings.Index(definition, "=")
+	if separator < 0 {
+		return nil, fmt.Errorf("plugin %q: missing =", definition)
+	}
+	newPlugin := &plugin{
+		name:    strings.TrimSpace(definition[:separator]),
+		mode:    modeCollect,
+		command: strings.TrimSpace(definition[separator+1:]),
+		samples: make(map[sampleKey]sample),
+	}
+	if colon := strings.Index(newPlugin.name, ":"); colon >= 0 {
+		newPlugin.mode = newPlugin.name[colon+1:]
+		newPlugin.name = newPlugin.name[:colon]
+	}
+	if !pluginNamePattern.MatchString(newPlugin.name) {
+		return nil, fmt.Errorf("plugin %q: name must be lower case letters and digits", newPlugin.name)
+	}
+	if newPlugin.mode != modeCollect && newPlugin.mode != modeLookup && newPlugin.mode != modeDaemon {
+		return nil, fmt.Errorf("plugin %s: unknown mode %q (collect, lookup, daemon)", newPlugin.name, newPlugin.mode)
+	}
+	if newPlugin.command == "" {
+		return nil, fmt.Errorf("plugin %s: missing command", newPlugin.name)
+	}
+	return newPlugin, nil
+}
+
+// newCommand returns the shell command of the plugin in an own process group, killed with proxtop
+func (p *plugin) newCommand() *exec.Cmd {
+	cmd := exec.Command("/bin/sh", "-c", p.command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+	return cmd
+}
+
+// timeout returns the configured maximum duration of a plugin run
+func timeout() time.Duration {
+	if config.Options.PluginTimeout < 1 {
+		return time.Second
+	}
+	return time.Duration(config.Options.PluginTimeout) * time.Second
+}
+
+// maxSampleAge returns the configured time a metric of a daemon is kept after it was last reported
+func maxSampleAge() time.Duration {
+	if config.Options.PluginMaxAge < 1 {
+		return time.Second
+	}
+	return time.Duration(config.Options.PluginMaxAge) * time.Second
+}
+
+// run executes the plugin once and replaces its samples by the output. A failed run clears the samples,
+// so the metrics of the plugin are shown as not available.
+func (p *plugin) run() {
+	if !atomic.CompareAndSwapInt32(&p.running, 0, 1) {
+		log.Printf("plugin %s: previous run still in progress, skipped", p.name)
+		return
+	}
+	defer atomic.StoreInt32(&p.running, 0)
+
+	samples, err := p.execute()
+	if err != nil {
+		log.Printf("plugin %s: %v", p.name, err)
+	}
+
+	p.mu.Lock()
+	p.samples = make(map[sampleKey]sample, len(samples))
+	for _, newSample := range samples {
+		p.samples[newSample.key] = newSample
+	}
+	p.mu.Unlock()
+}
+
+// execute runs the command, killing its process group after the timeout
+func (p *plugin) execute() ([]sample, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := p.newCommand()
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		if message := strings.TrimSpace(stderr.String()); err != nil && message != "" {
+			return nil, fmt.Errorf("%v: %s", err, truncate(message, maxLoggedStderr))
+		} else if err != nil {
+			return nil, err
+		}
+	case <-time.After(timeout()):
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-done
+		return nil, fmt.Errorf("killed after timeout of %s", timeout())
+	}
+	return parseOutput(stdout.Bytes())
+}
+
+// ensureDaemon starts the daemon child if it is not running and the restart gap passed
+func (p *plugin) ensureDaemon() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.daemonAlive || time.Since(p.daemonStarted) < daemonRestartGap {
+		return
+	}
+
+	cmd := p.newCommand()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		log.Printf("plugin %s: %v", p.name, err)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		log.Printf("plugin %s: %v", p.name, err)
+		return
+	}
+	p.daemonStarted = time.Now()
+	p.daemonAlive = true
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), maxDaemonLine)
+		for scanner.Scan() {
+			samples, err := parseLine(scanner.Text())
+			if err != nil {
+				log.Printf("plugin %s: %v", p.name, err)
+				continue
+			}
+			reported := time.Now()
+			p.mu.Lock()
+			for _, newSample := range samples {
+				newSample.reported = reported
+				p.samples[newSample.key] = newSample
+			}
+			p.mu.Unlock()
+		}
+		// stdout is not read anymore, a daemon still writing would block forever
+		if err := scanner.Err(); err != nil {
+			log.Printf("plugin %s: %v, killing daemon", p.name, err)
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+		err := cmd.Wait()
+		log.Printf("plugin %s: daemon exited: %v", p.name, err)
+
+		// metrics of a daemon which is gone are not available until it reports again
+		p.mu.Lock()
+		p.daemonAlive = false
+		p.samples = make(map[sampleKey]sample)
+		p.mu.Unlock()
+	}()
+}
+
+// currentSamples returns a copy of the samples of the plugin sorted by metric, new fields are registered in this order.
+// Samples of a daemon not reported again within --plugin-max-age are removed.
+func (p *plugin) currentSamples() []sample {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	samples := make([]sample, 0, len(p.samples))
+	for key, current := range p.samples {
+		if !current.reported.IsZero() && time.Since(current.reported) > maxSampleAge() {
+			delete(p.samples, key)
+			continue
+		}
+		samples = append(samples, current)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].key.metric != samples[j].key.metric {
+			return samples[i].key.metric < samples[j].key.metric
+		}
+		return samples[i].key.target < samples[j].key.target
+	})
+	return samples
+}
+
+// truncate shortens text to at most length bytes
+func truncate(text string, length int) string {
+	if len(text) > length {
+		return text[:length]
+	}
+	return text
+}
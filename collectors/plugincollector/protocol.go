@@ -0,0 +1,126 @@
+package plugincollector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hostTarget is the target of host metrics in both protocols
+const hostTarget = "host"
+
+// sampleKey identifies a metric of the host or a VM
+type sampleKey struct {
+	// target is "host", a VM UUID or a Proxmox VMID
+	target string
+	metric string
+}
+
+// sample is one reported value, numbers as float64, texts as string
+type sample struct {
+	key   sampleKey
+	value interface{}
+	// reported is the time a daemon reported the value, zero for the other modes
+	reported time.Time
+}
+
+// jsonOutput is the JSON protocol: {"host": {"metric": 1}, "vms": {"<uuid or vmid>": {"metric": "text"}}}
+type jsonOutput struct {
+	Host map[string]interface{}            `json:"host"`
+	VMs  map[string]map[string]interface{} `json:"vms"`
+}
+
+// parseOutput parses the output of a plugin run, JSON objects if it starts with { and else the line protocol
+func parseOutput(output []byte) ([]sample, error) {
+	trimmed := bytes.TrimSpace(output)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseJSON(bytes.NewReader(trimmed))
+	}
+
+	samples := []sample{}
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		lineSamples, err := parseLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		samples = append(samples, lineSamples...)
+	}
+	return samples, scanner.Err()
+}
+
+// parseLine parses a line of a daemon plugin or of the line protocol: TARGET METRIC VALUE,
+// TARGET is host, a VM UUID or a Proxmox VMID. Empty lines and lines starting with # are skipped.
+func parseLine(line string) ([]sample, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+	if strings.HasPrefix(line, "{") {
+		return parseJSON(strings.NewReader(line))
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("expected TARGET METRIC VALUE: %q", line)
+	}
+	// texts may contain spaces
+	text := strings.Join(fields[2:], " ")
+	return []sample{{key: sampleKey{target: fields[0], metric: fields[1]}, value: parseValue(text)}}, nil
+}
+
+// parseJSON parses one or more JSON objects of the JSON protocol
+func parseJSON(reader io.Reader) ([]sample, error) {
+	samples := []sample{}
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	for {
+		var output jsonOutput
+		if err := decoder.Decode(&output); err == io.EOF {
+			return samples, nil
+		} else if err != nil {
+			return nil, err
+		}
+		for metric, value := range output.Host {
+			samples = appendJSONSample(samples, hostTarget, metric, value)
+		}
+		for target, metrics := range output.VMs {
+			for metric, value := range metrics {
+				samples = appendJSONSample(samples, target, metric, value)
+			}
+		}
+	}
+}
+
+// appendJSONSample appends a JSON value: numbers, strings and booleans (1, 0), other values are ignored
+func appendJSONSample(samples []sample, target string, metric string, value interface{}) []sample {
+	key := sampleKey{target: target, metric: strings.Join(strings.Fields(metric), "_")}
+	switch typed := value.(type) {
+	case json.Number:
+		if number, err := typed.Float64(); err == nil {
+			return append(samples, sample{key: key, value: number})
+		}
+	case string:
+		return append(samples, sample{key: key, value: typed})
+	case bool:
+		number := 0.0
+		if typed {
+			number = 1
+		}
+		return append(samples, sample{key: key, value: number})
+	}
+	return samples
+}
+
+// parseValue returns a number as float64 and anything else as text
+func parseValue(text string) interface{} {
+	if number, err := strconv.ParseFloat(text, 64); err == nil {
+		return number
+	}
+	return text
+}
@@ -0,0 +1,84 @@
+package plugincollector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []sample
+		wantErr bool
+	}{
+		{"number", "host iops 42", []sample{{key: sampleKey{"host", "iops"}, value: 42.0}}, false},
+		{"float", "101 latency 0.25", []sample{{key: sampleKey{"101", "latency"}, value: 0.25}}, false},
+		{"text with spaces", "host state degraded  array", []sample{{key: sampleKey{"host", "state"}, value: "degraded array"}}, false},
+		{"surrounding whitespace", "  host iops 1\t", []sample{{key: sampleKey{"host", "iops"}, value: 1.0}}, false},
+		{"empty", "", nil, false},
+		{"comment", "# host iops 1", nil, false},
+		{"json", `{"host": {"iops": 3}}`, []sample{{key: sampleKey{"host", "iops"}, value: 3.0}}, false},
+		{"missing value", "host iops", nil, true},
+		{"invalid json", `{"host": `, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseLine(test.line)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseLine(%q) error = %v, want error %v", test.line, err, test.wantErr)
+			}
+			if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseLine(%q) = %v, want %v", test.line, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    map[sampleKey]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "line protocol",
+			output: "# comment\nhost iops 1\n\n101 state ok\n",
+			want:   map[sampleKey]interface{}{{"host", "iops"}: 1.0, {"101", "state"}: "ok"},
+		},
+		{
+			name:   "json objects",
+			output: `{"host": {"iops": 1, "up": true}, "vms": {"101": {"state": "ok", "lost": false}}} {"host": {"load avg": 2}}`,
+			want: map[sampleKey]interface{}{
+				{"host", "iops"}: 1.0, {"host", "up"}: 1.0, {"101", "state"}: "ok", {"101", "lost"}: 0.0, {"host", "load_avg"}: 2.0,
+			},
+		},
+		{
+			name:   "json values which are no number, text or boolean",
+			output: `{"host": {"list": [1], "object": {}, "null": null}}`,
+			want:   map[sampleKey]interface{}{},
+		},
+		{name: "invalid line", output: "host iops 1\nhost\n", wantErr: true},
+		{name: "invalid json", output: `{"host": {"iops": }}`, wantErr: true},
+		{name: "empty", output: "", want: map[sampleKey]interface{}{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			samples, err := parseOutput([]byte(test.output))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseOutput() error = %v, want error %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			got := make(map[sampleKey]interface{})
+			for _, current := range samples {
+				got[current.key] = current.value
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseOutput() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
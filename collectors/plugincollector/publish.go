@@ -0,0 +1,112 @@
+package plugincollector
+
+import (
+	"strconv"
+	"sync"
+
+	"proxtop/connector"
+	"proxtop/models"
+)
+
+// publishMu serializes publishing, published holds the fields stored in the last cycle by VM UUID, host fields by ""
+var publishMu sync.Mutex
+var published = make(map[string][]string)
+
+// publish stores the current samples of all plugins as measurements of the host and the VMs.
+// Plugin metrics not reported anymore are removed, so they are shown as not available.
+func (collector *Collector) publish() {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	// Proxmox VMIDs of the VMs
+	vmids := make(map[string]string)
+	connector.ProxmoxVMStore.Range(func(uuid string, vm connector.VMInfo) bool {
+		vmids[vm.VMID] = uuid
+		return true
+	})
+
+	// field values by VM UUID, host values by ""
+	values := make(map[string]map[string]interface{})
+	for _, p := range collector.plugins {
+		for _, current := range p.currentSamples() {
+			uuid, scope, ok := resolveTarget(current.key.target, vmids)
+			if !ok {
+				continue
+			}
+			field := p.name + "_" + current.key.metric
+			if !registerField(scope, field, p.name, current.value) {
+				continue
+			}
+			if values[uuid] == nil {
+				values[uuid] = make(map[string]interface{})
+			}
+			values[uuid][field] = current.value
+		}
+	}
+
+	stored := make(map[string][]string)
+	for uuid, fields := range values {
+		measurable := models.Collection.Host.Measurable
+		if uuid != "" {
+			domain, exists := models.Collection.Domains.Load(uuid)
+			if !exists {
+				continue
+			}
+			measurable = domain.Measurable
+		}
+		for field, value := range fields {
+			measurable.AddMetricMeasurement(field, models.CreateMeasurement(value))
+			stored[uuid] = append(stored[uuid], field)
+		}
+	}
+
+	// remove the fields of the previous cycle which are not reported anymore
+	for uuid, fields := range published {
+		measurable := models.Collection.Host.Measurable
+		if uuid != "" {
+			domain, exists := models.Collection.Domains.Load(uuid)
+			if !exists {
+				continue
+			}
+			measurable = domain.Measurable
+		}
+		for _, field := range fields {
+			if _, reported := values[uuid][field]; !reported {
+				measurable.DelMetricMeasurement(field)
+			}
+		}
+	}
+	published = stored
+}
+
+// resolveTarget returns the UUID ("" for the host) and scope of a target: host, VM UUID or Proxmox VMID
+func resolveTarget(target string, vmids map[string]string) (string, models.Scope, bool) {
+	if target == hostTarget {
+		return "", models.ScopeHost, true
+	}
+	if _, exists := models.Collection.Domains.Load(target); exists {
+		return target, models.ScopeDomain, true
+	}
+	if uuid, exists := vmids[target]; exists {
+		return uuid, models.ScopeDomain, true
+	}
+	return "", "", false
+}
+
+// printValues returns the printed values of fields, "-" for fields not reported
+func printValues(measurable *models.Measurable, fields []string) []string {
+	result := make([]string, len(fields))
+	for i, field := range fields {
+		result[i] = "-"
+		measurement, err := measurable.GetMeasurement(field, 0)
+		if err != nil {
+			continue
+		}
+		if number, err := measurement.Float64(); err == nil {
+			result[i] = strconv.FormatFloat(number, 'f', -1, 64)
+		} else if text, err := measurement.Text(); err == nil && text != "" {
+			result[i] = text
+		}
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+package powercollector
+
+import (
+	"proxtop/config"
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// Collector describes the power collector
+type Collector struct {
+	models.Collector
+}
+
+// Lookup power collector data
+func (collector *Collector) Lookup() {
+	// vhost workers are separate kernel threads, map them to their QEMU process once
+	vhostWorkers := util.GetVhostWorkers()
+
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		domain := value.(models.Domain)
+		domainLookup(&domain, vhostWorkers)
+		return true
+	})
+}
+
+// Collect power collector data
+func (collector *Collector) Collect() {
+	hostCollect(&models.Collection.Host)
+
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		domain := value.(models.Domain)
+		domainCollect(&domain)
+		return true
+	})
+}
+
+// Print returns the collectors measurements in a Printable struct
+func (collector *Collector) Print() models.Printable {
+	// fields are declared in fields.go
+	printable := models.Printable{
+		HostFields:   models.FieldNames("power", models.ScopeHost, config.Options.Verbose),
+		DomainFields: models.FieldNames("power", models.ScopeDomain, config.Options.Verbose),
+	}
+
+	// lookup for each domain
+	printable.DomainValues = make(map[string][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		printable.DomainValues[uuid] = domainPrint(&domain)
+		return true
+	})
+
+	// lookup for host
+	printable.HostValues = hostPrint(&models.Collection.Host)
+
+	return printable
+}
+
+// CreateCollector creates a new power collector
+func CreateCollector() Collector {
+	return Collector{}
+}
@@ -0,0 +1,25 @@
+package powercollector
+
+import (
+	"proxtop/models"
+	"proxtop/util"
+)
+
+func domainLookup(domain *models.Domain, vhostWorkers map[int][]int) {
+	vhostPIDs, ok := vhostWorkers[domain.PID]
+	if !ok {
+		vhostPIDs = []int{}
+	}
+	domain.AddMetricMeasurement("power_vhostPIDs", models.CreateMeasurement(vhostPIDs))
+}
+
+func domainCollect(domain *models.Domain) {
+	// CPU time of all QEMU threads (vCPUs, emulator, iothreads) and the vhost workers
+	stat := util.GetProcPIDStat(domain.PID)
+	cputime := uint64(stat.UTime + stat.STime)
+	for _, pid := range domain.GetMetricIntArray("power_vhostPIDs") {
+		vhostStat := util.GetProcPIDStat(pid)
+		cputime += uint64(vhostStat.UTime + vhostStat.STime)
+	}
+	domain.AddMetricMeasurement("power_cputime", models.CreateMeasurement(cputime))
+}
@@ -0,0 +1,36 @@
+package powercollector
+
+import "proxtop/models"
+
+func init() {
+	models.RegisterFields("power", models.ScopeHost, []models.FieldDef{
+		{Name: "power_PKGW", Kind: models.KindRate, Unit: models.UnitWatts, Description: "Power of all CPU packages (RAPL)"},
+		{Name: "power_DRAMW", Kind: models.KindRate, Unit: models.UnitWatts, Description: "Power of the DRAM (RAPL)"},
+		{Name: "power_CPUTEMP", Kind: models.KindGauge, Unit: models.UnitCelsius, Description: "Highest CPU package temperature"},
+		{Name: "power_FANMIN", Kind: models.KindGauge, Unit: models.UnitRPM, Description: "Slowest spinning fan"},
+		{Name: "power_VMW", Kind: models.KindRate, Unit: models.UnitWatts, Description: "Package power attributed to all VMs"},
+		// attribution inputs, kept in the default output so the per VM watts can be audited
+		{Name: "power_BUSY", Kind: models.KindRate, Description: "Busy host CPUs, CPU seconds per second"},
+		{Name: "power_MODEL", Kind: models.KindInfo, Description: "Model splitting the package power between VMs"},
+		{Name: "power_COREW", Kind: models.KindRate, Unit: models.UnitWatts, Description: "Power of the CPU cores (RAPL)", Verbose: true},
+		{Name: "power_UNCOREW", Kind: models.KindRate, Unit: models.UnitWatts, Description: "Power of the uncore, e.g. integrated graphics (RAPL)", Verbose: true},
+		{Name: "power_PSYSW", Kind: models.KindRate, Unit: models.UnitWatts, Description: "Power of the platform (RAPL psys)", Verbose: true},
+		{Name: "power_MAXTEMP", Kind: models.KindGauge, Unit: models.UnitCelsius, Description: "Highest temperature of all hwmon sensors", Verbose: true},
+		{Name: "power_FANS", Kind: models.KindGauge, Description: "Spinning fans", Verbose: true},
+	})
+
+	// VM watts = package watts * VM CPU time / busy host CPU time
+	models.RegisterFields("power", models.ScopeDomain, []models.FieldDef{
+		{Name: "power_W", Kind: models.KindRate, Unit: models.UnitWatts, Description: "Package power attributed to the VM"},
+		{Name: "power_%PKG", Kind: models.KindRate, Unit: models.UnitPercent, Description: "Share of the busy host CPU time used by the VM"},
+		{Name: "power_CPU", Kind: models.KindRate, Description: "CPUs used by the VM, CPU seconds per second"},
+	})
+
+	// raw counters for exporters, in USER_HZ ticks
+	models.RegisterCounters("power", models.ScopeHost, []models.CounterDef{
+		{Metric: "power_cputime", Name: "power_busy_cpu", BaseUnit: "seconds", Scale: 0.01, Description: "Busy CPU time of the host"},
+	})
+	models.RegisterCounters("power", models.ScopeDomain, []models.CounterDef{
+		{Metric: "power_cputime", Name: "power_cpu", BaseUnit: "seconds", Scale: 0.01, Description: "CPU time of the QEMU process and its vhost workers"},
+	})
+}
@@ -0,0 +1,71 @@
+package powercollector
+
+import (
+	"fmt"
+	"strings"
+
+	"proxtop/models"
+	"proxtop/util"
+)
+
+func hostCollect(host *models.Host) {
+	// RAPL energy counters, watts are calculated from the counter difference
+	zones := []string{}
+	for _, zone := range util.GetSysEnergyZones() {
+		zones = append(zones, zone.Name)
+		host.AddMetricMeasurement(fmt.Sprint("power_energy_", zone.Name), models.CreateMeasurement(zone.EnergyUJ))
+		host.AddMetricMeasurement(fmt.Sprint("power_range_", zone.Name), models.CreateMeasurement(zone.MaxEnergyRangeUJ))
+	}
+	host.AddMetricMeasurement("power_zones", models.CreateMeasurement(zones))
+
+	// busy CPU time of the host, the base for the per VM attribution
+	for _, stat := range util.GetProcStatCPU() {
+		if stat.Name != "cpu" {
+			continue
+		}
+		// guest time is already accounted in user time, steal time is not spent on this host
+		busy := stat.User + stat.Nice + stat.System + stat.IRQ + stat.SoftIRQ
+		host.AddMetricMeasurement("power_cputime", models.CreateMeasurement(busy))
+		break
+	}
+
+	// temperatures and fans
+	var cpuTemp, maxTemp, fanMin float64
+	fans := 0
+	for _, sensor := range util.GetSysHwmonSensors() {
+		switch sensor.Type {
+		case "temp":
+			if sensor.Value > maxTemp {
+				maxTemp = sensor.Value
+			}
+			if isCPUTemperature(sensor) && sensor.Value > cpuTemp {
+				cpuTemp = sensor.Value
+			}
+		case "fan":
+			if sensor.Value <= 0 {
+				// not connected
+				continue
+			}
+			if fans == 0 || sensor.Value < fanMin {
+				fanMin = sensor.Value
+			}
+			fans++
+		}
+	}
+	host.AddMetricMeasurement("power_cputemp", models.CreateMeasurement(uint64(cpuTemp)))
+	host.AddMetricMeasurement("power_maxtemp", models.CreateMeasurement(uint64(maxTemp)))
+	host.AddMetricMeasurement("power_fanmin", models.CreateMeasurement(uint64(fanMin)))
+	host.AddMetricMeasurement("power_fans", models.CreateMeasurement(uint64(fans)))
+}
+
+// isCPUTemperature checks if the sensor reports a CPU package temperature
+// (coretemp "Package id <n>" on Intel, k10temp/zenpower "Tctl"/"Tdie" on AMD)
+func isCPUTemperature(sensor util.SysHwmonSensor) bool {
+	switch sensor.Chip {
+	case "coretemp":
+		return strings.HasPrefix(sensor.Label, "Package id")
+	case "k10temp", "zenpower":
+		return sensor.Label == "Tctl" || sensor.Label == "Tdie"
+	}
+	return false
+}
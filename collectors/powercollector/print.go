@@ -0,0 +1,126 @@
+package powercollector
+
+import (
+	"fmt"
+	"strings"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// AttributionModel names how package power is split between VMs:
+// VM watts = package watts * VM CPU time / busy host CPU time
+const AttributionModel = "cputime-share"
+
+// USER_HZ, the unit of /proc/stat and /proc/<pid>/stat CPU times
+const userHZ = 100
+
+func hostPrint(host *models.Host) []string {
+	var vmWatts float64
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		domain := value.(models.Domain)
+		watts, _, _ := domainAttribution(host, &domain)
+		vmWatts += watts
+		return true
+	})
+
+	cpuTemp, _ := host.GetMetricUint64("power_cputemp", 0)
+	fanMin, _ := host.GetMetricUint64("power_fanmin", 0)
+	result := []string{
+		fmt.Sprintf("%.1f", zoneWatts(host, "package")),
+		fmt.Sprintf("%.1f", zoneWatts(host, "dram")),
+		cpuTemp,
+		fanMin,
+		fmt.Sprintf("%.1f", vmWatts),
+		fmt.Sprintf("%.2f", host.GetMetricDiffUint64AsFloat("power_cputime", true)/userHZ),
+		AttributionModel,
+	}
+	if config.Options.Verbose {
+		maxTemp, _ := host.GetMetricUint64("power_maxtemp", 0)
+		fans, _ := host.GetMetricUint64("power_fans", 0)
+		result = append(result,
+			fmt.Sprintf("%.1f", zoneWatts(host, "core")),
+			fmt.Sprintf("%.1f", zoneWatts(host, "uncore")),
+			fmt.Sprintf("%.1f", zoneWatts(host, "psys")),
+			maxTemp,
+			fans,
+		)
+	}
+	return result
+}
+
+func domainPrint(domain *models.Domain) []string {
+	watts, share, cpus := domainAttribution(&models.Collection.Host, domain)
+	return []string{
+		fmt.Sprintf("%.1f", watts),
+		fmt.Sprintf("%.1f", share*100),
+		fmt.Sprintf("%.2f", cpus),
+	}
+}
+
+// domainAttribution returns the estimated watts of a VM, its share of the busy host CPU time
+// and the CPUs it used (CPU seconds per second)
+func domainAttribution(host *models.Host, domain *models.Domain) (float64, float64, float64) {
+	hostCPUs := host.GetMetricDiffUint64AsFloat("power_cputime", true) / userHZ
+	cpus := domain.GetMetricDiffUint64AsFloat("power_cputime", true) / userHZ
+	if hostCPUs <= 0 {
+		return 0, 0, cpus
+	}
+	share := cpus / hostCPUs
+	if share > 1 {
+		// host and VM counters are read at slightly different times
+		share = 1
+	}
+	return zoneWatts(host, "package") * share, share, cpus
+}
+
+// zoneWatts returns the summed power of all energy zones of the given kind (e.g. all packages)
+func zoneWatts(host *models.Host, kind string) float64 {
+	var watts float64
+	for _, zone := range host.GetMetricStringArray("power_zones") {
+		// package0, package1, ... or psys
+		if strings.HasPrefix(zone, kind) && isDigits(strings.TrimPrefix(zone, kind)) {
+			watts += energyWatts(host, zone)
+		}
+	}
+	return watts
+}
+
+// energyWatts calculates the power of a zone from its energy counter, handling the wrap around
+// of the RAPL counter at max_energy_range_uj
+func energyWatts(host *models.Host, zone string) float64 {
+	metricName := fmt.Sprint("power_energy_", zone)
+	metric, ok := host.GetMetric(metricName)
+	if !ok || metric.Len() < 2 {
+		return 0
+	}
+	current, err1 := host.GetMetricUint64Raw(metricName, 0)
+	previous, err2 := host.GetMetricUint64Raw(metricName, 1)
+	if err1 != nil || err2 != nil {
+		return 0
+	}
+	diff := current - previous
+	if current < previous {
+		maxRange, _ := host.GetMetricUint64Raw(fmt.Sprint("power_range_", zone), 0)
+		if maxRange == 0 || previous > maxRange {
+			// counter reset
+			return 0
+		}
+		diff = maxRange - previous + current
+	}
+	seconds := metric.At(0).Timestamp.Sub(metric.At(1).Timestamp).Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	// microjoules per second to watts
+	return float64(diff) / 1000000 / seconds
+}
+
+func isDigits(value string) bool {
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
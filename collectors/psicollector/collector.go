@@ -22,47 +22,10 @@ func (collector *Collector) Collect() {
 
 // Print returns the collectors measurements in a Printable struct
 func (collector *Collector) Print() models.Printable {
-	hostFields := []string{
-		"psi_some_cpu_avg60",
-
-		"psi_some_io_avg60",
-		"psi_full_io_avg60",
-
-		"psi_some_mem_avg60",
-		"psi_full_mem_avg60",
-	}
-	domainFields := []string{}
-
-	if config.Options.Verbose {
-		hostFields = []string{
-			"psi_some_cpu_avg10",
-			"psi_some_cpu_avg60",
-			"psi_some_cpu_avg300",
-			"psi_some_cpu_total",
-
-			"psi_some_io_avg10",
-			"psi_some_io_avg60",
-			"psi_some_io_avg300",
-			"psi_some_io_total",
-			"psi_full_io_avg10",
-			"psi_full_io_avg60",
-			"psi_full_io_avg300",
-			"psi_full_io_total",
-
-			"psi_some_mem_avg10",
-			"psi_some_mem_avg60",
-			"psi_some_mem_avg300",
-			"psi_some_mem_total",
-			"psi_full_mem_avg10",
-			"psi_full_mem_avg60",
-			"psi_full_mem_avg300",
-			"psi_full_mem_total",
-		}
-	}
-
+	// fields are declared in fields.go
 	printable := models.Printable{
-		HostFields:   hostFields,
-		DomainFields: domainFields,
+		HostFields:   models.FieldNames("psi", models.ScopeHost, config.Options.Verbose),
+		DomainFields: []string{},
 	}
 
 	// lookup for host
@@ -0,0 +1,38 @@
+package psicollector
+
+import "proxtop/models"
+
+func init() {
+	// some: at least one task stalled, full: all non-idle tasks stalled at the same time
+	models.RegisterFields("psi", models.ScopeHost, []models.FieldDef{
+		{Name: "psi_some_cpu_avg10", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time some tasks stalled on CPU over 10s", Verbose: true},
+		{Name: "psi_some_cpu_avg60", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time some tasks stalled on CPU over 60s"},
+		{Name: "psi_some_cpu_avg300", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time some tasks stalled on CPU over 300s", Verbose: true},
+		{Name: "psi_some_cpu_total", Kind: models.KindCounter, Unit: models.UnitMicroseconds, Description: "Total time some tasks stalled on CPU", Verbose: true},
+		{Name: "psi_some_io_avg10", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time some tasks stalled on I/O over 10s", Verbose: true},
+		{Name: "psi_some_io_avg60", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time some tasks stalled on I/O over 60s"},
+		{Name: "psi_some_io_avg300", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time some tasks stalled on I/O over 300s", Verbose: true},
+		{Name: "psi_some_io_total", Kind: models.KindCounter, Unit: models.UnitMicroseconds, Description: "Total time some tasks stalled on I/O", Verbose: true},
+		{Name: "psi_full_io_avg10", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time all tasks stalled on I/O over 10s", Verbose: true},
+		{Name: "psi_full_io_avg60", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time all tasks stalled on I/O over 60s"},
+		{Name: "psi_full_io_avg300", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time all tasks stalled on I/O over 300s", Verbose: true},
+		{Name: "psi_full_io_total", Kind: models.KindCounter, Unit: models.UnitMicroseconds, Description: "Total time all tasks stalled on I/O", Verbose: true},
+		{Name: "psi_some_mem_avg10", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time some tasks stalled on memory over 10s", Verbose: true},
+		{Name: "psi_some_mem_avg60", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time some tasks stalled on memory over 60s"},
+		{Name: "psi_some_mem_avg300", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time some tasks stalled on memory over 300s", Verbose: true},
+		{Name: "psi_some_mem_total", Kind: models.KindCounter, Unit: models.UnitMicroseconds, Description: "Total time some tasks stalled on memory", Verbose: true},
+		{Name: "psi_full_mem_avg10", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time all tasks stalled on memory over 10s", Verbose: true},
+		{Name: "psi_full_mem_avg60", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time all tasks stalled on memory over 60s"},
+		{Name: "psi_full_mem_avg300", Kind: models.KindGauge, Unit: models.UnitPercent, Description: "Time all tasks stalled on memory over 300s", Verbose: true},
+		{Name: "psi_full_mem_total", Kind: models.KindCounter, Unit: models.UnitMicroseconds, Description: "Total time all tasks stalled on memory", Verbose: true},
+	})
+
+	// raw counters for exporters, in microseconds
+	models.RegisterCounters("psi", models.ScopeHost, []models.CounterDef{
+		{Metric: "psi_some_cpu_total", Name: "psi_some_cpu_stalled", BaseUnit: "seconds", Scale: 1e-6, Description: "Total time some tasks stalled on CPU"},
+		{Metric: "psi_some_io_total", Name: "psi_some_io_stalled", BaseUnit: "seconds", Scale: 1e-6, Description: "Total time some tasks stalled on I/O"},
+		{Metric: "psi_full_io_total", Name: "psi_full_io_stalled", BaseUnit: "seconds", Scale: 1e-6, Description: "Total time all tasks stalled on I/O"},
+		{Metric: "psi_some_mem_total", Name: "psi_some_mem_stalled", BaseUnit: "seconds", Scale: 1e-6, Description: "Total time some tasks stalled on memory"},
+		{Metric: "psi_full_mem_total", Name: "psi_full_mem_stalled", BaseUnit: "seconds", Scale: 1e-6, Description: "Total time all tasks stalled on memory"},
+	})
+}
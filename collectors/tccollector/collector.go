@@ -0,0 +1,79 @@
+package tccollector
+
+import (
+	"proxtop/connector"
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// Collector describes the traffic control (tc) collector
+type Collector struct {
+	models.Collector
+}
+
+// Lookup tc collector data
+func (collector *Collector) Lookup() {
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		if connector.IsProxmox() {
+			vmInfo, ok := connector.ProxmoxVMStore.Load(uuid)
+			if ok {
+				domainLookupProxmox(&domain, vmInfo)
+			}
+		} else {
+			libvirtDomain, _ := models.Collection.LibvirtDomains.Load(uuid)
+			domainLookup(&domain, libvirtDomain)
+		}
+		return true
+	})
+}
+
+// Collect tc collector data
+func (collector *Collector) Collect() {
+	// the qdiscs of all devices are dumped at once, the classes per device
+	qdiscs, err := util.GetNetlinkQdiscs()
+	if err != nil {
+		logNetlinkError(err)
+	}
+
+	// lookup for each domain
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
+		domain := value.(models.Domain)
+		domainCollect(&domain, qdiscs)
+		return true
+	})
+}
+
+// Print returns the collectors measurements in a Printable struct
+func (collector *Collector) Print() models.Printable {
+	printable := models.Printable{
+		HostFields:   []string{},
+		HostValues:   []string{},
+		DomainFields: DomainTCFields(),
+	}
+
+	// lookup for each domain
+	printable.DomainValues = make(map[string][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		printable.DomainValues[uuid] = domainPrint(&domain)
+		printable.AddDomainDevices(uuid, models.DomainDevicesTC, printable.DomainFields, DomainPrintPerInterface(&domain))
+		return true
+	})
+
+	return printable
+}
+
+// CreateCollector creates a new tc collector
+func CreateCollector() Collector {
+	// struct gnet_stats_queue counts in __u32, the totals of a VM wrap around with the counter of an interface
+	models.DeclareCounter("tc_egress_drops", 32)
+	models.DeclareCounter("tc_egress_overlimits", 32)
+	models.DeclareCounter("tc_egress_requeues", 32)
+	models.DeclareCounter("tc_ingress_drops", 32)
+	models.DeclareCounter("tc_class_overlimits", 32)
+	models.DeclareCounter("tc_shaped", 32)
+	return Collector{}
+}
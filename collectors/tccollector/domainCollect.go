@@ -0,0 +1,151 @@
+package tccollector
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// tcStats holds the accumulated tc counters of one tap interface
+type tcStats struct {
+	EgressBytes      uint64
+	EgressPackets    uint64
+	EgressDrops      uint64
+	EgressOverlimits uint64
+	EgressRequeues   uint64
+	EgressBacklog    uint64
+	EgressQlen       uint64
+	IngressPackets   uint64
+	IngressDrops     uint64
+	ClassBytes       uint64
+	ClassOverlimits  uint64
+	Shaped           uint64
+	Qdisc            string
+}
+
+// netlinkErrorOnce logs the first netlink error only, it recurs for every interface in every cycle
+var netlinkErrorOnce sync.Once
+
+func domainCollect(domain *models.Domain, hostQdiscs map[int][]util.NetlinkTCStat) {
+	ifs := domain.GetMetricStringArray("tc_interfaces")
+	statsSum := tcStats{}
+	qdiscs := []string{}
+	for _, devname := range ifs {
+		devStats := collectInterface(devname, hostQdiscs)
+
+		// Store per-interface stats with device name suffix
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_egress_bytes_%s", devname), models.CreateMeasurement(devStats.EgressBytes))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_egress_packets_%s", devname), models.CreateMeasurement(devStats.EgressPackets))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_egress_drops_%s", devname), models.CreateMeasurement(devStats.EgressDrops))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_egress_overlimits_%s", devname), models.CreateMeasurement(devStats.EgressOverlimits))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_egress_requeues_%s", devname), models.CreateMeasurement(devStats.EgressRequeues))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_egress_backlog_%s", devname), models.CreateMeasurement(devStats.EgressBacklog))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_egress_qlen_%s", devname), models.CreateMeasurement(devStats.EgressQlen))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_ingress_packets_%s", devname), models.CreateMeasurement(devStats.IngressPackets))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_ingress_drops_%s", devname), models.CreateMeasurement(devStats.IngressDrops))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_class_bytes_%s", devname), models.CreateMeasurement(devStats.ClassBytes))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_class_overlimits_%s", devname), models.CreateMeasurement(devStats.ClassOverlimits))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_shaped_%s", devname), models.CreateMeasurement(devStats.Shaped))
+		domain.AddMetricMeasurement(fmt.Sprintf("tc_qdisc_%s", devname), models.CreateMeasurement(devStats.Qdisc))
+
+		// Sum for totals
+		statsSum.EgressBytes += devStats.EgressBytes
+		statsSum.EgressPackets += devStats.EgressPackets
+		statsSum.EgressDrops += devStats.EgressDrops
+		statsSum.EgressOverlimits += devStats.EgressOverlimits
+		statsSum.EgressRequeues += devStats.EgressRequeues
+		statsSum.EgressBacklog += devStats.EgressBacklog
+		statsSum.EgressQlen += devStats.EgressQlen
+		statsSum.IngressPackets += devStats.IngressPackets
+		statsSum.IngressDrops += devStats.IngressDrops
+		statsSum.ClassBytes += devStats.ClassBytes
+		statsSum.ClassOverlimits += devStats.ClassOverlimits
+		statsSum.Shaped += devStats.Shaped
+		qdiscs = append(qdiscs, devStats.Qdisc)
+	}
+	domain.AddMetricMeasurement("tc_egress_bytes", models.CreateMeasurement(statsSum.EgressBytes))
+	domain.AddMetricMeasurement("tc_egress_packets", models.CreateMeasurement(statsSum.EgressPackets))
+	domain.AddMetricMeasurement("tc_egress_drops", models.CreateMeasurement(statsSum.EgressDrops))
+	domain.AddMetricMeasurement("tc_egress_overlimits", models.CreateMeasurement(statsSum.EgressOverlimits))
+	domain.AddMetricMeasurement("tc_egress_requeues", models.CreateMeasurement(statsSum.EgressRequeues))
+	domain.AddMetricMeasurement("tc_egress_backlog", models.CreateMeasurement(statsSum.EgressBacklog))
+	domain.AddMetricMeasurement("tc_egress_qlen", models.CreateMeasurement(statsSum.EgressQlen))
+	domain.AddMetricMeasurement("tc_ingress_packets", models.CreateMeasurement(statsSum.IngressPackets))
+	domain.AddMetricMeasurement("tc_ingress_drops", models.CreateMeasurement(statsSum.IngressDrops))
+	domain.AddMetricMeasurement("tc_class_bytes", models.CreateMeasurement(statsSum.ClassBytes))
+	domain.AddMetricMeasurement("tc_class_overlimits", models.CreateMeasurement(statsSum.ClassOverlimits))
+	domain.AddMetricMeasurement("tc_shaped", models.CreateMeasurement(statsSum.Shaped))
+	domain.AddMetricMeasurement("tc_qdisc", models.CreateMeasurement(strings.Join(qdiscs, ";")))
+}
+
+// collectInterface reads the qdisc and class statistics of a tap interface.
+// Proxmox applies the netX "rate" option as htb root qdisc (tap egress, traffic
+// towards the guest) and as police filter on the ingress qdisc (tap ingress,
+// traffic sent by the guest).
+func collectInterface(devname string, qdiscs map[int][]util.NetlinkTCStat) tcStats {
+	stats := tcStats{}
+
+	// a tap device which is gone has no statistics
+	iface, err := net.InterfaceByName(devname)
+	if err != nil {
+		return tcStats{Qdisc: "-"}
+	}
+
+	for _, qdisc := range qdiscs[iface.Index] {
+		switch qdisc.Parent {
+		case util.TCParentRoot:
+			stats.EgressBytes += qdisc.Bytes
+			stats.EgressPackets += qdisc.Packets
+			stats.EgressDrops += qdisc.Drops
+			stats.EgressOverlimits += qdisc.Overlimits
+			stats.EgressRequeues += qdisc.Requeues
+			stats.EgressBacklog += qdisc.Backlog
+			stats.EgressQlen += qdisc.Qlen
+			stats.Qdisc = qdisc.Kind
+		case util.TCParentIngress:
+			stats.IngressPackets += qdisc.Packets
+			stats.IngressDrops += qdisc.Drops
+		}
+	}
+
+	hasClasses := false
+	classes, err := util.GetNetlinkClasses(iface.Index)
+	if err != nil {
+		logNetlinkError(err)
+	}
+	for _, class := range classes {
+		// only top level classes, their children are accounted for in their parent
+		if class.Parent != util.TCParentRoot {
+			continue
+		}
+		hasClasses = true
+		stats.ClassBytes += class.Bytes
+		stats.ClassOverlimits += class.Overlimits
+	}
+
+	// packets delayed by the shaper: class overlimits for classful qdiscs (htb),
+	// qdisc overlimits otherwise (tbf)
+	if hasClasses {
+		stats.Shaped = stats.ClassOverlimits
+	} else {
+		stats.Shaped = stats.EgressOverlimits
+	}
+
+	if stats.Qdisc == "" {
+		stats.Qdisc = "-"
+	}
+
+	return stats
+}
+
+// logNetlinkError logs the first error reading the tc statistics
+func logNetlinkError(err error) {
+	netlinkErrorOnce.Do(func() {
+		log.Printf("tc: %v", err)
+	})
+}
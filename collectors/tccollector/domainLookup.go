@@ -0,0 +1,50 @@
+package tccollector
+
+import (
+	"time"
+
+	"proxtop/connector"
+	"proxtop/models"
+
+	libvirt "github.com/libvirt/libvirt-go"
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+)
+
+func domainLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
+	var ifs []string
+	start := time.Now()
+	xmldoc, err := libvirtDomain.GetXMLDesc(libvirt.DOMAIN_XML_SECURE)
+	models.RecordCall(models.CallLibvirt, start, err)
+	domcfg := &libvirtxml.Domain{}
+	domcfg.Unmarshal(xmldoc)
+
+	if domcfg.Devices != nil {
+		for _, devInterface := range domcfg.Devices.Interfaces {
+			if devInterface.Target != nil {
+				ifs = append(ifs, devInterface.Target.Dev)
+			}
+		}
+	}
+
+	domain.AddMetricMeasurement("tc_interfaces", models.CreateMeasurement(ifs))
+}
+
+// domainLookupProxmox handles tc interface lookup for Proxmox VMs
+func domainLookupProxmox(domain *models.Domain, vmInfo connector.VMInfo) {
+	var ifs []string
+
+	// tap interfaces carry the rate limit set in the VM's netX config
+	if len(vmInfo.Interfaces) > 0 {
+		ifs = vmInfo.Interfaces
+	} else {
+		proxmoxConn, ok := connector.CurrentConnector.(*connector.ProxmoxConnector)
+		if ok {
+			interfaces, err := proxmoxConn.GetNetworkInterfaces(vmInfo)
+			if err == nil {
+				ifs = interfaces
+			}
+		}
+	}
+
+	domain.AddMetricMeasurement("tc_interfaces", models.CreateMeasurement(ifs))
+}
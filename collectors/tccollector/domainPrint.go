@@ -0,0 +1,47 @@
+package tccollector
+
+import (
+	"fmt"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// DomainTCFields returns the domain field names of the tc collector
+func DomainTCFields() []string {
+	return models.FieldNames("tc", models.ScopeDomain, config.Options.Verbose)
+}
+
+func domainPrint(domain *models.Domain) []string {
+	return printInterface(domain, "")
+}
+
+// DomainPrintPerInterface returns per-interface tc stats for a domain
+// Returns a map of interface name -> []string (field values in same order as DomainTCFields)
+func DomainPrintPerInterface(domain *models.Domain) map[string][]string {
+	ifsRaw := domain.GetMetricStringArray("tc_interfaces")
+	result := make(map[string][]string)
+
+	for _, devname := range ifsRaw {
+		result[devname] = printInterface(domain, "_"+devname)
+	}
+
+	return result
+}
+
+// printInterface formats the tc metrics with the given suffix ("" for domain totals)
+func printInterface(domain *models.Domain, suffix string) []string {
+	shaped := domain.GetMetricDiffUint64(fmt.Sprintf("tc_shaped%s", suffix), true)
+	dropRx := domain.GetMetricDiffUint64(fmt.Sprintf("tc_ingress_drops%s", suffix), true)
+	dropTx := domain.GetMetricDiffUint64(fmt.Sprintf("tc_egress_drops%s", suffix), true)
+
+	result := []string{shaped, dropRx, dropTx}
+	if config.Options.Verbose {
+		backlog, _ := domain.GetMetricUint64(fmt.Sprintf("tc_egress_backlog%s", suffix), 0)
+		qlen, _ := domain.GetMetricUint64(fmt.Sprintf("tc_egress_qlen%s", suffix), 0)
+		requeues := domain.GetMetricDiffUint64(fmt.Sprintf("tc_egress_requeues%s", suffix), true)
+		qdisc := domain.GetMetricString(fmt.Sprintf("tc_qdisc%s", suffix), 0)
+		result = append(result, backlog, qlen, requeues, qdisc)
+	}
+	return result
+}
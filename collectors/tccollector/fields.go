@@ -0,0 +1,25 @@
+package tccollector
+
+import "proxtop/models"
+
+func init() {
+	// esxtop style: SHAPED/s, LIMDRPRX/s, LIMDRPTX/s, directions as seen from the VM
+	models.RegisterFields("tc", models.ScopeDomain, []models.FieldDef{
+		{Name: "tc_SHAPED/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Packets delayed by the shaper"},
+		{Name: "tc_LIMDRPRX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Packets from the VM dropped by the ingress policer"},
+		{Name: "tc_LIMDRPTX/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Packets to the VM dropped by the root qdisc"},
+		{Name: "tc_BACKLOG", Kind: models.KindGauge, Unit: models.UnitBytes, Description: "Bytes queued in the root qdisc", Verbose: true},
+		{Name: "tc_QLEN", Kind: models.KindGauge, Description: "Packets queued in the root qdisc", Verbose: true},
+		{Name: "tc_REQUEUE/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Packets requeued by the root qdisc", Verbose: true},
+		{Name: "tc_QDISC", Kind: models.KindInfo, Description: "Kind of the root qdisc", Verbose: true},
+	})
+
+	// raw counters of the root qdisc per interface for exporters
+	models.RegisterCounters("tc", models.ScopeDomain, []models.CounterDef{
+		{Metric: "tc_egress_bytes_*", Name: "tc_egress", BaseUnit: "bytes", Description: "Bytes sent by the root qdisc"},
+		{Metric: "tc_egress_packets_*", Name: "tc_egress_packets", Description: "Packets sent by the root qdisc"},
+		{Metric: "tc_egress_drops_*", Name: "tc_egress_drops", Description: "Packets dropped by the root qdisc"},
+		{Metric: "tc_egress_overlimits_*", Name: "tc_egress_overlimits", Description: "Packets over the limit of the root qdisc"},
+		{Metric: "tc_ingress_drops_*", Name: "tc_ingress_drops", Description: "Packets dropped by the ingress policer"},
+	})
+}
@@ -0,0 +1,71 @@
+package vfiocollector
+
+import (
+	"proxtop/config"
+	"proxtop/connector"
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// Collector describes the vfio (PCI passthrough) collector
+type Collector struct {
+	models.Collector
+}
+
+// Lookup vfio collector data
+func (collector *Collector) Lookup() {
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		if connector.IsProxmox() {
+			vmInfo, ok := connector.ProxmoxVMStore.Load(uuid)
+			if ok {
+				domainLookupProxmox(&domain, vmInfo)
+			}
+		} else {
+			libvirtDomain, _ := models.Collection.LibvirtDomains.Load(uuid)
+			domainLookup(&domain, libvirtDomain)
+		}
+		return true
+	})
+}
+
+// Collect vfio collector data
+func (collector *Collector) Collect() {
+	// read /proc/interrupts once for all domains
+	interrupts := vfioInterrupts(util.GetProcInterrupts())
+
+	models.Collection.Domains.RangeGuarded(func(key, value interface{}) bool {
+		domain := value.(models.Domain)
+		domainCollect(&domain, interrupts)
+		return true
+	})
+}
+
+// Print returns the collectors measurements in a Printable struct
+func (collector *Collector) Print() models.Printable {
+	// fields are declared in fields.go
+	printable := models.Printable{
+		HostFields:   []string{},
+		DomainFields: models.FieldNames("vfio", models.ScopeDomain, config.Options.Verbose),
+	}
+
+	// lookup for each domain
+	printable.DomainValues = make(map[string][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		printable.DomainValues[uuid] = domainPrint(&domain)
+		return true
+	})
+	printable.AddDevices(models.DevicesVFIO, DomainVFIOFields(), DomainPrintPerDevice())
+
+	return printable
+}
+
+// CreateCollector creates a new vfio collector
+func CreateCollector() Collector {
+	// interrupt counts of /proc/interrupts are unsigned int
+	models.DeclareCounter("vfio_irqs_", 32)
+	return Collector{}
+}
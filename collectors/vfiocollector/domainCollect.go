@@ -0,0 +1,40 @@
+package vfiocollector
+
+import (
+	"fmt"
+
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// vfioInterruptStats holds the interrupt count and number of vectors of a passed through device
+type vfioInterruptStats struct {
+	count   uint64
+	vectors uint64
+}
+
+// vfioInterrupts sums the vfio interrupt lines (vfio-msix[n](<address>)) of all CPUs per PCI address
+func vfioInterrupts(interrupts []util.ProcInterrupt) map[string]vfioInterruptStats {
+	stats := make(map[string]vfioInterruptStats)
+	for _, interrupt := range interrupts {
+		address := util.GetVFIOInterruptAddress(interrupt.Device)
+		if address == "" {
+			continue
+		}
+		stat := stats[address]
+		for _, count := range interrupt.Counts {
+			stat.count += count
+		}
+		stat.vectors++
+		stats[address] = stat
+	}
+	return stats
+}
+
+func domainCollect(domain *models.Domain, interrupts map[string]vfioInterruptStats) {
+	for _, address := range domain.GetMetricStringArray("vfio_devices") {
+		stat := interrupts[address]
+		domain.AddMetricMeasurement(fmt.Sprint("vfio_irqs_", address), models.CreateMeasurement(stat.count))
+		domain.AddMetricMeasurement(fmt.Sprint("vfio_vectors_", address), models.CreateMeasurement(stat.vectors))
+	}
+}
@@ -0,0 +1,79 @@
+package vfiocollector
+
+import (
+	"fmt"
+	"time"
+
+	"proxtop/connector"
+	"proxtop/models"
+	"proxtop/util"
+
+	libvirt "github.com/libvirt/libvirt-go"
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+)
+
+func domainLookup(domain *models.Domain, libvirtDomain libvirt.Domain) {
+	ids := []string{}
+	start := time.Now()
+	xmldoc, err := libvirtDomain.GetXMLDesc(libvirt.DOMAIN_XML_SECURE)
+	models.RecordCall(models.CallLibvirt, start, err)
+	domcfg := &libvirtxml.Domain{}
+	domcfg.Unmarshal(xmldoc)
+
+	if domcfg.Devices != nil {
+		for _, hostdev := range domcfg.Devices.Hostdevs {
+			if hostdev.SubsysPCI == nil || hostdev.SubsysPCI.Source == nil || hostdev.SubsysPCI.Source.Address == nil {
+				continue
+			}
+			address := hostdev.SubsysPCI.Source.Address
+			if address.Domain == nil || address.Bus == nil || address.Slot == nil || address.Function == nil {
+				continue
+			}
+			ids = append(ids, fmt.Sprintf("%04x:%02x:%02x.%x", *address.Domain, *address.Bus, *address.Slot, *address.Function))
+		}
+	}
+
+	lookupDevices(domain, ids)
+}
+
+// domainLookupProxmox handles the hostpciN lookup for Proxmox VMs
+func domainLookupProxmox(domain *models.Domain, vmInfo connector.VMInfo) {
+	ids := []string{}
+	proxmoxConn, ok := connector.CurrentConnector.(*connector.ProxmoxConnector)
+	if ok {
+		if hostpci, err := proxmoxConn.GetPCIPassthrough(vmInfo); err == nil {
+			ids = hostpci
+		}
+	}
+
+	lookupDevices(domain, ids)
+}
+
+// lookupDevices stores the passed through PCI devices with driver, class, IOMMU group and NUMA node
+func lookupDevices(domain *models.Domain, ids []string) {
+	devices := []string{}
+	for _, id := range ids {
+		for _, address := range util.ExpandPCIAddress(id) {
+			if util.ContainsString(devices, address) {
+				continue
+			}
+			devices = append(devices, address)
+
+			device := util.GetSysPCIDevice(address)
+			domain.AddMetricMeasurement(fmt.Sprint("vfio_driver_", address), models.CreateMeasurement(device.Driver))
+			domain.AddMetricMeasurement(fmt.Sprint("vfio_class_", address), models.CreateMeasurement(device.ClassName()))
+			domain.AddMetricMeasurement(fmt.Sprint("vfio_id_", address), models.CreateMeasurement(device.ID))
+			domain.AddMetricMeasurement(fmt.Sprint("vfio_iommu_", address), models.CreateMeasurement(formatNumber(device.IOMMUGroup)))
+			domain.AddMetricMeasurement(fmt.Sprint("vfio_numa_", address), models.CreateMeasurement(formatNumber(device.NUMANode)))
+		}
+	}
+	domain.AddMetricMeasurement("vfio_devices", models.CreateMeasurement(devices))
+}
+
+// formatNumber prints -1 (not available) as "-"
+func formatNumber(number int) string {
+	if number < 0 {
+		return "-"
+	}
+	return fmt.Sprint(number)
+}
@@ -0,0 +1,67 @@
+package vfiocollector
+
+import (
+	"fmt"
+	"strings"
+
+	"proxtop/config"
+	"proxtop/models"
+	"proxtop/util"
+)
+
+func domainPrint(domain *models.Domain) []string {
+	devices := domain.GetMetricStringArray("vfio_devices")
+
+	var irqRate float64
+	nodes := []string{}
+	for _, address := range devices {
+		irqRate = models.AddRate(irqRate, domain.GetMetricDiffUint64AsFloat(fmt.Sprint("vfio_irqs_", address), true))
+		node := domain.GetMetricString(fmt.Sprint("vfio_numa_", address), 0)
+		if !util.ContainsString(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+
+	result := []string{
+		fmt.Sprintf("%d", len(devices)),
+		fmt.Sprintf("%.0f", irqRate),
+	}
+	if config.Options.Verbose {
+		numa := "-"
+		if len(nodes) > 0 {
+			numa = strings.Join(nodes, ",")
+		}
+		result = append(result, numa)
+	}
+	return result
+}
+
+// DomainVFIOFields returns the field names for the passthrough device view
+func DomainVFIOFields() []string {
+	return models.DeviceFieldNames(models.DevicesVFIO, config.Options.Verbose)
+}
+
+// DomainPrintPerDevice returns the passed through PCI devices of all VMs for the passthrough device view
+// Returns a map of PCI address -> []string (field values in same order as DomainVFIOFields)
+func DomainPrintPerDevice() map[string][]string {
+	result := make(map[string][]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		domain := value.(models.Domain)
+		for _, address := range domain.GetMetricStringArray("vfio_devices") {
+			vectors, _ := domain.GetMetricUint64(fmt.Sprint("vfio_vectors_", address), 0)
+			result[address] = []string{
+				address,
+				domain.Name,
+				domain.GetMetricString(fmt.Sprint("vfio_class_", address), 0),
+				domain.GetMetricString(fmt.Sprint("vfio_id_", address), 0),
+				domain.GetMetricString(fmt.Sprint("vfio_driver_", address), 0),
+				domain.GetMetricString(fmt.Sprint("vfio_iommu_", address), 0),
+				domain.GetMetricString(fmt.Sprint("vfio_numa_", address), 0),
+				vectors,
+				domain.GetMetricDiffUint64(fmt.Sprint("vfio_irqs_", address), true),
+			}
+		}
+		return true
+	})
+	return result
+}
@@ -0,0 +1,24 @@
+package vfiocollector
+
+import "proxtop/models"
+
+func init() {
+	models.RegisterFields("vfio", models.ScopeDomain, []models.FieldDef{
+		{Name: "vfio_DEVS", Kind: models.KindInfo, Description: "Passed through PCI functions"},
+		{Name: "vfio_IRQ/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Interrupts of the passed through devices"},
+		{Name: "vfio_NODES", Kind: models.KindInfo, Description: "NUMA nodes of the passed through devices", Verbose: true},
+	})
+
+	// passthrough view 'v'
+	models.RegisterDeviceFields("vfio", []string{models.DevicesVFIO}, []models.FieldDef{
+		{Name: "vfio_DEVICE", Kind: models.KindInfo, Description: "PCI address"},
+		{Name: "vfio_VM", Kind: models.KindInfo, Description: "VM the device is passed through to"},
+		{Name: "vfio_TYPE", Kind: models.KindInfo, Description: "Device class"},
+		{Name: "vfio_ID", Kind: models.KindInfo, Description: "PCI vendor and device ID"},
+		{Name: "vfio_DRIVER", Kind: models.KindInfo, Description: "Bound host driver"},
+		{Name: "vfio_IOMMU", Kind: models.KindInfo, Description: "IOMMU group, - if the IOMMU is disabled"},
+		{Name: "vfio_NUMA", Kind: models.KindInfo, Description: "NUMA node of the device, - if unknown"},
+		{Name: "vfio_VEC", Kind: models.KindGauge, Description: "Interrupt vectors"},
+		{Name: "vfio_IRQ/s", Kind: models.KindRate, Unit: models.UnitPerSecond, Description: "Interrupts of the device"},
+	})
+}
@@ -3,8 +3,14 @@ package config
 // OptionsType defines the runtime configuration parameters
 type OptionsType struct {
 	Version    bool   `short:"v" long:"version" description:"Show version"`
+	Schema     bool   `long:"schema" description:"Print a JSON schema of all fields with unit, kind and description"`
 	Frequency  int    `short:"f" long:"frequency" description:"Frequency (in seconds) for collecting metrics" default:"2"`
 	Runs       int    `short:"r" long:"runs" description:"Amount of collection runs" default:"-1"`
+	Parallel   int    `long:"parallel" description:"Amount of collectors collecting at the same time within a cycle" default:"4"`
+	CollectorTimeout int `long:"collector-timeout" description:"Seconds a collector may take per lookup or collect phase before the cycle continues without it" default:"5"`
+	VMTimeout        int `long:"vm-timeout" description:"Seconds a collector may take for one VM before the VM is skipped" default:"2"`
+	VMBackoff        int `long:"vm-backoff" description:"Seconds a VM not responding in time is skipped, doubled on each failed retry" default:"30"`
+	History    int    `long:"history" description:"Amount of measurements kept per metric for rates, windows and trends (minimum 2)" default:"2"`
 	LibvirtURI string `short:"c" long:"connection" description:"connection uri to libvirt daemon" default:"qemu:///system"`
 	ProcFS        string `long:"procfs" description:"path to the proc filesystem" default:"/proc"`
 	Verbose       bool   `long:"verbose" description:"Verbose output, adds more detailed fields"`
@@ -21,11 +27,46 @@ type OptionsType struct {
 	EnableIO       bool `long:"io" description:"enable io metrics (requires root)"`
 	EnablePressure bool `long:"pressure" description:"enable pressure metrics (requires kernel 4.20+)"`
 	EnableHost     bool `long:"host" description:"enable host metrics"`
+	EnableTC       bool `long:"tc" description:"enable traffic control (qdisc/class) metrics of VM interfaces"`
+	EnableIRQ      bool `long:"irq" description:"enable per-CPU interrupt and softirq metrics"`
+	EnableVFIO     bool `long:"vfio" description:"enable PCI passthrough (vfio) device inventory and interrupt rates"`
+	EnablePower    bool `long:"power" description:"enable RAPL power, hwmon temperature/fan metrics and per-VM power estimation"`
+	EnableMigrate  bool `long:"migrate" description:"enable live migration progress monitoring (QMP query-migrate / libvirt job stats)"`
+	EnableDirty    bool `long:"dirtyrate" description:"enable dirty page rate measurement of VMs (QMP calc-dirty-rate, page-sampling)"`
+	EnableJobs     bool `long:"jobs" description:"enable backup and block job (mirror, stream, commit) monitoring"`
+	EnableOverhead bool `long:"overhead" description:"enable hypervisor overhead view of host processes and kernel threads outside of VMs"`
 
-	Printer string `short:"p" long:"printer" description:"the output printer to use (valid printers: ncurses, text, json)" default:"ncurses"`
+	Plugins       []string `long:"plugin" description:"run an external plugin NAME[:MODE]=COMMAND reporting host and VM metrics, MODE collect (default), lookup or daemon (repeatable)"`
+	PluginTimeout int      `long:"plugin-timeout" description:"seconds a plugin run may take before it is killed" default:"5"`
+	PluginMaxAge  int      `long:"plugin-max-age" description:"seconds a metric of a daemon plugin is kept after it was last reported" default:"60"`
 
-	Output       string `short:"o" long:"output" description:"the output channel to send printer output (valid output: stdout, file, tcp, udp)" default:"stdout"`
-	OutputTarget string `long:"target" description:"for output 'file' the location, for 'tcp' or 'udp' the url (host:port) to the server"`
+	DirtyRateInterval int `long:"dirtyrate-interval" description:"seconds between periodic dirty rate measurements of a VM, 0 for on-demand only (key 'y', SIGUSR1)" default:"300"`
+	DirtyRateWindow   int `long:"dirtyrate-window" description:"dirty rate measurement window in seconds (1-60)" default:"5"`
+
+	Derive     []string `long:"derive" description:"define a computed field [host:]NAME=EXPRESSION of other fields, e.g. 'dsk_IOPSVCPU=({dsk_READS/s}+{dsk_WRITES/s})/cpu_cores' (repeatable)"`
+	DeriveFile string   `long:"derive-file" description:"file with computed field definitions, one [host:]NAME=EXPRESSION per line"`
+
+	Printer string `short:"p" long:"printer" description:"the output printer to use (valid printers: ncurses, text, json, prometheus, influx, csv)" default:"ncurses"`
+	Listen  string `long:"listen" description:"for printer 'prometheus' the address (host:port) to serve /metrics on" default:":9910"`
+
+	JSONMachineNames bool `long:"json-machine-names" description:"for printer 'json' name fields for machines, lower case without % and /, e.g. cpu_pct_used for cpu_%used"`
+
+	CSVFormat string `long:"csv-format" description:"for printer 'csv' the format, esxtop for the PDH-CSV format of esxtop -b (valid formats: plain, esxtop)" default:"plain"`
+
+	InfluxPrecision string `long:"influx-precision" description:"for printer 'influx' the timestamp precision (valid precisions: ns, us, ms, s)" default:"s"`
+	InfluxToken     string `long:"influx-token" env:"PROXTOP_INFLUX_TOKEN" description:"for output 'http' the InfluxDB API token (v2) or user:password (v1)"`
+	InfluxBatch     int    `long:"influx-batch" description:"for output 'http' the maximum number of lines per write request" default:"5000"`
+	InfluxFlush     int    `long:"influx-flush" description:"for output 'http' the maximum seconds lines are batched before they are written" default:"10"`
+	InfluxGzip      bool   `long:"influx-gzip" description:"for output 'http' compress write requests with gzip"`
+
+	Output       string `short:"o" long:"output" description:"the output channel to send printer output (valid output: stdout, file, tcp, udp, http)" default:"stdout"`
+	OutputTarget string `long:"target" description:"for output 'file' the location, for 'tcp' or 'udp' the url (host:port) to the server, for 'http' the InfluxDB write url"`
+	Events       string `long:"events" description:"file to append JSON events (e.g. migration summaries) to, one per line ('-' for stdout, default: log)"`
+
+	OutputQueue    int    `long:"output-queue" description:"for output 'tcp', 'udp' or 'http' the maximum MiB of output queued in memory while the target is unreachable" default:"16"`
+	OutputSpool    string `long:"output-spool" description:"for output 'tcp', 'udp' or 'http' a file to spool output to when the queue is full, replayed in order after reconnecting"`
+	OutputSpoolMax int    `long:"output-spool-max" description:"maximum MiB of the spool file" default:"1024"`
+	OutputBackoff  int    `long:"output-backoff" description:"maximum seconds between reconnects to an unreachable target, doubled from 1 second" default:"60"`
 
 	NetworkDevice string `long:"netdev" description:"The network device used for the virtual traffic"`
 	StorageDevice string `long:"storedev" description:"The storage device used for the virtual block devices"`
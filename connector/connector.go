@@ -16,6 +16,7 @@ type VMInfo struct {
 	Interfaces  []string
 	DiskStats   DiskStatsInfo
 	CPUThreads  []int // vCPU thread IDs
+	Lock        string // Proxmox config lock (e.g. "migrate", "backup")
 }
 
 // DiskStatsInfo contains disk statistics
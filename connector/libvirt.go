@@ -1,7 +1,12 @@
 package connector
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
+	"time"
+
+	"proxtop/models"
 
 	libvirt "github.com/libvirt/libvirt-go"
 )
@@ -32,3 +37,28 @@ func CloseConnection() error {
 	}
 	return nil
 }
+
+// LibvirtQMPExecutor returns an executor running QMP commands via the QEMU monitor passthrough of libvirt.
+// libvirt marks the domain as tainted (custom-monitor) once a monitor command was sent.
+func LibvirtQMPExecutor(domain libvirt.Domain) QMPExecutor {
+	return func(command string, arguments interface{}) (json.RawMessage, error) {
+		request, err := json.Marshal(qmpCommand{Execute: command, Arguments: arguments})
+		if err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		output, err := domain.QemuMonitorCommand(string(request), libvirt.DOMAIN_QEMU_MONITOR_COMMAND_DEFAULT)
+		models.RecordCall(models.CallQMP, start, err)
+		if err != nil {
+			return nil, fmt.Errorf("%s failed: %v", command, err)
+		}
+		var response qmpResponse
+		if err := json.Unmarshal([]byte(output), &response); err != nil {
+			return nil, fmt.Errorf("failed to parse %s response: %v", command, err)
+		}
+		if response.Error != nil {
+			return nil, fmt.Errorf("%s failed: %s: %s", command, response.Error.Class, response.Error.Desc)
+		}
+		return response.Return, nil
+	}
+}
@@ -0,0 +1,106 @@
+package connector
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"proxtop/models"
+)
+
+// qmpCommand is a QMP command with optional arguments
+type qmpCommand struct {
+	Execute   string      `json:"execute"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+// QMPIOThread describes a QEMU iothread as returned by query-iothreads
+type QMPIOThread struct {
+	ID       string `json:"id"`
+	ThreadID int    `json:"thread-id"`
+}
+
+// QMPExecute runs a single QMP command on the VM's monitor socket and returns the raw return value
+func (p *ProxmoxConnector) QMPExecute(vmid string, command string, arguments interface{}) (result json.RawMessage, err error) {
+	defer func(start time.Time) {
+		models.RecordCall(models.CallQMP, start, err)
+	}(time.Now())
+	socketPath := fmt.Sprintf("/var/run/qemu-server/%s.qmp", vmid)
+
+	// Connect to QMP socket with timeout
+	conn, err := net.DialTimeout("unix", socketPath, 500*time.Millisecond)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to QMP socket: %v", err)
+	}
+	defer conn.Close()
+
+	// Set deadline for all operations
+	conn.SetDeadline(time.Now().Add(1 * time.Second))
+
+	reader := bufio.NewReader(conn)
+
+	// Read greeting
+	if _, err := reader.ReadBytes('\n'); err != nil {
+		return nil, fmt.Errorf("failed to read QMP greeting: %v", err)
+	}
+
+	// Enter command mode
+	if _, err := qmpRoundTrip(conn, reader, qmpCommand{Execute: "qmp_capabilities"}); err != nil {
+		return nil, err
+	}
+
+	return qmpRoundTrip(conn, reader, qmpCommand{Execute: command, Arguments: arguments})
+}
+
+// QMPExecutor returns an executor running QMP commands on the monitor socket of the VM
+func (p *ProxmoxConnector) QMPExecutor(vmid string) QMPExecutor {
+	return func(command string, arguments interface{}) (json.RawMessage, error) {
+		return p.QMPExecute(vmid, command, arguments)
+	}
+}
+
+// qmpRoundTrip sends a command and reads its response, skipping asynchronous events
+func qmpRoundTrip(conn net.Conn, reader *bufio.Reader, command qmpCommand) (json.RawMessage, error) {
+	request, err := json.Marshal(command)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(append(request, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %v", command.Execute, err)
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s response: %v", command.Execute, err)
+		}
+
+		var response struct {
+			qmpResponse
+			Event string `json:"event"`
+		}
+		if err := json.Unmarshal(line, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse %s response: %v", command.Execute, err)
+		}
+		if response.Event != "" {
+			continue
+		}
+		if response.Error != nil {
+			return nil, fmt.Errorf("%s failed: %s: %s", command.Execute, response.Error.Class, response.Error.Desc)
+		}
+		return response.Return, nil
+	}
+}
+
+// GetIOThreads returns the iothreads of a VM via QMP query-iothreads
+func (p *ProxmoxConnector) GetIOThreads(vm VMInfo) ([]QMPIOThread, error) {
+	var iothreads []QMPIOThread
+	result, err := p.QMPExecute(vm.VMID, "query-iothreads", nil)
+	if err != nil {
+		return iothreads, err
+	}
+	err = json.Unmarshal(result, &iothreads)
+	return iothreads, err
+}
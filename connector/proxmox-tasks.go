@@ -0,0 +1,84 @@
+package connector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProxmoxTask describes a running Proxmox task (vzdump, qmigrate, qmmove, ...)
+type ProxmoxTask struct {
+	UPID  string
+	Node  string
+	PID   int
+	Start time.Time
+	Type  string
+	// VMID or other object the task works on, empty for tasks on several VMs (e.g. vzdump of all guests)
+	ID   string
+	User string
+}
+
+// tasksActiveFile lists the running and recently finished tasks of this node
+const tasksActiveFile = "/var/log/pve/tasks/active"
+
+// GetActiveTasks returns the running tasks of this node from /var/log/pve/tasks/active.
+// Finished tasks carry end time and status after the UPID, running tasks are the UPID only.
+func (p *ProxmoxConnector) GetActiveTasks() ([]ProxmoxTask, error) {
+	var tasks []ProxmoxTask
+
+	file, err := os.Open(tasksActiveFile)
+	if err != nil {
+		return tasks, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 1 {
+			continue
+		}
+		task, err := parseUPID(fields[0])
+		if err != nil {
+			continue
+		}
+		if p.nodeName != "" && task.Node != p.nodeName {
+			continue
+		}
+		// skip tasks whose worker died without updating the list
+		if _, err := os.Stat(fmt.Sprintf("/proc/%d", task.PID)); err != nil {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, scanner.Err()
+}
+
+// parseUPID parses a task ID: UPID:$node:$pid:$pstart:$starttime:$type:$id:$user:
+// pid, pstart and starttime are hex encoded
+func parseUPID(upid string) (ProxmoxTask, error) {
+	task := ProxmoxTask{UPID: upid}
+	parts := strings.Split(upid, ":")
+	if len(parts) < 8 || parts[0] != "UPID" {
+		return task, fmt.Errorf("invalid UPID %s", upid)
+	}
+	pid, err := strconv.ParseInt(parts[2], 16, 64)
+	if err != nil {
+		return task, fmt.Errorf("invalid UPID pid %s", parts[2])
+	}
+	start, err := strconv.ParseInt(parts[4], 16, 64)
+	if err != nil {
+		return task, fmt.Errorf("invalid UPID start time %s", parts[4])
+	}
+	task.Node = parts[1]
+	task.PID = int(pid)
+	task.Start = time.Unix(start, 0)
+	task.Type = parts[5]
+	task.ID = parts[6]
+	task.User = parts[7]
+	return task, nil
+}
@@ -2,6 +2,7 @@ package connector
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -11,10 +12,13 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"proxtop/models"
 )
 
 // vmStatusCache caches qm status --verbose output per VM
@@ -236,6 +240,9 @@ func (p *ProxmoxConnector) getVMInfoByVMID(vmid string) (VMInfo, error) {
 
 		// Get network interfaces
 		vm.Interfaces = p.getNetworkInterfaces(vmid)
+
+		// Get lock (set during migration, backup, ...)
+		vm.Lock = config["lock"]
 	}
 
 	return vm, nil
@@ -361,12 +368,20 @@ func (p *ProxmoxConnector) getCachedVMStatus(vmid string) (string, error) {
 	statusCacheMu.RUnlock()
 
 	if exists && now.Sub(cached.timestamp) < statusCacheTTL {
+		models.RecordCache(models.CacheStatus, true)
 		return cached.output, nil
 	}
+	models.RecordCache(models.CacheStatus, false)
 
-	// Cache miss or expired - fetch fresh
-	cmd := exec.Command("qm", "status", vmid, "--verbose")
+	// Cache miss or expired - fetch fresh, killed after the deadline of the VM
+	ctx, cancel := context.WithTimeout(context.Background(), models.VMTimeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "qm", "status", vmid, "--verbose")
 	output, err := cmd.Output()
+	models.RecordCall(models.CallQMStatus, now, err)
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("qm status %s: no response within %s", vmid, models.VMTimeout())
+	}
 	if err != nil {
 		return "", err
 	}
@@ -384,7 +399,7 @@ func (p *ProxmoxConnector) getCachedVMStatus(vmid string) (string, error) {
 	return outputStr, nil
 }
 
-// queryQMP sends commands to QMP socket and returns balloon and blockstats
+// queryQMP sends commands to QMP socket and returns balloon and blockstats, cached for qmpCacheTTL
 func (p *ProxmoxConnector) queryQMP(vmid string) (*qmpBalloonStats, []qmpBlockStats, error) {
 	now := time.Now()
 
@@ -394,9 +409,31 @@ func (p *ProxmoxConnector) queryQMP(vmid string) (*qmpBalloonStats, []qmpBlockSt
 	qmpCacheMu.RUnlock()
 
 	if exists && now.Sub(cached.timestamp) < qmpCacheTTL {
+		models.RecordCache(models.CacheQMP, true)
 		return cached.balloon, cached.blockstats, nil
 	}
+	models.RecordCache(models.CacheQMP, false)
+
+	balloon, blockstats, err := p.queryQMPSocket(vmid)
+	models.RecordCall(models.CallQMP, now, err)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Update cache
+	qmpCacheMu.Lock()
+	qmpCacheMap[vmid] = &qmpCache{
+		balloon:    balloon,
+		blockstats: blockstats,
+		timestamp:  now,
+	}
+	qmpCacheMu.Unlock()
 
+	return balloon, blockstats, nil
+}
+
+// queryQMPSocket queries balloon and blockstats on the QMP socket of the VM
+func (p *ProxmoxConnector) queryQMPSocket(vmid string) (*qmpBalloonStats, []qmpBlockStats, error) {
 	socketPath := fmt.Sprintf("/var/run/qemu-server/%s.qmp", vmid)
 
 	// Connect to QMP socket with timeout
@@ -474,15 +511,6 @@ func (p *ProxmoxConnector) queryQMP(vmid string) (*qmpBalloonStats, []qmpBlockSt
 		json.Unmarshal(blockResp.Return, &blockstats)
 	}
 
-	// Update cache
-	qmpCacheMu.Lock()
-	qmpCacheMap[vmid] = &qmpCache{
-		balloon:    balloon,
-		blockstats: blockstats,
-		timestamp:  now,
-	}
-	qmpCacheMu.Unlock()
-
 	return balloon, blockstats, nil
 }
 
@@ -743,6 +771,81 @@ func (p *ProxmoxConnector) GetNetworkInterfaces(vm VMInfo) ([]string, error) {
 	return p.getNetworkInterfaces(vm.VMID), nil
 }
 
+// GetPCIPassthrough returns the host PCI IDs passed through with hostpciN (e.g. 0000:01:00.0, 01:00)
+// Resource mappings (mapping=<id>) are resolved for this node via /etc/pve/mapping/pci.cfg
+func (p *ProxmoxConnector) GetPCIPassthrough(vm VMInfo) ([]string, error) {
+	ids := []string{}
+	configFile := fmt.Sprintf("/etc/pve/qemu-server/%s.conf", vm.VMID)
+	config, err := p.parseVMConfig(configFile)
+	if err != nil {
+		return ids, err
+	}
+
+	hostpciRegexp := regexp.MustCompile(`^hostpci\d+$`)
+	keys := []string{}
+	for key := range config {
+		if hostpciRegexp.MatchString(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, option := range strings.Split(config[key], ",") {
+			switch {
+			case strings.HasPrefix(option, "mapping="):
+				ids = append(ids, p.getPCIMapping(strings.TrimPrefix(option, "mapping="))...)
+			case strings.HasPrefix(option, "host="):
+				ids = append(ids, strings.Split(strings.TrimPrefix(option, "host="), ";")...)
+			case !strings.Contains(option, "="):
+				// the host option can be given without key as first option
+				ids = append(ids, strings.Split(option, ";")...)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// getPCIMapping returns the PCI paths of a resource mapping on this node
+func (p *ProxmoxConnector) getPCIMapping(mapping string) []string {
+	paths := []string{}
+	file, err := os.Open("/etc/pve/mapping/pci.cfg")
+	if err != nil {
+		return paths
+	}
+	defer file.Close()
+
+	// <mapping id>
+	// 	map id=8086:1521,iommugroup=12,node=pve1,path=0000:01:00.0
+	current := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			current = strings.TrimSpace(line)
+			continue
+		}
+		fields := strings.Fields(line)
+		if current != mapping || len(fields) < 2 || fields[0] != "map" {
+			continue
+		}
+		options := make(map[string]string)
+		for _, option := range strings.Split(fields[1], ",") {
+			parts := strings.SplitN(option, "=", 2)
+			if len(parts) == 2 {
+				options[parts[0]] = parts[1]
+			}
+		}
+		if options["node"] == p.nodeName {
+			paths = append(paths, strings.Split(options["path"], ";")...)
+		}
+	}
+	return paths
+}
+
 // GetPerDiskStats returns per-disk statistics for a VM via QMP
 // Returns a map of disk device name -> DiskStatsInfo
 func (p *ProxmoxConnector) GetPerDiskStats(vm VMInfo) (map[string]DiskStatsInfo, []string, error) {
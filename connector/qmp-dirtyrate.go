@@ -0,0 +1,44 @@
+package connector
+
+import (
+	"encoding/json"
+)
+
+// QMPDirtyRate holds the result of a dirty rate measurement as returned by query-dirty-rate
+type QMPDirtyRate struct {
+	// unstarted, measuring or measured
+	Status string `json:"status"`
+	// MB/s, only set once measured
+	DirtyRate int64 `json:"dirty-rate"`
+	CalcTime  int64 `json:"calc-time"`
+	// second or millisecond (QEMU 8.2+), seconds if not set
+	CalcTimeUnit string `json:"calc-time-unit"`
+	SamplePages  uint64 `json:"sample-pages"`
+	Mode         string `json:"mode"`
+}
+
+// StartDirtyRate starts a dirty rate measurement in page-sampling mode over calcTime seconds.
+// samplePages is the number of pages sampled per GiB of guest memory.
+func StartDirtyRate(execute QMPExecutor, calcTime int64, samplePages uint64) error {
+	_, err := execute("calc-dirty-rate", map[string]interface{}{
+		"calc-time":    calcTime,
+		"sample-pages": samplePages,
+		"mode":         "page-sampling",
+	})
+	if err != nil {
+		// QEMU before 6.1 knows neither sample-pages nor mode, page-sampling is its only mode
+		_, err = execute("calc-dirty-rate", map[string]interface{}{"calc-time": calcTime})
+	}
+	return err
+}
+
+// GetDirtyRate returns the state and result of the last dirty rate measurement
+func GetDirtyRate(execute QMPExecutor) (QMPDirtyRate, error) {
+	rate := QMPDirtyRate{}
+	result, err := execute("query-dirty-rate", nil)
+	if err != nil {
+		return rate, err
+	}
+	err = json.Unmarshal(result, &rate)
+	return rate, err
+}
@@ -0,0 +1,118 @@
+package connector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// QMPExecutor runs a QMP command on the monitor of a VM and returns the raw return value.
+// It allows to share QMP based lookups between the Proxmox and libvirt connectors.
+type QMPExecutor func(command string, arguments interface{}) (json.RawMessage, error)
+
+// QMPGuestStats holds the virtio-balloon guest statistics as returned by qom-get guest-stats.
+// Values are -1 if the guest driver does not report them.
+type QMPGuestStats struct {
+	Stats      map[string]int64 `json:"stats"`
+	LastUpdate int64            `json:"last-update"`
+	// polling interval in seconds the guest reports with
+	Interval int64 `json:"-"`
+}
+
+// Get returns a statistic value, ok is false if the guest does not report it
+func (stats QMPGuestStats) Get(name string) (uint64, bool) {
+	value, ok := stats.Stats[name]
+	if !ok || value < 0 {
+		return 0, false
+	}
+	return uint64(value), true
+}
+
+type qomProperty struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// guestStatsState remembers the balloon device path and polling interval per VM
+type guestStatsState struct {
+	path     string
+	interval int64
+}
+
+var guestStatsMu sync.Mutex
+var guestStatsStates = make(map[string]guestStatsState)
+
+// GetGuestStats reads the guest statistics of the VM's virtio-balloon device. Stats polling is enabled
+// with the given interval (seconds) if the guest is not polled yet. key identifies the VM instance.
+func GetGuestStats(key string, execute QMPExecutor, interval int64) (QMPGuestStats, error) {
+	stats := QMPGuestStats{}
+
+	guestStatsMu.Lock()
+	state, ok := guestStatsStates[key]
+	guestStatsMu.Unlock()
+
+	if !ok {
+		path, err := findBalloonDevice(execute)
+		if err != nil {
+			return stats, err
+		}
+		state = guestStatsState{path: path}
+
+		// keep an interval configured by someone else (e.g. libvirt <stats period='...'/>)
+		result, err := execute("qom-get", map[string]string{"path": path, "property": "guest-stats-polling-interval"})
+		if err != nil {
+			return stats, err
+		}
+		json.Unmarshal(result, &state.interval)
+		if state.interval <= 0 {
+			_, err = execute("qom-set", map[string]interface{}{"path": path, "property": "guest-stats-polling-interval", "value": interval})
+			if err != nil {
+				return stats, err
+			}
+			state.interval = interval
+		}
+
+		guestStatsMu.Lock()
+		guestStatsStates[key] = state
+		guestStatsMu.Unlock()
+	}
+
+	result, err := execute("qom-get", map[string]string{"path": state.path, "property": "guest-stats"})
+	if err != nil {
+		// device may be gone (hot unplug), search again next time
+		ForgetGuestStats(key)
+		return stats, err
+	}
+	err = json.Unmarshal(result, &stats)
+	stats.Interval = state.interval
+	return stats, err
+}
+
+// ForgetGuestStats removes the remembered balloon device of a VM
+func ForgetGuestStats(key string) {
+	guestStatsMu.Lock()
+	delete(guestStatsStates, key)
+	guestStatsMu.Unlock()
+}
+
+// findBalloonDevice returns the QOM path of the virtio-balloon device (with or without device id)
+func findBalloonDevice(execute QMPExecutor) (string, error) {
+	for _, parent := range []string{"/machine/peripheral", "/machine/peripheral-anon"} {
+		result, err := execute("qom-list", map[string]string{"path": parent})
+		if err != nil {
+			continue
+		}
+		var properties []qomProperty
+		if err := json.Unmarshal(result, &properties); err != nil {
+			continue
+		}
+		for _, property := range properties {
+			// child<virtio-balloon-pci>, child<virtio-balloon-ccw>, ...
+			if strings.HasPrefix(property.Type, "child<virtio-balloon") {
+				return parent + "/" + property.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no virtio-balloon device found")
+}
@@ -0,0 +1,93 @@
+package connector
+
+import (
+	"encoding/json"
+)
+
+// QMPBlockJob describes a running block job (backup, mirror, stream, commit) as returned by query-block-jobs
+type QMPBlockJob struct {
+	Type   string `json:"type"`
+	Device string `json:"device"`
+	Len    uint64 `json:"len"`
+	Offset uint64 `json:"offset"`
+	// throttle in bytes/s, 0 for unlimited
+	Speed  uint64 `json:"speed"`
+	Busy   bool   `json:"busy"`
+	Paused bool   `json:"paused"`
+	Ready  bool   `json:"ready"`
+	Status string `json:"status"`
+}
+
+// QMPJob describes a job as returned by query-jobs, progress is in bytes for block jobs
+type QMPJob struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	Status          string `json:"status"`
+	CurrentProgress uint64 `json:"current-progress"`
+	TotalProgress   uint64 `json:"total-progress"`
+	Error           string `json:"error"`
+}
+
+// QMPProxmoxSupport holds the Proxmox specific QEMU capabilities as returned by query-proxmox-support
+type QMPProxmoxSupport struct {
+	PBSLibraryVersion string `json:"pbs-library-version"`
+	PBSDirtyBitmap    bool   `json:"pbs-dirty-bitmap"`
+}
+
+// QMPBackupStatus holds the state of the last Proxmox backup (vzdump) as returned by query-backup
+type QMPBackupStatus struct {
+	// active, done or error
+	Status      string `json:"status"`
+	Total       uint64 `json:"total"`
+	Transferred uint64 `json:"transferred"`
+	ZeroBytes   uint64 `json:"zero-bytes"`
+	Reused      uint64 `json:"reused"`
+	StartTime   int64  `json:"start-time"`
+	EndTime     int64  `json:"end-time"`
+	BackupFile  string `json:"backup-file"`
+	ErrMsg      string `json:"errmsg"`
+}
+
+// GetBlockJobs returns the running block jobs of a VM via query-block-jobs
+func GetBlockJobs(execute QMPExecutor) ([]QMPBlockJob, error) {
+	var jobs []QMPBlockJob
+	result, err := execute("query-block-jobs", nil)
+	if err != nil {
+		return jobs, err
+	}
+	err = json.Unmarshal(result, &jobs)
+	return jobs, err
+}
+
+// GetJobs returns the jobs of a VM via query-jobs (QEMU 3.0+)
+func GetJobs(execute QMPExecutor) ([]QMPJob, error) {
+	var jobs []QMPJob
+	result, err := execute("query-jobs", nil)
+	if err != nil {
+		return jobs, err
+	}
+	err = json.Unmarshal(result, &jobs)
+	return jobs, err
+}
+
+// GetProxmoxSupport returns the Proxmox QEMU capabilities, an error for QEMU builds without Proxmox patches
+func GetProxmoxSupport(execute QMPExecutor) (QMPProxmoxSupport, error) {
+	support := QMPProxmoxSupport{}
+	result, err := execute("query-proxmox-support", nil)
+	if err != nil {
+		return support, err
+	}
+	err = json.Unmarshal(result, &support)
+	return support, err
+}
+
+// GetBackupStatus returns the state of the last Proxmox backup via query-backup
+func GetBackupStatus(execute QMPExecutor) (QMPBackupStatus, error) {
+	status := QMPBackupStatus{}
+	result, err := execute("query-backup", nil)
+	if err != nil {
+		return status, err
+	}
+	err = json.Unmarshal(result, &status)
+	return status, err
+}
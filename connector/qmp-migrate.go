@@ -0,0 +1,40 @@
+package connector
+
+import (
+	"encoding/json"
+)
+
+// QMPMigrationRAM holds the RAM statistics of a migration as returned by query-migrate
+type QMPMigrationRAM struct {
+	Transferred    uint64  `json:"transferred"`
+	Remaining      uint64  `json:"remaining"`
+	Total          uint64  `json:"total"`
+	DirtyPagesRate uint64  `json:"dirty-pages-rate"`
+	DirtySyncCount uint64  `json:"dirty-sync-count"`
+	PageSize       uint64  `json:"page-size"`
+	Mbps           float64 `json:"mbps"`
+}
+
+// QMPMigrationInfo holds the migration status of a VM as returned by query-migrate.
+// Times are in milliseconds, Downtime is only set once the migration completed.
+type QMPMigrationInfo struct {
+	Status           string           `json:"status"`
+	TotalTime        uint64           `json:"total-time"`
+	ExpectedDowntime uint64           `json:"expected-downtime"`
+	Downtime         uint64           `json:"downtime"`
+	SetupTime        uint64           `json:"setup-time"`
+	ErrorDesc        string           `json:"error-desc"`
+	RAM              *QMPMigrationRAM `json:"ram"`
+}
+
+// GetMigrationInfo returns the status of the current or last outgoing migration via QMP query-migrate.
+// Status is empty if the VM has never been migrated.
+func GetMigrationInfo(execute QMPExecutor) (QMPMigrationInfo, error) {
+	info := QMPMigrationInfo{}
+	result, err := execute("query-migrate", nil)
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(result, &info)
+	return info, err
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"proxtop/config"
+)
+
+// the backoff of a circuit breaker is doubled on each failed retry up to this factor of --vm-backoff
+const maxBackoffFactor = 16
+
+// breaker is the circuit breaker of one VM. It opens when a call for the VM did not return within
+// --vm-timeout, the VM is skipped until the backoff passed and its last call returned.
+type breaker struct {
+	// inFlight counts the calls for the VM which did not return yet
+	inFlight int
+	// openUntil is the end of the backoff, zero if closed
+	openUntil time.Time
+	backoff   time.Duration
+	// skipped is set when the VM was not reached within the time of the collector, its values are stale
+	skipped bool
+}
+
+// breakersMu protects breakers, the circuit breakers by VM UUID
+var breakersMu sync.Mutex
+var breakers = make(map[string]*breaker)
+
+// VMTimeout returns the configured deadline of the calls for one VM
+func VMTimeout() time.Duration {
+	if config.Options.VMTimeout < 1 {
+		return time.Second
+	}
+	return time.Duration(config.Options.VMTimeout) * time.Second
+}
+
+// CollectorTimeout returns the configured deadline of a collector per phase
+func CollectorTimeout() time.Duration {
+	if config.Options.CollectorTimeout < 1 {
+		return time.Second
+	}
+	return time.Duration(config.Options.CollectorTimeout) * time.Second
+}
+
+// GuardBudget returns the time the guarded calls of a collector phase may take for all VMs together,
+// four fifths of --collector-timeout so the collector finishes in time even if several VMs hang
+func GuardBudget() time.Duration {
+	return CollectorTimeout() * 4 / 5
+}
+
+// vmBackoff returns the configured initial time a misbehaving VM is skipped
+func vmBackoff() time.Duration {
+	if config.Options.VMBackoff < 1 {
+		return time.Second
+	}
+	return time.Duration(config.Options.VMBackoff) * time.Second
+}
+
+// GuardDomain runs f for the VM uuid with the deadline --vm-timeout, shortened to deadline. Returns false
+// if the VM was skipped because its breaker is open or deadline passed, or f did not return in time.
+// f keeps running after the deadline, the VM is skipped until it returned and the backoff passed.
+func GuardDomain(uuid string, deadline time.Time, f func()) bool {
+	breakersMu.Lock()
+	current, exists := breakers[uuid]
+	if !exists {
+		current = &breaker{}
+		breakers[uuid] = current
+	}
+	if (current.inFlight > 0 && !current.openUntil.IsZero()) || time.Now().Before(current.openUntil) {
+		breakersMu.Unlock()
+		return false
+	}
+	timeout := VMTimeout()
+	if remaining := time.Until(deadline); remaining < timeout {
+		timeout = remaining
+	}
+	if timeout <= 0 {
+		// not reached within the time of the collector, tried first again in the next cycle
+		current.skipped = true
+		breakersMu.Unlock()
+		return false
+	}
+	current.inFlight++
+	breakersMu.Unlock()
+
+	done := make(chan bool, 1)
+	go func() {
+		defer func() {
+			breakersMu.Lock()
+			current.inFlight--
+			breakersMu.Unlock()
+			done <- true
+		}()
+		f()
+	}()
+
+	select {
+	case <-done:
+		breakersMu.Lock()
+		if !current.openUntil.IsZero() {
+			log.Printf("VM %s: responding again, circuit breaker closed", uuid)
+		}
+		current.openUntil = time.Time{}
+		current.backoff = 0
+		current.skipped = false
+		breakersMu.Unlock()
+		return true
+	case <-time.After(timeout):
+		breakersMu.Lock()
+		if current.backoff == 0 {
+			current.backoff = vmBackoff()
+		} else if current.backoff < maxBackoffFactor*vmBackoff() {
+			current.backoff *= 2
+		}
+		current.openUntil = time.Now().Add(current.backoff)
+		log.Printf("VM %s: no response within %s, skipped for %s", uuid, timeout, current.backoff)
+		breakersMu.Unlock()
+		return false
+	}
+}
+
+// StaleDomains returns the UUIDs of the VMs with an open circuit breaker, their values are from an earlier cycle
+func StaleDomains() map[string]bool {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	stale := make(map[string]bool)
+	for uuid, current := range breakers {
+		if !current.openUntil.IsZero() || current.skipped {
+			stale[uuid] = true
+		}
+	}
+	return stale
+}
+
+// ForgetDomain removes the circuit breaker of a VM which is gone
+func ForgetDomain(uuid string) {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	delete(breakers, uuid)
+}
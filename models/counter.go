@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrCounterReset is returned for counter diffs across a reset, e.g. a restarted VM or a recreated tap device
+var ErrCounterReset = errors.New("counter reset")
+
+// ResetMarker is printed instead of values computed across a counter reset
+const ResetMarker = "reset"
+
+var countersMu sync.RWMutex
+
+// counterWraps maps metric name prefixes to the width in bits of counters wrapping around
+var counterWraps = make(map[string]uint)
+
+// DeclareCounter declares the metrics starting with prefix as counters wrapping around at wrapBits
+// (e.g. 32 for the unsigned int counts of /proc/interrupts). Undeclared counters are 64 bit,
+// a decrease of them is a reset.
+func DeclareCounter(prefix string, wrapBits uint) {
+	countersMu.Lock()
+	defer countersMu.Unlock()
+	counterWraps[prefix] = wrapBits
+}
+
+// counterWrapBits returns the declared wrap width of a metric, 0 if it does not wrap.
+// Of several matching prefixes the longest is the most specific declaration.
+func counterWrapBits(metricName string) uint {
+	countersMu.RLock()
+	defer countersMu.RUnlock()
+	var bits uint
+	longest := -1
+	for prefix, prefixBits := range counterWraps {
+		if len(prefix) > longest && strings.HasPrefix(metricName, prefix) {
+			bits = prefixBits
+			longest = len(prefix)
+		}
+	}
+	return bits
+}
+
+// counterDiff returns the increase of a counter from older to newer measurement. Measurements of another
+// identity (process, device) or a decrease of a counter not wrapping around result in ErrCounterReset.
+func counterDiff(metricName string, newer Measurement, older Measurement) (uint64, error) {
+	value1, err := newer.Uint64()
+	if err != nil {
+		return 0, err
+	}
+	value2, err := older.Uint64()
+	if err != nil {
+		return 0, err
+	}
+	if newer.identity != older.identity {
+		return 0, ErrCounterReset
+	}
+	if value1 >= value2 {
+		return value1 - value2, nil
+	}
+	bits := counterWrapBits(metricName)
+	if bits == 0 || bits >= 64 || value2-value1 >= 1<<bits {
+		return 0, ErrCounterReset
+	}
+	return 1<<bits - (value2 - value1), nil
+}
+
+// SetIdentity sets the identity stamped on new measurements without own identity, e.g. the PID of a VM.
+// Counters are not compared across identities.
+func (measurable *Measurable) SetIdentity(identity uint64) {
+	measurable.access.Lock()
+	defer measurable.access.Unlock()
+	if !measurable.writable() {
+		return
+	}
+	measurable.identity = identity
+}
+
+// GetMetricCounterDiff returns the increase of a counter between the last two measurements, per second if perTime.
+// A rate of two measurements taken at the same time is an error.
+func (measurable *Measurable) GetMetricCounterDiff(metricName string, perTime bool) (float64, error) {
+	newer, older, err := measurable.getMeasurementPair(metricName, 1)
+	if err != nil {
+		return 0, err
+	}
+	diff, err := counterDiff(metricName, newer, older)
+	if err != nil {
+		return 0, err
+	}
+	value := float64(diff)
+	if perTime {
+		interval := newer.Timestamp.Sub(older.Timestamp).Seconds()
+		if interval <= 0 {
+			return 0, fmt.Errorf("metric %s: no time passed between the measurements", metricName)
+		}
+		value = value / interval
+	}
+	return value, nil
+}
+
+// AddRate adds a rate to a sum over several counters, unless it was computed across a counter reset (NaN),
+// so the reset of one counter does not turn the whole sum into a reset
+func AddRate(sum float64, rate float64) float64 {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return sum
+	}
+	return sum + rate
+}
+
+// MarkResets replaces values computed across a counter reset (NaN, Inf) with the reset marker, so all printers show it the same way
+func MarkResets(values []string) {
+	for i, value := range values {
+		if number, err := strconv.ParseFloat(value, 64); err == nil && (math.IsNaN(number) || math.IsInf(number, 0)) {
+			values[i] = ResetMarker
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCounterWrapBits(t *testing.T) {
+	DeclareCounter("t_wrap", 32)
+	DeclareCounter("t_wrap_wide", 64)
+	DeclareCounter("t_wrap_wide_narrow", 16)
+	tests := map[string]uint{
+		"t_wrap_count":        32,
+		"t_wrap_wide_count":   64,
+		"t_wrap_wide_narrow0": 16,
+		"t_other":             0,
+	}
+	for metricName, want := range tests {
+		if got := counterWrapBits(metricName); got != want {
+			t.Errorf("counterWrapBits(%q) = %d, want %d", metricName, got, want)
+		}
+	}
+}
+
+func TestCounterDiff(t *testing.T) {
+	DeclareCounter("t_diff32", 32)
+	DeclareCounter("t_diff64", 64)
+	tests := []struct {
+		name       string
+		metricName string
+		older      Measurement
+		newer      Measurement
+		want       uint64
+		err        error
+	}{
+		{"increase", "t_diff", CreateMeasurement(uint64(10)), CreateMeasurement(uint64(25)), 15, nil},
+		{"unchanged", "t_diff", CreateMeasurement(uint64(10)), CreateMeasurement(uint64(10)), 0, nil},
+		{"decrease is a reset", "t_diff", CreateMeasurement(uint64(10)), CreateMeasurement(uint64(5)), 0, ErrCounterReset},
+		{"32 bit wrap", "t_diff32", CreateMeasurement(uint64(1<<32 - 10)), CreateMeasurement(uint64(5)), 15, nil},
+		{"32 bit decrease beyond the width", "t_diff32", CreateMeasurement(uint64(1<<33 + 10)), CreateMeasurement(uint64(5)), 0, ErrCounterReset},
+		{"64 bit wrap is a reset", "t_diff64", CreateMeasurement(uint64(1<<64 - 10)), CreateMeasurement(uint64(5)), 0, ErrCounterReset},
+		{"identity change", "t_diff", CreateMeasurement(uint64(10)).WithIdentity(1), CreateMeasurement(uint64(25)).WithIdentity(2), 0, ErrCounterReset},
+		{"same identity", "t_diff", CreateMeasurement(uint64(10)).WithIdentity(1), CreateMeasurement(uint64(25)).WithIdentity(1), 15, nil},
+	}
+	for _, test := range tests {
+		got, err := counterDiff(test.metricName, test.newer, test.older)
+		if err != test.err || got != test.want {
+			t.Errorf("%s: counterDiff = %d, %v, want %d, %v", test.name, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestGetMetricCounterDiffInterval(t *testing.T) {
+	start := time.Now()
+	measurable := NewMeasurable()
+	older := CreateMeasurement(uint64(100))
+	older.Timestamp = start
+	newer := CreateMeasurement(uint64(300))
+	newer.Timestamp = start.Add(2 * time.Second)
+	measurable.AddMetricMeasurement("t_rate", older)
+	measurable.AddMetricMeasurement("t_rate", newer)
+	if rate, err := measurable.GetMetricCounterDiff("t_rate", true); err != nil || rate != 100 {
+		t.Errorf("GetMetricCounterDiff = %v, %v, want 100", rate, err)
+	}
+
+	same := CreateMeasurement(uint64(500))
+	same.Timestamp = newer.Timestamp
+	measurable.AddMetricMeasurement("t_rate", same)
+	if _, err := measurable.GetMetricCounterDiff("t_rate", true); err == nil {
+		t.Error("GetMetricCounterDiff of a zero interval succeeded, want error")
+	}
+	if diff, err := measurable.GetMetricCounterDiff("t_rate", false); err != nil || diff != 200 {
+		t.Errorf("GetMetricCounterDiff without time = %v, %v, want 200", diff, err)
+	}
+}
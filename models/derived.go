@@ -0,0 +1,184 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DerivedCollector is the collector name of derived fields in the metric registry
+const DerivedCollector = "derived"
+
+// DerivedField is a user-defined field computed from the printed fields of its scope
+type DerivedField struct {
+	Name       string
+	Scope      Scope
+	Expression Expression
+}
+
+// derivedFields holds the derived fields in definition order, defined on startup before printing
+var derivedFields []DerivedField
+
+// DefineDerivedField parses a definition [host:]NAME=EXPRESSION and registers the derived field.
+// Fields without host: prefix are computed per VM. Expressions may reference fields defined before.
+func DefineDerivedField(definition string) error {
+	scope := ScopeDomain
+	if strings.HasPrefix(definition, "host:") {
+		scope = ScopeHost
+		definition = strings.TrimPrefix(definition, "host:")
+	}
+	separator := strings.Index(definition, "=")
+	if separator < 0 {
+		return fmt.Errorf("derived field %q: missing =", definition)
+	}
+	name := strings.TrimSpace(definition[:separator])
+	if name == "" || strings.ContainsAny(name, " \t{}().,") {
+		return fmt.Errorf("derived field %q: invalid name", name)
+	}
+	if name == "UUID" || name == "name" {
+		return fmt.Errorf("derived field %s: name of a built-in field", name)
+	}
+	if def, exists := LookupField(scope, name); exists && def.Collector != DerivedCollector {
+		return fmt.Errorf("derived field %s: name of a field of collector %s", name, def.Collector)
+	}
+
+	expression, err := ParseExpression(strings.TrimSpace(definition[separator+1:]), scope)
+	if err != nil {
+		return fmt.Errorf("derived field %s: %v", name, err)
+	}
+	RegisterFields(DerivedCollector, scope, []FieldDef{
+		{Name: name, Kind: KindGauge, Description: expression.Source},
+	})
+	for i, field := range derivedFields {
+		if field.Name == name && field.Scope == scope {
+			derivedFields = append(derivedFields[:i], derivedFields[i+1:]...)
+			break
+		}
+	}
+	derivedFields = append(derivedFields, DerivedField{Name: name, Scope: scope, Expression: expression})
+	return nil
+}
+
+// LoadDerivedFields defines the derived fields of a file, one definition per line. Empty lines and
+// lines starting with # are skipped.
+func LoadDerivedFields(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := DefineDerivedField(line); err != nil {
+			return fmt.Errorf("%s:%d: %v", path, lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// CheckDerivedFields returns an error if a derived field references a field which is not printed: verbose fields
+// are only printed with verbose, a derived field would show "-" without them
+func CheckDerivedFields(verbose bool) error {
+	for _, field := range derivedFields {
+		for _, ref := range field.Expression.Refs {
+			if def, exists := LookupField(ref.Scope, ref.Name); exists && def.Verbose && !verbose {
+				return fmt.Errorf("derived field %s: field %s is only printed with --verbose", field.Name, ref.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyDerivedFields appends the derived fields to the merged printable of all collectors, host fields
+// first so domain fields can reference them
+func ApplyDerivedFields(printable *Printable) {
+	if len(derivedFields) == 0 {
+		return
+	}
+
+	hostIndex := fieldIndex(printable.HostFields)
+	for _, field := range derivedFields {
+		if field.Scope != ScopeHost {
+			continue
+		}
+		value := evalDerived(field, func(ref FieldRef) (float64, error) {
+			return printedValue(ref, hostIndex, printable.HostValues)
+		})
+		hostIndex[field.Name] = len(printable.HostValues)
+		printable.HostFields = append(printable.HostFields, field.Name)
+		printable.HostValues = append(printable.HostValues, value)
+	}
+
+	domainIndex := fieldIndex(printable.DomainFields)
+	for _, field := range derivedFields {
+		if field.Scope != ScopeDomain {
+			continue
+		}
+		for uuid, values := range printable.DomainValues {
+			printable.DomainValues[uuid] = append(values, evalDerived(field, func(ref FieldRef) (float64, error) {
+				if ref.Scope == ScopeHost {
+					return printedValue(ref, hostIndex, printable.HostValues)
+				}
+				return printedValue(ref, domainIndex, values)
+			}))
+		}
+		domainIndex[field.Name] = len(printable.DomainFields)
+		printable.DomainFields = append(printable.DomainFields, field.Name)
+	}
+}
+
+// fieldIndex maps field names to their position
+func fieldIndex(fields []string) map[string]int {
+	index := make(map[string]int, len(fields))
+	for i, field := range fields {
+		index[field] = i
+	}
+	return index
+}
+
+// printedValue returns the value of a referenced field from the printed values, ErrCounterReset if it shows a reset
+func printedValue(ref FieldRef, index map[string]int, values []string) (float64, error) {
+	position, exists := index[ref.Name]
+	if !exists || position >= len(values) {
+		return 0, fmt.Errorf("field %s is not printed", ref.Name)
+	}
+	if values[position] == ResetMarker {
+		return 0, ErrCounterReset
+	}
+	unit := UnitNone
+	if def, exists := LookupField(ref.Scope, ref.Name); exists {
+		unit = def.Unit
+	}
+	value, err := unit.Parse(values[position])
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, ErrCounterReset
+	}
+	return value, nil
+}
+
+// evalDerived returns the printed value of a derived field, the reset marker if it depends on a reset counter
+// and "-" if a referenced field is not available or not numeric
+func evalDerived(field DerivedField, resolve Resolver) string {
+	value, err := field.Expression.Eval(resolve)
+	if err == ErrCounterReset {
+		return ResetMarker
+	}
+	if err != nil {
+		return "-"
+	}
+	if value == math.Trunc(value) && math.Abs(value) < 1e15 {
+		return strconv.FormatFloat(value, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// withDerivedFields defines derived fields for a test, the returned function restores the former ones
+func withDerivedFields(t *testing.T, definitions ...string) func() {
+	t.Helper()
+	saved := derivedFields
+	derivedFields = nil
+	for _, definition := range definitions {
+		if err := DefineDerivedField(definition); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return func() { derivedFields = saved }
+}
+
+func TestApplyDerivedFields(t *testing.T) {
+	RegisterFields("test", ScopeHost, []FieldDef{{Name: "t_cores"}})
+	RegisterFields("test", ScopeDomain, []FieldDef{{Name: "t_reads"}, {Name: "t_writes"}, {Name: "t_hidden"}})
+	defer withDerivedFields(t,
+		"host:t_half=t_cores/2",
+		"t_iops=t_reads+t_writes",
+		"t_percore=t_iops/host.t_half",
+		"t_ratio=t_reads/t_writes",
+		"t_missing=t_hidden+1",
+	)()
+
+	printable := Printable{
+		HostFields:   []string{"t_cores"},
+		HostValues:   []string{"8"},
+		DomainFields: []string{"UUID", "name", "t_reads", "t_writes"},
+		DomainValues: map[string][]string{
+			"a": {"a", "vm-a", "30", "10"},
+			"b": {"b", "vm-b", ResetMarker, "0"},
+			"c": {"c", "vm-c", "3", "0"},
+		},
+	}
+	ApplyDerivedFields(&printable)
+
+	if want := []string{"t_cores", "t_half"}; !reflect.DeepEqual(printable.HostFields, want) {
+		t.Errorf("HostFields = %v, want %v", printable.HostFields, want)
+	}
+	if want := []string{"8", "4"}; !reflect.DeepEqual(printable.HostValues, want) {
+		t.Errorf("HostValues = %v, want %v", printable.HostValues, want)
+	}
+	want := map[string][]string{
+		// a derived field references derived fields defined before, also of the host
+		"a": {"a", "vm-a", "30", "10", "40", "10", "3", "-"},
+		// a reset input shows reset, a division by zero and a field not printed show -
+		"b": {"b", "vm-b", ResetMarker, "0", ResetMarker, ResetMarker, ResetMarker, "-"},
+		"c": {"c", "vm-c", "3", "0", "3", "0.75", "-", "-"},
+	}
+	for uuid, values := range want {
+		if !reflect.DeepEqual(printable.DomainValues[uuid], values) {
+			t.Errorf("DomainValues[%s] = %v, want %v", uuid, printable.DomainValues[uuid], values)
+		}
+	}
+}
+
+func TestDefineDerivedFieldErrors(t *testing.T) {
+	RegisterFields("test", ScopeDomain, []FieldDef{{Name: "t_reads"}})
+	defer withDerivedFields(t)()
+	tests := []string{
+		"t_x",
+		"=t_reads",
+		"t x=t_reads",
+		"UUID=t_reads",
+		"t_reads=1",
+		"t_x=t_reads+",
+		"t_x=t_unknown",
+		"host:t_x=t_reads",
+	}
+	for _, definition := range tests {
+		if err := DefineDerivedField(definition); err == nil {
+			t.Errorf("DefineDerivedField(%q) succeeded, want error", definition)
+		}
+	}
+}
+
+func TestCheckDerivedFields(t *testing.T) {
+	RegisterFields("test", ScopeDomain, []FieldDef{{Name: "t_reads"}, {Name: "t_detail", Verbose: true}})
+	defer withDerivedFields(t, "t_sum=t_reads+t_detail")()
+	if err := CheckDerivedFields(true); err != nil {
+		t.Errorf("CheckDerivedFields(true) error: %v", err)
+	}
+	if err := CheckDerivedFields(false); err == nil || !strings.Contains(err.Error(), "t_detail") {
+		t.Errorf("CheckDerivedFields(false) = %v, want error of t_detail", err)
+	}
+}
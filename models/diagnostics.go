@@ -0,0 +1,216 @@
+package models
+
+import (
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"proxtop/util"
+)
+
+// Kinds of hypervisor calls counted by RecordCall
+const (
+	CallQMP      = "qmp"       // QMP commands on the monitor socket (Proxmox) or via libvirt
+	CallQMStatus = "qm-status" // qm status --verbose (Proxmox fallback)
+	CallLibvirt  = "libvirt"   // libvirt RPCs
+)
+
+// Caches counted by RecordCache
+const (
+	CacheQMP    = "qmp"       // qmpCacheMap, QMP balloon and block stats per VM
+	CacheStatus = "qm-status" // statusCache, qm status output per VM
+)
+
+// PhaseDurations are the durations of the last lookup, collect and print phase of a collector
+type PhaseDurations struct {
+	Lookup  time.Duration
+	Collect time.Duration
+	Print   time.Duration
+}
+
+// CallStats counts the calls of one kind with their errors and latencies
+type CallStats struct {
+	Calls  uint64
+	Errors uint64
+	Total  time.Duration
+	Max    time.Duration
+}
+
+// Average returns the average latency of the calls
+func (stats CallStats) Average() time.Duration {
+	if stats.Calls == 0 {
+		return 0
+	}
+	return stats.Total / time.Duration(stats.Calls)
+}
+
+// CacheStats counts the hits and misses of a cache
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRatio returns the share of hits in percent
+func (stats CacheStats) HitRatio() float64 {
+	if stats.Hits+stats.Misses == 0 {
+		return 0
+	}
+	return float64(stats.Hits) / float64(stats.Hits+stats.Misses) * 100
+}
+
+// OutputStats are the counters of the network output (tcp, udp)
+type OutputStats struct {
+	// Connected tells whether the target is connected, Reconnects counts the connections lost since start
+	Connected  bool
+	Reconnects uint64
+	// Queued is the number of messages in the in-memory queue, QueuedBytes their size
+	Queued      int
+	QueuedBytes int
+	// SpooledBytes is the size of the messages in the spool file not yet sent
+	SpooledBytes int64
+	// Dropped counts the messages lost since start because the queue and spool were full
+	Dropped uint64
+}
+
+// Diagnostics are the metrics of proxtop itself, to tell whether collection is lagging
+type Diagnostics struct {
+	// Phases holds the phase durations by collector name, "lookup" is the VM discovery
+	Phases map[string]PhaseDurations
+	// Calls and Caches are counted since start
+	Calls  map[string]CallStats
+	Caches map[string]CacheStats
+	// UnresolvedPIDs is the number of VMs without QEMU PID, their process metrics are missing
+	UnresolvedPIDs int
+	// CPU is the CPU usage of proxtop in percent of one CPU during the last cycle, RSS its resident memory in bytes
+	CPU float64
+	RSS uint64
+	// Output holds the counters of the network output, nil for other outputs
+	Output *OutputStats
+}
+
+// Collectors returns the names of the collectors with phase durations, sorted
+func (diagnostics Diagnostics) Collectors() []string {
+	names := make([]string, 0, len(diagnostics.Phases))
+	for name := range diagnostics.Phases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diagnosticsMu protects the counters of the diagnostics
+var diagnosticsMu sync.Mutex
+var phases = make(map[string]PhaseDurations)
+var calls = make(map[string]CallStats)
+var caches = make(map[string]CacheStats)
+var outputStats *OutputStats
+
+// previous CPU time of proxtop and the time it was read, for the CPU usage of the last cycle
+var previousCPUTime time.Duration
+var previousCPURead time.Time
+var cpuUsage float64
+
+// RecordPhase records the duration of a lookup, collect or print phase of a collector
+func RecordPhase(collector string, phase string, duration time.Duration) {
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	durations := phases[collector]
+	switch phase {
+	case "lookup":
+		durations.Lookup = duration
+	case "collect":
+		durations.Collect = duration
+	case "print":
+		durations.Print = duration
+	}
+	phases[collector] = durations
+}
+
+// RecordCall records a hypervisor call of kind started at start, failed if err is set
+func RecordCall(kind string, start time.Time, err error) {
+	latency := time.Since(start)
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	stats := calls[kind]
+	stats.Calls++
+	if err != nil {
+		stats.Errors++
+	}
+	stats.Total += latency
+	if latency > stats.Max {
+		stats.Max = latency
+	}
+	calls[kind] = stats
+}
+
+// RecordCache records a lookup in the cache name
+func RecordCache(name string, hit bool) {
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	stats := caches[name]
+	if hit {
+		stats.Hits++
+	} else {
+		stats.Misses++
+	}
+	caches[name] = stats
+}
+
+// RecordOutput records the current counters of the network output
+func RecordOutput(stats OutputStats) {
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	outputStats = &stats
+}
+
+// RecordCPU measures the CPU usage of proxtop since the last call, called once per cycle
+func RecordCPU() {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return
+	}
+	now := time.Now()
+	cpuTime := time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	if !previousCPURead.IsZero() && now.After(previousCPURead) {
+		cpuUsage = float64(cpuTime-previousCPUTime) / float64(now.Sub(previousCPURead)) * 100
+	}
+	previousCPUTime = cpuTime
+	previousCPURead = now
+}
+
+// CurrentDiagnostics returns a copy of the diagnostics
+func CurrentDiagnostics() Diagnostics {
+	diagnostics := Diagnostics{
+		Phases: make(map[string]PhaseDurations),
+		Calls:  make(map[string]CallStats),
+		Caches: make(map[string]CacheStats),
+		RSS:    util.GetSelfRSS(),
+	}
+	Collection.Domains.Range(func(_, value interface{}) bool {
+		if value.(Domain).PID == 0 {
+			diagnostics.UnresolvedPIDs++
+		}
+		return true
+	})
+
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	for name, durations := range phases {
+		diagnostics.Phases[name] = durations
+	}
+	for kind, stats := range calls {
+		diagnostics.Calls[kind] = stats
+	}
+	for name, stats := range caches {
+		diagnostics.Caches[name] = stats
+	}
+	if outputStats != nil {
+		stats := *outputStats
+		diagnostics.Output = &stats
+	}
+	diagnostics.CPU = cpuUsage
+	return diagnostics
+}
@@ -1,6 +1,9 @@
 package models
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 // NewDomains instantiates a new Domains list
 func NewDomains() *Domains {
@@ -41,18 +44,53 @@ func (domains *Domains) Length() int {
 	return len(domains.domains)
 }
 
-// Range loops over the items and executes the given function
+// Range loops over the items and executes the given function until it returns false.
+// The function is called without holding the lock, so a slow VM does not block other collectors.
 func (domains *Domains) Range(f func(key, value interface{}) bool) {
 	domains.access.Lock()
-	defer domains.access.Unlock()
+	current := make(map[interface{}]Domain, len(domains.domains))
 	for key, domain := range domains.domains {
-		f(key, domain)
+		current[key] = domain
+	}
+	domains.access.Unlock()
+
+	for key, domain := range current {
+		if !f(key, domain) {
+			return
+		}
 	}
 }
 
+// RangeGuarded loops over the items like Range until f returns false, each call is guarded by the
+// circuit breaker of the VM (see GuardDomain). Used for calls which may block on the VM, like QMP or libvirt requests.
+// All calls together take at most GuardBudget, VMs not reached by then are skipped and stale.
+// A call abandoned after its deadline gets a guarded view of the metrics of the VM, which is fenced,
+// so it cannot write into the metrics of later cycles. A call skipped or abandoned does not stop the loop.
+func (domains *Domains) RangeGuarded(f func(key, value interface{}) bool) {
+	deadline := time.Now().Add(GuardBudget())
+	domains.Range(func(key, value interface{}) bool {
+		domain := value.(Domain)
+		if domain.Measurable != nil {
+			domain.Measurable = domain.Measurable.Guarded()
+		}
+		proceed := true
+		if !GuardDomain(key.(string), deadline, func() {
+			proceed = f(key, domain)
+		}) {
+			if domain.Measurable != nil {
+				domain.Measurable.Fence()
+			}
+			// an abandoned call may still set proceed
+			return true
+		}
+		return proceed
+	})
+}
+
 // Delete removes the element given by ke from the domains list
 func (domains *Domains) Delete(key string) {
 	domains.access.Lock()
 	defer domains.access.Unlock()
 	delete(domains.domains, key)
+	ForgetDomain(key)
 }
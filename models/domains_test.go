@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestRangeGuardedStops(t *testing.T) {
+	domains := NewDomains()
+	for _, uuid := range []string{"t-range-a", "t-range-b", "t-range-c"} {
+		domains.Store(uuid, Domain{UUID: uuid})
+	}
+	calls := 0
+	domains.RangeGuarded(func(key, value interface{}) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Errorf("RangeGuarded called f %d times after it returned false, want 1", calls)
+	}
+
+	calls = 0
+	domains.RangeGuarded(func(key, value interface{}) bool {
+		calls++
+		return true
+	})
+	if calls != 3 {
+		t.Errorf("RangeGuarded called f %d times, want 3", calls)
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// eventsMu protects pendingEvents, the events recorded since the last snapshot
+var eventsMu sync.Mutex
+var pendingEvents []json.RawMessage
+
+// RecordEvent records an event, e.g. the summary of a migration, as JSON object for the snapshot of the cycle
+func RecordEvent(event interface{}) {
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Cannot encode event: %v", err)
+		return
+	}
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	pendingEvents = append(pendingEvents, encoded)
+}
+
+// TakeEvents returns the events recorded since the last call, in the order they were recorded
+func TakeEvents() []json.RawMessage {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	events := pendingEvents
+	pendingEvents = nil
+	return events
+}
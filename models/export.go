@@ -0,0 +1,137 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CounterDef declares a raw counter of a collector for exporters computing rates themselves, e.g. prometheus.
+// The printed fields hold rates over the last interval, the raw counters are the totals they are computed from.
+type CounterDef struct {
+	// Metric is the name of the measurement, a * stands for the device name of counters per device,
+	// e.g. net_ReceivedBytes_* or disk_physdev_*_reads
+	Metric string
+	// Name is the exported name without scope and unit, e.g. net_received for proxtop_vm_net_received_bytes_total
+	Name      string
+	Collector string
+	Scope     Scope
+	// BaseUnit is the exported unit, e.g. bytes or seconds, empty for counts
+	BaseUnit string
+	// Scale converts the measured value to BaseUnit, e.g. 1e-9 for nanoseconds, 0 keeps the value
+	Scale       float64
+	Description string
+}
+
+// CounterSample is the value of a declared counter of the host or a VM at the time of a snapshot
+type CounterSample struct {
+	Def CounterDef
+	// UUID of the VM, empty for the host
+	UUID string
+	// Device is the device name of counters per device
+	Device string
+	Value  float64
+}
+
+var exportMu sync.RWMutex
+
+// counterDefs holds the declared counters in declaration order
+var counterDefs []CounterDef
+
+// RegisterCounters declares the raw counters a collector measures for scope (ScopeHost or ScopeDomain).
+// A counter declared again for the same measurement replaces the former declaration.
+func RegisterCounters(collector string, scope Scope, defs []CounterDef) {
+	exportMu.Lock()
+	defer exportMu.Unlock()
+	for _, def := range defs {
+		def.Collector = collector
+		def.Scope = scope
+		replaced := false
+		for i, existing := range counterDefs {
+			if existing.Scope == scope && existing.Metric == def.Metric {
+				counterDefs[i] = def
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			counterDefs = append(counterDefs, def)
+		}
+	}
+}
+
+// RegisteredCounters returns all declared counters in declaration order
+func RegisteredCounters() []CounterDef {
+	exportMu.RLock()
+	defer exportMu.RUnlock()
+	defs := make([]CounterDef, len(counterDefs))
+	copy(defs, counterDefs)
+	return defs
+}
+
+// counterSamples returns the current values of the declared counters of scope found in measurable
+func counterSamples(measurable *Measurable, scope Scope, uuid string, defs []CounterDef) []CounterSample {
+	samples := []CounterSample{}
+	metrics := measurable.Dump()
+	for _, def := range defs {
+		if def.Scope != scope {
+			continue
+		}
+		prefix, suffix, perDevice := splitCounterMetric(def.Metric)
+		if !perDevice {
+			if value, ok := counterValue(metrics[def.Metric], def); ok {
+				samples = append(samples, CounterSample{Def: def, UUID: uuid, Value: value})
+			}
+			continue
+		}
+		// sorted by device for a stable order
+		devices := []string{}
+		for name := range metrics {
+			if len(name) > len(prefix)+len(suffix) && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix) {
+				devices = append(devices, name[len(prefix):len(name)-len(suffix)])
+			}
+		}
+		sort.Strings(devices)
+		for _, device := range devices {
+			if value, ok := counterValue(metrics[prefix+device+suffix], def); ok {
+				samples = append(samples, CounterSample{Def: def, UUID: uuid, Device: device, Value: value})
+			}
+		}
+	}
+	return samples
+}
+
+// splitCounterMetric splits a counter metric at the * of the device name
+func splitCounterMetric(metric string) (string, string, bool) {
+	index := strings.Index(metric, "*")
+	if index < 0 {
+		return metric, "", false
+	}
+	return metric[:index], metric[index+1:], true
+}
+
+// counterValue returns the latest value of a counter metric converted to the base unit
+func counterValue(metric Metric, def CounterDef) (float64, bool) {
+	if metric.Len() == 0 {
+		return 0, false
+	}
+	value, err := metric.At(0).Uint64()
+	if err != nil {
+		return 0, false
+	}
+	if def.Scale != 0 {
+		return float64(value) * def.Scale, true
+	}
+	return float64(value), true
+}
+
+// CounterSamples returns the current values of the declared counters of the host and all VMs
+func CounterSamples() []CounterSample {
+	defs := RegisteredCounters()
+	samples := counterSamples(Collection.Host.Measurable, ScopeHost, "", defs)
+	Collection.Domains.Range(func(key, value interface{}) bool {
+		samples = append(samples, counterSamples(value.(Domain).Measurable, ScopeDomain, key.(string), defs)...)
+		return true
+	})
+	return samples
+}
@@ -0,0 +1,372 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// errDivisionByZero is returned by expressions dividing by zero, the derived field is shown as not available
+var errDivisionByZero = errors.New("division by zero")
+
+// FieldRef references a field of a scope in an expression
+type FieldRef struct {
+	Scope Scope
+	Name  string
+}
+
+// Resolver returns the value of a referenced field
+type Resolver func(ref FieldRef) (float64, error)
+
+// exprNode is a node of a parsed expression
+type exprNode interface {
+	eval(resolve Resolver) (float64, error)
+}
+
+type numberNode float64
+
+type refNode FieldRef
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+type callNode struct {
+	function string
+	args     []exprNode
+}
+
+// Expression is a parsed expression of a derived field
+type Expression struct {
+	Source string
+	// Refs lists the referenced fields in order of appearance
+	Refs []FieldRef
+	root exprNode
+}
+
+// Eval evaluates the expression, resolving referenced fields with resolve
+func (expression Expression) Eval(resolve Resolver) (float64, error) {
+	return expression.root.eval(resolve)
+}
+
+func (node numberNode) eval(resolve Resolver) (float64, error) {
+	return float64(node), nil
+}
+
+func (node refNode) eval(resolve Resolver) (float64, error) {
+	return resolve(FieldRef(node))
+}
+
+func (node unaryNode) eval(resolve Resolver) (float64, error) {
+	value, err := node.operand.eval(resolve)
+	if err != nil {
+		return 0, err
+	}
+	if node.op == "!" {
+		return boolValue(value == 0), nil
+	}
+	return -value, nil
+}
+
+func (node binaryNode) eval(resolve Resolver) (float64, error) {
+	left, err := node.left.eval(resolve)
+	if err != nil {
+		return 0, err
+	}
+	// logical operators do not evaluate the right side if the left one decides
+	if node.op == "&&" && left == 0 {
+		return 0, nil
+	}
+	if node.op == "||" && left != 0 {
+		return 1, nil
+	}
+	right, err := node.right.eval(resolve)
+	if err != nil {
+		return 0, err
+	}
+	switch node.op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return 0, errDivisionByZero
+		}
+		return left / right, nil
+	case "<":
+		return boolValue(left < right), nil
+	case "<=":
+		return boolValue(left <= right), nil
+	case ">":
+		return boolValue(left > right), nil
+	case ">=":
+		return boolValue(left >= right), nil
+	case "==":
+		return boolValue(left == right), nil
+	case "!=":
+		return boolValue(left != right), nil
+	}
+	// && and || with undecided left side
+	return boolValue(right != 0), nil
+}
+
+func (node callNode) eval(resolve Resolver) (float64, error) {
+	// if only evaluates the selected branch, e.g. if(x > 0, y / x, 0)
+	if node.function == "if" {
+		condition, err := node.args[0].eval(resolve)
+		if err != nil {
+			return 0, err
+		}
+		if condition != 0 {
+			return node.args[1].eval(resolve)
+		}
+		return node.args[2].eval(resolve)
+	}
+
+	values := make([]float64, len(node.args))
+	for i, arg := range node.args {
+		value, err := arg.eval(resolve)
+		if err != nil {
+			return 0, err
+		}
+		values[i] = value
+	}
+	result := values[0]
+	switch node.function {
+	case "min":
+		for _, value := range values[1:] {
+			result = math.Min(result, value)
+		}
+	case "max":
+		for _, value := range values[1:] {
+			result = math.Max(result, value)
+		}
+	case "abs":
+		result = math.Abs(result)
+	}
+	return result, nil
+}
+
+// boolValue returns 1 for true and 0 for false
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// functionArgs maps the functions of expressions to their number of arguments, -1 for at least one
+var functionArgs = map[string]int{
+	"min": -1,
+	"max": -1,
+	"abs": 1,
+	"if":  3,
+}
+
+// binaryLevels lists the binary operators by increasing precedence
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+// exprParser is a recursive descent parser of expressions
+type exprParser struct {
+	source string
+	pos    int
+	scope  Scope
+	refs   []FieldRef
+}
+
+// ParseExpression parses an expression of fields of scope. Fields are referenced by name, names containing
+// operators like dsk_READS/s in braces: {dsk_READS/s}. Expressions of domain fields reference host fields
+// with prefix host., e.g. mem_RSS / host.mem_total.
+func ParseExpression(source string, scope Scope) (Expression, error) {
+	parser := &exprParser{source: source, scope: scope}
+	root, err := parser.parseBinary(0)
+	if err != nil {
+		return Expression{}, err
+	}
+	parser.skipSpaces()
+	if parser.pos < len(source) {
+		return Expression{}, parser.errorf("unexpected %q", source[parser.pos:])
+	}
+	return Expression{Source: source, Refs: parser.refs, root: root}, nil
+}
+
+// errorf returns a parse error at the current position
+func (parser *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("expression %q at %d: %s", parser.source, parser.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (parser *exprParser) skipSpaces() {
+	for parser.pos < len(parser.source) && unicode.IsSpace(rune(parser.source[parser.pos])) {
+		parser.pos++
+	}
+}
+
+// accept consumes token if it follows
+func (parser *exprParser) accept(token string) bool {
+	parser.skipSpaces()
+	if strings.HasPrefix(parser.source[parser.pos:], token) {
+		parser.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// parseBinary parses the binary operators of level and higher precedence
+func (parser *exprParser) parseBinary(level int) (exprNode, error) {
+	if level == len(binaryLevels) {
+		return parser.parseUnary()
+	}
+	left, err := parser.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range binaryLevels[level] {
+			if parser.accept(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		right, err := parser.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (parser *exprParser) parseUnary() (exprNode, error) {
+	for _, op := range []string{"-", "!"} {
+		if parser.accept(op) {
+			operand, err := parser.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return unaryNode{op: op, operand: operand}, nil
+		}
+	}
+	return parser.parsePrimary()
+}
+
+func (parser *exprParser) parsePrimary() (exprNode, error) {
+	parser.skipSpaces()
+	if parser.pos >= len(parser.source) {
+		return nil, parser.errorf("unexpected end")
+	}
+
+	switch char := parser.source[parser.pos]; {
+	case char == '(':
+		parser.pos++
+		node, err := parser.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		if !parser.accept(")") {
+			return nil, parser.errorf("missing )")
+		}
+		return node, nil
+	case char == '{':
+		return parser.parseRef(parser.readBraced())
+	case char >= '0' && char <= '9' || char == '.':
+		start := parser.pos
+		for parser.pos < len(parser.source) && strings.ContainsRune("0123456789.", rune(parser.source[parser.pos])) {
+			parser.pos++
+		}
+		value, err := strconv.ParseFloat(parser.source[start:parser.pos], 64)
+		if err != nil {
+			return nil, parser.errorf("invalid number %q", parser.source[start:parser.pos])
+		}
+		return numberNode(value), nil
+	case isNameChar(char):
+		start := parser.pos
+		for parser.pos < len(parser.source) && (isNameChar(parser.source[parser.pos]) || parser.source[parser.pos] == '.') {
+			parser.pos++
+		}
+		name := parser.source[start:parser.pos]
+		if name == "host." && parser.pos < len(parser.source) && parser.source[parser.pos] == '{' {
+			name += parser.readBraced()
+		}
+		if _, isFunction := functionArgs[name]; isFunction && parser.accept("(") {
+			return parser.parseCall(name)
+		}
+		return parser.parseRef(name)
+	}
+	return nil, parser.errorf("unexpected %q", parser.source[parser.pos:parser.pos+1])
+}
+
+// readBraced returns the text within braces at the current position, empty if not closed
+func (parser *exprParser) readBraced() string {
+	end := strings.IndexByte(parser.source[parser.pos:], '}')
+	if end < 0 {
+		parser.pos = len(parser.source)
+		return ""
+	}
+	name := parser.source[parser.pos+1 : parser.pos+end]
+	parser.pos += end + 1
+	return name
+}
+
+// parseCall parses the arguments of a function after the opening parenthesis
+func (parser *exprParser) parseCall(function string) (exprNode, error) {
+	node := callNode{function: function}
+	for {
+		arg, err := parser.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		node.args = append(node.args, arg)
+		if parser.accept(")") {
+			break
+		}
+		if !parser.accept(",") {
+			return nil, parser.errorf("missing , or ) in %s()", function)
+		}
+	}
+	if expected := functionArgs[function]; expected > 0 && len(node.args) != expected {
+		return nil, parser.errorf("%s() takes %d arguments, not %d", function, expected, len(node.args))
+	}
+	return node, nil
+}
+
+// parseRef returns the reference of a field name, host fields are prefixed with host. in domain expressions
+func (parser *exprParser) parseRef(name string) (exprNode, error) {
+	ref := FieldRef{Scope: parser.scope, Name: name}
+	if strings.HasPrefix(name, "host.") {
+		ref = FieldRef{Scope: ScopeHost, Name: strings.TrimPrefix(name, "host.")}
+	}
+	if ref.Name == "" {
+		return nil, parser.errorf("missing field name")
+	}
+	if _, exists := LookupField(ref.Scope, ref.Name); !exists {
+		return nil, parser.errorf("unknown %s field %s", ref.Scope, ref.Name)
+	}
+	parser.refs = append(parser.refs, ref)
+	return refNode(ref), nil
+}
+
+// isNameChar returns whether char may be part of an unbraced field name
+func isNameChar(char byte) bool {
+	return char == '_' || char == '%' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9'
+}
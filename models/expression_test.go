@@ -0,0 +1,161 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+// registerExpressionFields declares the fields referenced by the expressions of the tests
+func registerExpressionFields() {
+	RegisterFields("test", ScopeDomain, []FieldDef{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "zero"}, {Name: "dsk_READS/s"}})
+	RegisterFields("test", ScopeHost, []FieldDef{{Name: "a"}, {Name: "mem_total"}, {Name: "net_MbRX/s"}})
+}
+
+// expressionValues resolves the fields of the tests, host fields are 100 times the domain fields
+func expressionValues(ref FieldRef) (float64, error) {
+	values := map[string]float64{"a": 2, "b": 3, "c": 4, "zero": 0, "dsk_READS/s": 10, "mem_total": 1000, "net_MbRX/s": 5}
+	value, exists := values[ref.Name]
+	if !exists {
+		return 0, errors.New("not printed")
+	}
+	if ref.Scope == ScopeHost {
+		return value * 100, nil
+	}
+	return value, nil
+}
+
+func TestParseExpressionEval(t *testing.T) {
+	registerExpressionFields()
+	tests := []struct {
+		source string
+		want   float64
+	}{
+		// precedence
+		{"a+b*c", 14},
+		{"(a+b)*c", 20},
+		{"a-b-c", -5},
+		{"c/a/a", 1},
+		{"a<b&&c", 1},
+		{"a<b&&zero", 0},
+		{"zero||a>b", 0},
+		{"a+1<b*2", 1},
+		{"a==2||b==2&&zero", 1},
+		// != and unary !
+		{"a!=b", 1},
+		{"a != a", 0},
+		{"!a", 0},
+		{"!zero", 1},
+		{"!!a", 1},
+		{"!a!=zero", 0},
+		{"-a*b", -6},
+		{"a - -b", 5},
+		// braced names and host fields
+		{"{dsk_READS/s}*2", 20},
+		{"{dsk_READS/s}/{a}", 5},
+		{"host.mem_total", 100000},
+		{"host.{net_MbRX/s}", 500},
+		{"a/host.a", 0.01},
+		// functions
+		{"min(c, a, b)", 2},
+		{"max(a, c)", 4},
+		{"abs(a-c)", 2},
+		{"if(a>b, 1, 2)", 2},
+		{"1.5*a", 3},
+	}
+	for _, test := range tests {
+		expression, err := ParseExpression(test.source, ScopeDomain)
+		if err != nil {
+			t.Errorf("ParseExpression(%q) error: %v", test.source, err)
+			continue
+		}
+		got, err := expression.Eval(expressionValues)
+		if err != nil {
+			t.Errorf("Eval(%q) error: %v", test.source, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Eval(%q) = %v, want %v", test.source, got, test.want)
+		}
+	}
+}
+
+func TestParseExpressionRefs(t *testing.T) {
+	registerExpressionFields()
+	expression, err := ParseExpression("{dsk_READS/s}/host.{net_MbRX/s}+a", ScopeDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FieldRef{{ScopeDomain, "dsk_READS/s"}, {ScopeHost, "net_MbRX/s"}, {ScopeDomain, "a"}}
+	if len(expression.Refs) != len(want) {
+		t.Fatalf("Refs = %v, want %v", expression.Refs, want)
+	}
+	for i := range want {
+		if expression.Refs[i] != want[i] {
+			t.Errorf("Refs[%d] = %v, want %v", i, expression.Refs[i], want[i])
+		}
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	registerExpressionFields()
+	tests := []string{
+		"",
+		"a+",
+		"(a+b",
+		"a b",
+		"a=b",
+		"{dsk_READS/s",
+		"unknown",
+		"host.b",
+		"{}",
+		"if(a, b)",
+		"if(a, b, c, a)",
+		"abs(a, b)",
+		"min()",
+		"max(a b)",
+		"1.2.3",
+		"a $ b",
+	}
+	for _, source := range tests {
+		if _, err := ParseExpression(source, ScopeDomain); err == nil {
+			t.Errorf("ParseExpression(%q) succeeded, want error", source)
+		}
+	}
+}
+
+func TestExpressionEvalErrors(t *testing.T) {
+	registerExpressionFields()
+	failing := func(ref FieldRef) (float64, error) {
+		if ref.Name == "c" {
+			return 0, ErrCounterReset
+		}
+		return expressionValues(ref)
+	}
+	tests := []struct {
+		source string
+		want   float64
+		err    error
+	}{
+		{"a/zero", 0, errDivisionByZero},
+		{"a/(b-b)", 0, errDivisionByZero},
+		{"c+a", 0, ErrCounterReset},
+		// short-circuiting: the side not taken is not evaluated
+		{"if(zero, a/zero, b)", 3, nil},
+		{"if(a, b, c)", 3, nil},
+		{"if(zero, b, c)", 0, ErrCounterReset},
+		{"zero && c", 0, nil},
+		{"a || a/zero", 1, nil},
+		{"zero || a/zero", 0, errDivisionByZero},
+	}
+	for _, test := range tests {
+		expression, err := ParseExpression(test.source, ScopeDomain)
+		if err != nil {
+			t.Errorf("ParseExpression(%q) error: %v", test.source, err)
+			continue
+		}
+		got, err := expression.Eval(failing)
+		if err != test.err || got != test.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v, %v", test.source, got, err, test.want, test.err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// WindowStats summarizes the numeric measurements of a metric within a time window
+type WindowStats struct {
+	Count int
+	Min   float64
+	Max   float64
+	Avg   float64
+}
+
+// getMeasurementPair returns the newest measurement and the one intervals before it, read under one lock
+func (measurable *Measurable) getMeasurementPair(metricName string, intervals int) (Measurement, Measurement, error) {
+	measurable.access.Lock()
+	defer measurable.access.Unlock()
+	metric, exists := measurable.metrics[metricName]
+	if !exists {
+		return Measurement{}, Measurement{}, fmt.Errorf("metric %s not found", metricName)
+	}
+	if intervals < 1 || metric.Len() <= intervals {
+		return Measurement{}, Measurement{}, fmt.Errorf("metric %s has no index %d", metricName, intervals)
+	}
+	return metric.At(0), metric.At(intervals), nil
+}
+
+// windowValues returns the numeric measurements not older than window before the newest one, newest first
+func (measurable *Measurable) windowValues(metricName string, window time.Duration) ([]float64, error) {
+	measurable.access.Lock()
+	defer measurable.access.Unlock()
+	metric, exists := measurable.metrics[metricName]
+	if !exists || metric.Len() == 0 {
+		return nil, fmt.Errorf("metric %s not found", metricName)
+	}
+	start := metric.At(0).Timestamp.Add(-window)
+	values := make([]float64, 0, metric.Len())
+	for i := 0; i < metric.Len(); i++ {
+		measurement := metric.At(i)
+		if measurement.Timestamp.Before(start) {
+			break
+		}
+		value, err := measurement.numeric()
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %v", metricName, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// GetMetricRate returns the per second increase of an uint64 counter over the last intervals measurements,
+// ErrCounterReset if the counter was reset within them
+func (measurable *Measurable) GetMetricRate(metricName string, intervals int) (float64, error) {
+	measurable.access.Lock()
+	defer measurable.access.Unlock()
+	metric, exists := measurable.metrics[metricName]
+	if !exists {
+		return 0, fmt.Errorf("metric %s not found", metricName)
+	}
+	if intervals < 1 || metric.Len() <= intervals {
+		return 0, fmt.Errorf("metric %s has no index %d", metricName, intervals)
+	}
+	var sum uint64
+	for i := 0; i < intervals; i++ {
+		diff, err := counterDiff(metricName, metric.At(i), metric.At(i+1))
+		if err != nil {
+			return 0, err
+		}
+		sum += diff
+	}
+	seconds := metric.At(0).Timestamp.Sub(metric.At(intervals).Timestamp).Seconds()
+	if seconds <= 0 {
+		return 0, fmt.Errorf("metric %s has no time difference", metricName)
+	}
+	return float64(sum) / seconds, nil
+}
+
+// GetMetricWindow returns min, max and average of the measurements within window before the newest one
+func (measurable *Measurable) GetMetricWindow(metricName string, window time.Duration) (WindowStats, error) {
+	stats := WindowStats{}
+	values, err := measurable.windowValues(metricName, window)
+	if err != nil {
+		return stats, err
+	}
+	stats.Count = len(values)
+	stats.Min = values[0]
+	stats.Max = values[0]
+	sum := 0.0
+	for _, value := range values {
+		stats.Min = math.Min(stats.Min, value)
+		stats.Max = math.Max(stats.Max, value)
+		sum += value
+	}
+	stats.Avg = sum / float64(len(values))
+	return stats, nil
+}
+
+// GetMetricPercentile returns the percentile (0-100, nearest rank) of the measurements within window before the newest one
+func (measurable *Measurable) GetMetricPercentile(metricName string, window time.Duration, percentile float64) (float64, error) {
+	if percentile < 0 || percentile > 100 {
+		return 0, fmt.Errorf("invalid percentile %.1f", percentile)
+	}
+	values, err := measurable.windowValues(metricName, window)
+	if err != nil {
+		return 0, err
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(percentile / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	}
+	return values[rank-1], nil
+}
+
+// GetMetricAt returns the newest measurement taken at or before timestamp
+func (measurable *Measurable) GetMetricAt(metricName string, timestamp time.Time) (Measurement, error) {
+	measurable.access.Lock()
+	defer measurable.access.Unlock()
+	metric, exists := measurable.metrics[metricName]
+	if !exists {
+		return Measurement{}, fmt.Errorf("metric %s not found", metricName)
+	}
+	for i := 0; i < metric.Len(); i++ {
+		if measurement := metric.At(i); !measurement.Timestamp.After(timestamp) {
+			return measurement, nil
+		}
+	}
+	return Measurement{}, fmt.Errorf("metric %s has no measurement at %s", metricName, timestamp.Format(time.RFC3339))
+}
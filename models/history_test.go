@@ -0,0 +1,156 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"proxtop/config"
+)
+
+// historyOf returns a measurable with a history of depth holding a measurement of t_hist per value,
+// one second apart, the last at start
+func historyOf(depth int, start time.Time, values ...uint64) *Measurable {
+	saved := config.Options.History
+	config.Options.History = depth
+	defer func() { config.Options.History = saved }()
+
+	measurable := NewMeasurable()
+	for i, value := range values {
+		measurement := CreateMeasurement(value)
+		measurement.Timestamp = start.Add(time.Duration(i-len(values)+1) * time.Second)
+		measurable.AddMetricMeasurement("t_hist", measurement)
+	}
+	return measurable
+}
+
+func TestHistoryWraparound(t *testing.T) {
+	start := time.Now()
+	// 6 measurements in a ring of 4, the oldest two are dropped
+	measurable := historyOf(4, start, 1, 2, 3, 4, 5, 6)
+	metric, _ := measurable.GetMetric("t_hist")
+	if metric.Len() != 4 {
+		t.Fatalf("Len = %d, want 4", metric.Len())
+	}
+	for i, want := range []uint64{6, 5, 4, 3} {
+		if got, _ := metric.At(i).Uint64(); got != want {
+			t.Errorf("At(%d) = %d, want %d", i, got, want)
+		}
+	}
+	if _, err := measurable.GetMeasurement("t_hist", 4); err == nil {
+		t.Error("GetMeasurement beyond the history succeeded, want error")
+	}
+}
+
+func TestGetMetricRate(t *testing.T) {
+	start := time.Now()
+	measurable := historyOf(5, start, 100, 110, 130, 160, 200)
+	tests := []struct {
+		intervals int
+		want      float64
+		fails     bool
+	}{
+		{1, 40, false},
+		{2, 35, false},
+		{4, 25, false},
+		{0, 0, true},
+		{5, 0, true},
+	}
+	for _, test := range tests {
+		got, err := measurable.GetMetricRate("t_hist", test.intervals)
+		if (err != nil) != test.fails || got != test.want {
+			t.Errorf("GetMetricRate(%d) = %v, %v, want %v, fails %v", test.intervals, got, err, test.want, test.fails)
+		}
+	}
+
+	reset := historyOf(5, start, 100, 110, 5, 15, 25)
+	if _, err := reset.GetMetricRate("t_hist", 2); err != nil {
+		t.Errorf("GetMetricRate after the reset error: %v", err)
+	}
+	if _, err := reset.GetMetricRate("t_hist", 3); err != ErrCounterReset {
+		t.Errorf("GetMetricRate across the reset error = %v, want %v", err, ErrCounterReset)
+	}
+}
+
+func TestGetMetricWindow(t *testing.T) {
+	start := time.Now()
+	measurable := historyOf(6, start, 7, 1, 4, 2, 8, 3)
+	tests := []struct {
+		window time.Duration
+		want   WindowStats
+	}{
+		// only the newest measurement
+		{0, WindowStats{Count: 1, Min: 3, Max: 3, Avg: 3}},
+		{500 * time.Millisecond, WindowStats{Count: 1, Min: 3, Max: 3, Avg: 3}},
+		// the bounds are inclusive
+		{2 * time.Second, WindowStats{Count: 3, Min: 2, Max: 8, Avg: 13.0 / 3}},
+		{time.Hour, WindowStats{Count: 6, Min: 1, Max: 8, Avg: 25.0 / 6}},
+	}
+	for _, test := range tests {
+		got, err := measurable.GetMetricWindow("t_hist", test.window)
+		if err != nil || got != test.want {
+			t.Errorf("GetMetricWindow(%v) = %+v, %v, want %+v", test.window, got, err, test.want)
+		}
+	}
+	if _, err := measurable.GetMetricWindow("t_missing", time.Hour); err == nil {
+		t.Error("GetMetricWindow of a missing metric succeeded, want error")
+	}
+}
+
+func TestGetMetricPercentile(t *testing.T) {
+	start := time.Now()
+	measurable := historyOf(10, start, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100)
+	tests := []struct {
+		window     time.Duration
+		percentile float64
+		want       float64
+	}{
+		// nearest rank: the smallest value with at least percentile of the values at or below it
+		{time.Hour, 0, 10},
+		{time.Hour, 10, 10},
+		{time.Hour, 11, 20},
+		{time.Hour, 50, 50},
+		{time.Hour, 95, 100},
+		{time.Hour, 100, 100},
+		// within the newest 3 measurements 80, 90 and 100
+		{2 * time.Second, 50, 90},
+		{2 * time.Second, 34, 90},
+		{2 * time.Second, 33, 80},
+	}
+	for _, test := range tests {
+		got, err := measurable.GetMetricPercentile("t_hist", test.window, test.percentile)
+		if err != nil || got != test.want {
+			t.Errorf("GetMetricPercentile(%v, %v) = %v, %v, want %v", test.window, test.percentile, got, err, test.want)
+		}
+	}
+	for _, percentile := range []float64{-1, 101} {
+		if _, err := measurable.GetMetricPercentile("t_hist", time.Hour, percentile); err == nil {
+			t.Errorf("GetMetricPercentile(%v) succeeded, want error", percentile)
+		}
+	}
+}
+
+func TestGetMetricAt(t *testing.T) {
+	start := time.Now()
+	measurable := historyOf(4, start, 1, 2, 3, 4)
+	tests := []struct {
+		timestamp time.Time
+		want      uint64
+		fails     bool
+	}{
+		{start, 4, false},
+		{start.Add(time.Minute), 4, false},
+		{start.Add(-500 * time.Millisecond), 3, false},
+		{start.Add(-3 * time.Second), 1, false},
+		{start.Add(-4 * time.Second), 0, true},
+	}
+	for _, test := range tests {
+		measurement, err := measurable.GetMetricAt("t_hist", test.timestamp)
+		if (err != nil) != test.fails {
+			t.Errorf("GetMetricAt(%v) error = %v, fails %v", test.timestamp.Sub(start), err, test.fails)
+			continue
+		}
+		if got, _ := measurement.Uint64(); !test.fails && got != test.want {
+			t.Errorf("GetMetricAt(%v) = %d, want %d", test.timestamp.Sub(start), got, test.want)
+		}
+	}
+}
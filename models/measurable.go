@@ -1,214 +1,257 @@
 package models
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
+	"math"
+	"strconv"
 	"sync"
+
+	"proxtop/config"
 )
 
 // NewMeasurable instantiates and returns a new Measurable
 func NewMeasurable() *Measurable {
 	return &Measurable{
 		//Metrics: sync.Map{},
-		metrics: make(map[string]Metric),
-		access:  sync.Mutex{},
+		measurableData: &measurableData{
+			metrics: make(map[string]*Metric),
+		},
 	}
 }
 
 // Measurable holds collector metrics in a map
 type Measurable struct {
 	//Metrics sync.Map
-	access  sync.Mutex
-	metrics map[string]Metric
+	*measurableData
+	// fenced is set on the guarded view of a call which was abandoned, its writes are refused (see Guarded)
+	fenced *bool
 }
 
-// AddMetricMeasurement adds a metric measurement
-func (measurable *Measurable) AddMetricMeasurement(metricName string, measurement Measurement) {
+// measurableData are the metrics of a Measurable, shared with its guarded views
+type measurableData struct {
+	access   sync.Mutex
+	metrics  map[string]*Metric
+	identity uint64
+}
+
+// Guarded returns a view of the measurable for a call which may be abandoned after a deadline.
+// The view reads and writes the metrics of the measurable until Fence is called.
+func (measurable *Measurable) Guarded() *Measurable {
+	return &Measurable{measurableData: measurable.measurableData, fenced: new(bool)}
+}
+
+// Fence refuses all further writes of a guarded view. Writes in progress are done when it returns,
+// so an abandoned call cannot change the metrics of later cycles.
+func (measurable *Measurable) Fence() {
 	measurable.access.Lock()
 	defer measurable.access.Unlock()
-
-	// create empty metric if not existent
-	if _, ok := measurable.metrics[metricName]; !ok {
-		measurable.metrics[metricName] = Metric{
-			Name:   metricName,
-			Values: []Measurement{},
-		}
+	if measurable.fenced != nil {
+		*measurable.fenced = true
 	}
+}
+
+// writable tells whether writes are accepted, false for a fenced view. The lock must be held.
+func (measurable *Measurable) writable() bool {
+	return measurable.fenced == nil || !*measurable.fenced
+}
 
-	metric := measurable.metrics[metricName]
+// historyDepth returns the number of measurements kept per metric, at least 2 for diffs
+func historyDepth() int {
+	if config.Options.History < 2 {
+		return 2
+	}
+	return config.Options.History
+}
 
-	// add measurement as value to metric
-	end := len(metric.Values) - 1
-	if end <= 0 {
-		end = len(metric.Values)
+// AddMetricMeasurement adds a metric measurement, the oldest measurement is dropped when the history is full
+func (measurable *Measurable) AddMetricMeasurement(metricName string, measurement Measurement) {
+	measurable.access.Lock()
+	defer measurable.access.Unlock()
+	if !measurable.writable() {
+		return
 	}
-	metric.Values = append([]Measurement{measurement}, metric.Values[0:end]...)
 
-	// store back
-	measurable.metrics[metricName] = metric
+	// create empty metric if not existent
+	metric, ok := measurable.metrics[metricName]
+	if !ok {
+		created := newMetric(metricName, historyDepth())
+		metric = &created
+		measurable.metrics[metricName] = metric
+	}
+	if measurement.identity == 0 {
+		measurement.identity = measurable.identity
+	}
+	metric.add(measurement)
 }
 
 // DelMetricMeasurement removes a metric
 func (measurable *Measurable) DelMetricMeasurement(metricName string) {
 	measurable.access.Lock()
 	defer measurable.access.Unlock()
+	if !measurable.writable() {
+		return
+	}
 	delete(measurable.metrics, metricName)
 }
 
-// GetMetric reads and returns the metric values by metric name
+// GetMetric reads and returns a copy of the metric values by metric name
 func (measurable *Measurable) GetMetric(metricName string) (*Metric, bool) {
 	measurable.access.Lock()
 	defer measurable.access.Unlock()
-	var metric Metric
 	metric, exists := measurable.metrics[metricName]
 	if !exists {
 		return &Metric{}, false
 	}
-	return &metric, true
+	copied := metric.copy()
+	return &copied, true
+}
+
+// GetMeasurement returns the measurement of a metric at measurement index
+func (measurable *Measurable) GetMeasurement(metricName string, measurementIndex int) (Measurement, error) {
+	measurable.access.Lock()
+	defer measurable.access.Unlock()
+	metric, exists := measurable.metrics[metricName]
+	if !exists {
+		return Measurement{}, fmt.Errorf("metric %s not found", metricName)
+	}
+	if measurementIndex < 0 || metric.Len() <= measurementIndex {
+		return Measurement{}, fmt.Errorf("metric %s has no index %d", metricName, measurementIndex)
+	}
+	return metric.At(measurementIndex), nil
 }
 
 // GetMetricString returns the given metric value at measurement index as string
 func (measurable *Measurable) GetMetricString(metricName string, measurementIndex int) string {
-	var output string
-	if metric, ok := measurable.GetMetric(metricName); ok {
-		if len(metric.Values) > measurementIndex {
-			byteValue := metric.Values[measurementIndex].Value
-			reader := bytes.NewReader(byteValue)
-			decoder := gob.NewDecoder(reader)
-			var valuetype string
-			decoder.Decode(&valuetype)
-			output = fmt.Sprintf("%s", valuetype)
-		}
-	}
+	output, _ := measurable.GetMetricStringRaw(metricName, measurementIndex)
 	return output
 }
 
+// GetMetricStringRaw returns the given metric value at measurement index, an error if it is no string
+func (measurable *Measurable) GetMetricStringRaw(metricName string, measurementIndex int) (string, error) {
+	measurement, err := measurable.GetMeasurement(metricName, measurementIndex)
+	if err != nil {
+		return "", err
+	}
+	output, err := measurement.Text()
+	if err != nil {
+		return "", fmt.Errorf("metric %s: %v", metricName, err)
+	}
+	return output, nil
+}
+
 // GetMetricIntArray reads and returns a metric int array by metric name
 func (measurable *Measurable) GetMetricIntArray(metricName string) []int {
-	var array []int
-	if metric, ok := measurable.GetMetric(metricName); ok {
-		if len(metric.Values) > 0 {
-			byteValue := metric.Values[0].Value
-			reader := bytes.NewReader(byteValue)
-			dec := gob.NewDecoder(reader)
-			dec.Decode(&array)
-		}
-	}
+	array, _ := measurable.GetMetricIntArrayRaw(metricName)
 	return array
 }
 
+// GetMetricIntArrayRaw reads and returns a metric int array by metric name, an error if it is no int array
+func (measurable *Measurable) GetMetricIntArrayRaw(metricName string) ([]int, error) {
+	measurement, err := measurable.GetMeasurement(metricName, 0)
+	if err != nil {
+		return nil, err
+	}
+	array, err := measurement.IntArray()
+	if err != nil {
+		return nil, fmt.Errorf("metric %s: %v", metricName, err)
+	}
+	return array, nil
+}
+
 // GetMetricStringArray reads and returns a metric string array by metric name
 func (measurable *Measurable) GetMetricStringArray(metricName string) []string {
-	var array []string
-	if metric, ok := measurable.GetMetric(metricName); ok {
-		if len(metric.Values) > 0 {
-			byteValue := metric.Values[0].Value
-			reader := bytes.NewReader(byteValue)
-			dec := gob.NewDecoder(reader)
-			dec.Decode(&array)
-		}
-	}
+	array, _ := measurable.GetMetricStringArrayRaw(metricName)
 	return array
 }
 
-// Dump returns all the measurements as map
+// GetMetricStringArrayRaw reads and returns a metric string array by metric name, an error if it is no string array
+func (measurable *Measurable) GetMetricStringArrayRaw(metricName string) ([]string, error) {
+	measurement, err := measurable.GetMeasurement(metricName, 0)
+	if err != nil {
+		return nil, err
+	}
+	array, err := measurement.StringArray()
+	if err != nil {
+		return nil, fmt.Errorf("metric %s: %v", metricName, err)
+	}
+	return array, nil
+}
+
+// Dump returns a copy of all the measurements as map
 func (measurable *Measurable) Dump() map[string]Metric {
-	return measurable.metrics
+	measurable.access.Lock()
+	defer measurable.access.Unlock()
+	dump := make(map[string]Metric, len(measurable.metrics))
+	for name, metric := range measurable.metrics {
+		dump[name] = metric.copy()
+	}
+	return dump
 }
 
 // GetMetricUint64 returns the given metric value at measurement index as string
 func (measurable *Measurable) GetMetricUint64(metricName string, measurementIndex int) (string, error) {
-	var output string
-	if metric, ok := measurable.GetMetric(metricName); ok {
-		if len(metric.Values) > measurementIndex {
-			byteValue := metric.Values[measurementIndex].Value
-			reader := bytes.NewReader(byteValue)
-			decoder := gob.NewDecoder(reader)
-			var valuetype uint64
-			decoder.Decode(&valuetype)
-			output = fmt.Sprintf("%d", valuetype)
-		} else {
-			return "", fmt.Errorf("metric %s has no index %d", metricName, measurementIndex)
-		}
-	} else {
-		// fmt.Printf("metric %s not found, dump: %+v\n", metricName, measurable.Dump())
-		return "", fmt.Errorf("metric %s not found", metricName)
+	output, err := measurable.GetMetricUint64Raw(metricName, measurementIndex)
+	if err != nil {
+		return "", err
 	}
-	return output, nil
+	return strconv.FormatUint(output, 10), nil
 }
 
 // GetMetricUint64Raw returns the given metric value at measurement index as uint64
 func (measurable *Measurable) GetMetricUint64Raw(metricName string, measurementIndex int) (uint64, error) {
-	var output uint64
-	if metric, ok := measurable.GetMetric(metricName); ok {
-		if len(metric.Values) > measurementIndex {
-			byteValue := metric.Values[measurementIndex].Value
-			reader := bytes.NewReader(byteValue)
-			decoder := gob.NewDecoder(reader)
-			decoder.Decode(&output)
-		} else {
-			return 0, fmt.Errorf("metric %s has no index %d", metricName, measurementIndex)
-		}
-	} else {
-		return 0, fmt.Errorf("metric %s not found", metricName)
+	measurement, err := measurable.GetMeasurement(metricName, measurementIndex)
+	if err != nil {
+		return 0, err
+	}
+	output, err := measurement.Uint64()
+	if err != nil {
+		return 0, fmt.Errorf("metric %s: %v", metricName, err)
 	}
 	return output, nil
 }
 
-// GetMetricFloat64 computes the diff as Float64 between the two measurements for given metric and returns it as string
+// GetMetricFloat64 returns the given metric value at measurement index as string
 func (measurable *Measurable) GetMetricFloat64(metricName string, measurementIndex int) string {
-	var output string
-	if metric, ok := measurable.GetMetric(metricName); ok {
-		if len(metric.Values) > measurementIndex {
-			byteValue := metric.Values[measurementIndex].Value
-			reader := bytes.NewReader(byteValue)
-			decoder := gob.NewDecoder(reader)
-			var valuetype float64
-			decoder.Decode(&valuetype)
-			output = fmt.Sprintf("%f", valuetype)
-		}
+	output, err := measurable.GetMetricFloat64Raw(metricName, measurementIndex)
+	if err != nil {
+		return ""
 	}
-	return output
+	return fmt.Sprintf("%f", output)
 }
 
-// GetMetricDiffUint64AsFloat computes the diff as Uint64 between the two measurements for given metric and returns it as string
+// GetMetricFloat64Raw returns the given metric value at measurement index as float64
+func (measurable *Measurable) GetMetricFloat64Raw(metricName string, measurementIndex int) (float64, error) {
+	measurement, err := measurable.GetMeasurement(metricName, measurementIndex)
+	if err != nil {
+		return 0, err
+	}
+	output, err := measurement.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("metric %s: %v", metricName, err)
+	}
+	return output, nil
+}
+
+// GetMetricDiffUint64AsFloat computes the diff as Uint64 between the two measurements for given metric and returns it as float,
+// NaN across a counter reset
 func (measurable *Measurable) GetMetricDiffUint64AsFloat(metricName string, perTime bool) float64 {
-	var output float64
-	if metric, ok := measurable.GetMetric(metricName); ok {
-		if len(metric.Values) >= 2 {
-			// get first value
-			byteValue1 := metric.Values[0].Value
-			reader1 := bytes.NewReader(byteValue1)
-			decoder1 := gob.NewDecoder(reader1)
-			var value1 uint64
-			decoder1.Decode(&value1)
-
-			// get second value
-			byteValue2 := metric.Values[1].Value
-			reader2 := bytes.NewReader(byteValue2)
-			decoder2 := gob.NewDecoder(reader2)
-			var value2 uint64
-			decoder2.Decode(&value2)
-
-			// calculate value diff per time
-			value := float64(value1 - value2)
-
-			// get time diff
-			if perTime {
-				timeDiff := metric.Values[0].Timestamp.Sub(metric.Values[1].Timestamp).Seconds()
-				value = value / timeDiff
-			}
-			output = value
-		}
+	value, err := measurable.GetMetricCounterDiff(metricName, perTime)
+	if err == ErrCounterReset {
+		return math.NaN()
 	}
-	return output
+	if err != nil {
+		return 0
+	}
+	return value
 }
 
 // GetMetricDiffUint64 computes the diff as Uint64 between the two measurements for given metric and returns it as string
 func (measurable *Measurable) GetMetricDiffUint64(metricName string, perTime bool) string {
 	var output string
 	diff := measurable.GetMetricDiffUint64AsFloat(metricName, perTime)
+	if math.IsNaN(diff) {
+		return ResetMarker
+	}
 	output = fmt.Sprintf("%.0f", diff)
 	return output
 }
@@ -1,27 +1,138 @@
 package models
 
 import (
-	"bytes"
-	"encoding/gob"
+	"fmt"
+	"math"
 	"time"
 )
 
-// Measurement represents one measurement value at a timestamp
+// ValueType describes the type of a measurement value
+type ValueType uint8
+
+// Types of measurement values
+const (
+	ValueNone ValueType = iota
+	ValueUint64
+	ValueFloat64
+	ValueString
+	ValueStringArray
+	ValueIntArray
+)
+
+// String returns the name of the value type
+func (valueType ValueType) String() string {
+	switch valueType {
+	case ValueUint64:
+		return "uint64"
+	case ValueFloat64:
+		return "float64"
+	case ValueString:
+		return "string"
+	case ValueStringArray:
+		return "[]string"
+	case ValueIntArray:
+		return "[]int"
+	}
+	return "none"
+}
+
+// Measurement represents one measurement value at a timestamp.
+// Numbers are kept in number (float64 as IEEE 754 bits), so storing them does not allocate.
 type Measurement struct {
-	Value     []byte
+	Type   ValueType
+	number uint64
+	text   string
+	texts  []string
+	ints   []int
+	// identity of the counted object (process, device), counters are not compared across identities
+	identity  uint64
 	Timestamp time.Time
 }
 
-// CreateMeasurement creates a new measurement with recent time and bytes from provided value
+// CreateMeasurement creates a new measurement with recent time from provided value
+// (uint64, float64, string, []string or []int). Other types result in a measurement without value.
 func CreateMeasurement(value interface{}) Measurement {
-	var buffer bytes.Buffer
-	enc := gob.NewEncoder(&buffer)
-	err := enc.Encode(value)
-	if err != nil {
+	measurement := Measurement{Timestamp: time.Now()}
+	switch typed := value.(type) {
+	case uint64:
+		measurement.Type = ValueUint64
+		measurement.number = typed
+	case float64:
+		measurement.Type = ValueFloat64
+		measurement.number = math.Float64bits(typed)
+	case string:
+		measurement.Type = ValueString
+		measurement.text = typed
+	case []string:
+		measurement.Type = ValueStringArray
+		measurement.texts = typed
+	case []int:
+		measurement.Type = ValueIntArray
+		measurement.ints = typed
+	default:
 		return Measurement{}
 	}
-	return Measurement{
-		Value:     buffer.Bytes(),
-		Timestamp: time.Now(),
+	return measurement
+}
+
+// WithIdentity returns the measurement for the given identity of the counted object, e.g. the ifindex of a device
+func (measurement Measurement) WithIdentity(identity uint64) Measurement {
+	measurement.identity = identity
+	return measurement
+}
+
+// typeError returns the error for reading a measurement as another type
+func (measurement Measurement) typeError(expected ValueType) error {
+	return fmt.Errorf("measurement is %s, not %s", measurement.Type, expected)
+}
+
+// Uint64 returns the value of an uint64 measurement
+func (measurement Measurement) Uint64() (uint64, error) {
+	if measurement.Type != ValueUint64 {
+		return 0, measurement.typeError(ValueUint64)
+	}
+	return measurement.number, nil
+}
+
+// Float64 returns the value of a float64 measurement
+func (measurement Measurement) Float64() (float64, error) {
+	if measurement.Type != ValueFloat64 {
+		return 0, measurement.typeError(ValueFloat64)
+	}
+	return math.Float64frombits(measurement.number), nil
+}
+
+// Text returns the value of a string measurement
+func (measurement Measurement) Text() (string, error) {
+	if measurement.Type != ValueString {
+		return "", measurement.typeError(ValueString)
+	}
+	return measurement.text, nil
+}
+
+// StringArray returns the value of a []string measurement
+func (measurement Measurement) StringArray() ([]string, error) {
+	if measurement.Type != ValueStringArray {
+		return nil, measurement.typeError(ValueStringArray)
+	}
+	return measurement.texts, nil
+}
+
+// IntArray returns the value of a []int measurement
+func (measurement Measurement) IntArray() ([]int, error) {
+	if measurement.Type != ValueIntArray {
+		return nil, measurement.typeError(ValueIntArray)
+	}
+	return measurement.ints, nil
+}
+
+// numeric returns the value of an uint64 or float64 measurement as float64
+func (measurement Measurement) numeric() (float64, error) {
+	switch measurement.Type {
+	case ValueUint64:
+		return float64(measurement.number), nil
+	case ValueFloat64:
+		return math.Float64frombits(measurement.number), nil
 	}
+	return 0, fmt.Errorf("measurement is %s, not numeric", measurement.Type)
 }
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// benchVMs and benchCounters size the simulated host: 500 VMs with 60 counters each
+const benchVMs = 500
+const benchCounters = 60
+
+// gobMeasurement is the former measurement, the value gob-encoded
+type gobMeasurement struct {
+	Value     []byte
+	Timestamp time.Time
+}
+
+// createGobMeasurement encodes a value like the former CreateMeasurement
+func createGobMeasurement(value interface{}) gobMeasurement {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(value); err != nil {
+		return gobMeasurement{}
+	}
+	return gobMeasurement{Value: buffer.Bytes(), Timestamp: time.Now()}
+}
+
+// gobDiff decodes the last two measurements and returns their diff per second like the former GetMetricDiffUint64AsFloat
+func gobDiff(measurements []gobMeasurement) float64 {
+	var value1, value2 uint64
+	gob.NewDecoder(bytes.NewReader(measurements[0].Value)).Decode(&value1)
+	gob.NewDecoder(bytes.NewReader(measurements[1].Value)).Decode(&value2)
+	seconds := measurements[0].Timestamp.Sub(measurements[1].Timestamp).Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(value1-value2) / seconds
+}
+
+// benchMetricNames returns the counter names of a VM
+func benchMetricNames() []string {
+	names := make([]string, benchCounters)
+	for i := range names {
+		names[i] = fmt.Sprintf("bench_counter_%d", i)
+	}
+	return names
+}
+
+// BenchmarkCycle500VMs measures one collection cycle of a 500-VM host: every counter of every VM is
+// measured, then its rate and raw value are read for printing. gob is the former gob-encoded path,
+// typed the typed measurements.
+func BenchmarkCycle500VMs(b *testing.B) {
+	names := benchMetricNames()
+
+	b.Run("gob", func(b *testing.B) {
+		b.ReportAllocs()
+		domains := make([]map[string][]gobMeasurement, benchVMs)
+		for i := range domains {
+			domains[i] = make(map[string][]gobMeasurement)
+			for _, name := range names {
+				domains[i][name] = []gobMeasurement{createGobMeasurement(uint64(0))}
+			}
+		}
+		b.ResetTimer()
+		for cycle := 0; cycle < b.N; cycle++ {
+			for _, metrics := range domains {
+				for j, name := range names {
+					// newest first, two measurements kept like the former metrics
+					previous := metrics[name][0]
+					metrics[name] = []gobMeasurement{createGobMeasurement(uint64(cycle*j + cycle)), previous}
+				}
+			}
+			for _, metrics := range domains {
+				for _, name := range names {
+					var raw uint64
+					gobDiff(metrics[name])
+					gob.NewDecoder(bytes.NewReader(metrics[name][0].Value)).Decode(&raw)
+				}
+			}
+		}
+	})
+
+	b.Run("typed", func(b *testing.B) {
+		b.ReportAllocs()
+		domains := make([]*Measurable, benchVMs)
+		for i := range domains {
+			domains[i] = NewMeasurable()
+			for _, name := range names {
+				domains[i].AddMetricMeasurement(name, CreateMeasurement(uint64(0)))
+			}
+		}
+		b.ResetTimer()
+		for cycle := 0; cycle < b.N; cycle++ {
+			for _, measurable := range domains {
+				for j, name := range names {
+					measurable.AddMetricMeasurement(name, CreateMeasurement(uint64(cycle*j+cycle)))
+				}
+			}
+			for _, measurable := range domains {
+				for _, name := range names {
+					measurable.GetMetricDiffUint64AsFloat(name, true)
+					measurable.GetMetricUint64Raw(name, 0)
+				}
+			}
+		}
+	})
+}
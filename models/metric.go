@@ -1,7 +1,55 @@
 package models
 
-// Metric contains a monitoring metric value with current and previous
+// Metric contains the recent measurements of a monitoring metric in a ring buffer
 type Metric struct {
-	Name   string
-	Values []Measurement
+	Name string
+	// ring holds the measurements, head is the index of the newest one
+	ring  []Measurement
+	head  int
+	count int
+}
+
+// newMetric creates a metric keeping up to depth measurements
+func newMetric(name string, depth int) Metric {
+	return Metric{
+		Name: name,
+		ring: make([]Measurement, depth),
+		head: -1,
+	}
+}
+
+// add stores a measurement, overwriting the oldest one when the ring is full
+func (metric *Metric) add(measurement Measurement) {
+	metric.head = (metric.head + 1) % len(metric.ring)
+	metric.ring[metric.head] = measurement
+	if metric.count < len(metric.ring) {
+		metric.count++
+	}
+}
+
+// Len returns the number of measurements kept
+func (metric *Metric) Len() int {
+	return metric.count
+}
+
+// At returns the measurement at index, 0 is the newest
+func (metric *Metric) At(index int) Measurement {
+	return metric.ring[(metric.head-index+len(metric.ring))%len(metric.ring)]
+}
+
+// Values returns the measurements from newest to oldest
+func (metric *Metric) Values() []Measurement {
+	values := make([]Measurement, metric.count)
+	for i := range values {
+		values[i] = metric.At(i)
+	}
+	return values
+}
+
+// copy returns a metric with its own ring, which is not changed by later measurements
+func (metric *Metric) copy() Metric {
+	copied := *metric
+	copied.ring = make([]Measurement, len(metric.ring))
+	copy(copied.ring, metric.ring)
+	return copied
 }
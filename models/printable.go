@@ -1,9 +1,145 @@
 package models
 
-// Printable represents a set of fields and values to be printed
+import (
+	"encoding/json"
+	"time"
+
+	"proxtop/config"
+)
+
+// Names of the device tables of the device views
+const (
+	DevicesNet     = "net"     // physical network interfaces
+	DevicesDisk    = "disk"    // physical disks
+	DevicesLVM     = "lvm"     // LVM logical volumes
+	DevicesMpath   = "mpath"   // multipath devices
+	DevicesIRQ     = "irq"     // interrupts per CPU
+	DevicesCores   = "cores"   // CPU cores
+	DevicesVFIO    = "vfio"    // passed through PCI functions
+	DevicesMigrate = "migrate" // live migrations
+	DevicesJobs    = "jobs"    // backup and block jobs
+	// hypervisor overhead and guest totals, and the processes outside of VMs
+	DevicesOverheadSummary = "overhead-summary"
+	DevicesOverhead        = "overhead"
+)
+
+// Names of the per VM device tables
+const (
+	DomainDevicesNet  = "net"  // interfaces of the VM, network collector
+	DomainDevicesTC   = "tc"   // interfaces of the VM, tc collector
+	DomainDevicesDisk = "disk" // virtual disks of the VM
+	DomainDevicesVCPU = "vcpu" // vCPU threads of the VM
+)
+
+// DeviceTable is a table with one row of values per device, in the order of Fields
+type DeviceTable struct {
+	Fields []string
+	Rows   map[string][]string
+}
+
+// Printable represents a set of fields and values to be printed. The merged printable of all
+// collectors is the snapshot of one collection cycle, printers must not modify it.
 type Printable struct {
+	// Timestamp is the time the collection cycle started
+	Timestamp    time.Time
 	HostFields   []string
 	DomainFields []string
 	HostValues   []string
 	DomainValues map[string][]string
+	// Devices holds the tables of the device views by name (DevicesNet, ...)
+	Devices map[string]DeviceTable
+	// DomainDevices holds the device tables of each VM by UUID and name (DomainDevicesNet, ...)
+	DomainDevices map[string]map[string]DeviceTable
+	// StaleDomains holds the UUIDs of the VMs skipped by their circuit breaker, their values are from an earlier cycle
+	StaleDomains map[string]bool
+	// StaleCollectors lists the collectors which did not finish in time, their values are from an earlier cycle
+	StaleCollectors []string
+	// Diagnostics are the metrics of proxtop itself at the time of the snapshot
+	Diagnostics Diagnostics
+	// Counters holds the raw values of the counters declared with RegisterCounters, for exporters
+	Counters []CounterSample
+	// VMIDs holds the Proxmox VMID of each VM by UUID, empty without Proxmox
+	VMIDs map[string]string
+	// HumanReadable is whether the sizes were formatted human readable, see Unit.Format
+	HumanReadable bool
+	// Events holds the events recorded during the cycle as JSON objects, e.g. migration summaries
+	Events []json.RawMessage
+}
+
+// AddDevices adds a device table
+func (printable *Printable) AddDevices(name string, fields []string, rows map[string][]string) {
+	if printable.Devices == nil {
+		printable.Devices = make(map[string]DeviceTable)
+	}
+	printable.Devices[name] = DeviceTable{Fields: fields, Rows: rows}
+}
+
+// AddDomainDevices adds a device table of a VM
+func (printable *Printable) AddDomainDevices(uuid string, name string, fields []string, rows map[string][]string) {
+	if printable.DomainDevices == nil {
+		printable.DomainDevices = make(map[string]map[string]DeviceTable)
+	}
+	if printable.DomainDevices[uuid] == nil {
+		printable.DomainDevices[uuid] = make(map[string]DeviceTable)
+	}
+	printable.DomainDevices[uuid][name] = DeviceTable{Fields: fields, Rows: rows}
+}
+
+// Reformatted returns the snapshot with the sizes formatted for the current --human-readable setting,
+// e.g. when the units are toggled between two cycles. Sizes formatted human readable are rounded,
+// so the values are only exact again in the snapshot of the next cycle.
+func (printable Printable) Reformatted() Printable {
+	if printable.HumanReadable == config.Options.HumanReadable {
+		return printable
+	}
+	reformatted := printable
+	reformatted.HumanReadable = config.Options.HumanReadable
+	reformatted.HostValues = reformatValues(printable.HostFields, printable.HostValues, printable.HumanReadable, ScopeFields(ScopeHost))
+	reformatted.DomainValues = make(map[string][]string, len(printable.DomainValues))
+	for uuid, values := range printable.DomainValues {
+		reformatted.DomainValues[uuid] = reformatValues(printable.DomainFields, values, printable.HumanReadable, ScopeFields(ScopeDomain))
+	}
+	reformatted.Devices = make(map[string]DeviceTable, len(printable.Devices))
+	for name, table := range printable.Devices {
+		reformatted.Devices[name] = reformatTable(table, printable.HumanReadable, DeviceFields(name))
+	}
+	reformatted.DomainDevices = make(map[string]map[string]DeviceTable, len(printable.DomainDevices))
+	for uuid, tables := range printable.DomainDevices {
+		reformatted.DomainDevices[uuid] = make(map[string]DeviceTable, len(tables))
+		for name, table := range tables {
+			reformatted.DomainDevices[uuid][name] = reformatTable(table, printable.HumanReadable, DomainDeviceFields(name))
+		}
+	}
+	return reformatted
+}
+
+// reformatTable returns a copy of a device table with the sizes formatted for the current setting
+func reformatTable(table DeviceTable, humanReadable bool, lookup FieldLookup) DeviceTable {
+	rows := make(map[string][]string, len(table.Rows))
+	for device, values := range table.Rows {
+		rows[device] = reformatValues(table.Fields, values, humanReadable, lookup)
+	}
+	return DeviceTable{Fields: table.Fields, Rows: rows}
+}
+
+// reformatValues returns a copy of the values printed with or without humanReadable with the sizes formatted for
+// the current setting, the fields are declared in lookup. Values which are no number, like the reset marker, are kept.
+func reformatValues(fields []string, values []string, humanReadable bool, lookup FieldLookup) []string {
+	reformatted := make([]string, len(values))
+	copy(reformatted, values)
+	for i, field := range fields {
+		if i >= len(values) {
+			break
+		}
+		def, exists := lookup(field)
+		if !exists || (def.Unit != UnitBytes && def.Unit != UnitBytesPerSecond && def.Unit != UnitKibibytes) {
+			continue
+		}
+		number, err := def.Unit.parse(values[i], humanReadable)
+		if err != nil || number < 0 {
+			continue
+		}
+		reformatted[i] = def.Unit.Format(uint64(number + 0.5))
+	}
+	return reformatted
 }
@@ -2,7 +2,7 @@ package models
 
 // Printer defines a printer for output
 type Printer interface {
-	Open()
+	Open() error
 	Screen(Printable)
 	Close()
 }
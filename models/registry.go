@@ -0,0 +1,267 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"proxtop/config"
+	"proxtop/util"
+)
+
+// Kind describes how the value of a field behaves over time
+type Kind string
+
+// Kinds of fields
+const (
+	// KindGauge is a value sampled at collection time, e.g. RSS or queue depth
+	KindGauge Kind = "gauge"
+	// KindCounter is a monotonically increasing total, e.g. sectors read since boot
+	KindCounter Kind = "counter"
+	// KindRate is the per second (or per interval share) increase of a counter, e.g. READS/s or %RDY
+	KindRate Kind = "rate"
+	// KindInfo is a descriptive value like a name, state or device list
+	KindInfo Kind = "info"
+)
+
+// Scope describes which rows a field is printed for
+type Scope string
+
+// Scopes of fields
+const (
+	// ScopeHost fields are printed once for the hypervisor
+	ScopeHost Scope = "host"
+	// ScopeDomain fields are printed per VM
+	ScopeDomain Scope = "domain"
+	// ScopeDevice fields are printed per row of a device view: disks, interfaces, CPUs, jobs, processes
+	ScopeDevice Scope = "device"
+)
+
+// Unit describes the unit of a field value
+type Unit string
+
+// Units of fields, UnitNone for names, states and plain numbers
+const (
+	UnitNone           Unit = ""
+	UnitPercent        Unit = "%"
+	UnitBytes          Unit = "B"
+	UnitKibibytes      Unit = "KiB"
+	UnitBytesPerSecond Unit = "B/s"
+	UnitMBPerSecond    Unit = "MB/s"
+	UnitMbitPerSecond  Unit = "Mbit/s"
+	UnitPerSecond      Unit = "1/s"
+	UnitMicroseconds   Unit = "us"
+	UnitMilliseconds   Unit = "ms"
+	UnitSeconds        Unit = "s"
+	UnitMegahertz      Unit = "MHz"
+	UnitWatts          Unit = "W"
+	UnitCelsius        Unit = "C"
+	UnitRPM            Unit = "rpm"
+)
+
+// Format formats a value of the unit, sizes in human readable format if enabled
+func (unit Unit) Format(value uint64) string {
+	if config.Options.HumanReadable {
+		switch unit {
+		case UnitBytes, UnitBytesPerSecond:
+			return util.FormatBytes(value)
+		case UnitKibibytes:
+			return util.FormatBytes(value * 1024)
+		}
+	}
+	return fmt.Sprintf("%d", value)
+}
+
+// Parse parses a printed value of the unit, sizes also in human readable format
+func (unit Unit) Parse(value string) (float64, error) {
+	return unit.parse(value, config.Options.HumanReadable)
+}
+
+// parse parses a value of the unit printed with or without --human-readable
+func (unit Unit) parse(value string, humanReadable bool) (float64, error) {
+	switch unit {
+	case UnitBytes, UnitBytesPerSecond:
+		return util.ParseBytes(value)
+	case UnitKibibytes:
+		if humanReadable {
+			bytes, err := util.ParseBytes(value)
+			return bytes / 1024, err
+		}
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// FieldDef declares a printed field of a collector
+type FieldDef struct {
+	Name        string
+	Collector   string
+	Scope       Scope
+	Kind        Kind
+	Unit        Unit
+	Description string
+	// Verbose fields are only printed with --verbose and hidden by default in the field selector
+	Verbose bool
+	// Views are the device views a ScopeDevice field is printed in (DevicesNet, ..., DomainDeviceView)
+	Views []string
+}
+
+var registryMu sync.RWMutex
+
+// registry holds the declared fields in declaration order, registryIndex maps scope, view and name to their position
+var registry []FieldDef
+var registryIndex = make(map[string]int)
+
+// registryKey returns the index key of a field, names are unique per scope and device view only
+// (e.g. cpu_%sys of host and VMs, net_MbRX/s of the physical interfaces and of the VMs)
+func registryKey(scope Scope, view string, name string) string {
+	return string(scope) + "/" + view + "/" + name
+}
+
+// DomainDeviceView returns the view name of a device table of the VMs (DomainDevicesNet, ...) for the
+// registry, so its fields do not clash with the host device view of the same name
+func DomainDeviceView(table string) string {
+	return "domain-" + table
+}
+
+// register adds or replaces a declaration under all its keys, the lock must be held
+func register(def FieldDef) {
+	keys := []string{registryKey(def.Scope, "", def.Name)}
+	if def.Scope == ScopeDevice {
+		keys = keys[:0]
+		for _, view := range def.Views {
+			keys = append(keys, registryKey(def.Scope, view, def.Name))
+		}
+	}
+	for _, key := range keys {
+		if index, exists := registryIndex[key]; exists {
+			registry[index] = def
+			for _, other := range keys {
+				registryIndex[other] = index
+			}
+			return
+		}
+	}
+	for _, key := range keys {
+		registryIndex[key] = len(registry)
+	}
+	registry = append(registry, def)
+}
+
+// RegisterFields declares the host or VM fields a collector prints for scope, in the order of its printed values.
+// A field declared again replaces the former declaration.
+func RegisterFields(collector string, scope Scope, defs []FieldDef) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, def := range defs {
+		def.Collector = collector
+		def.Scope = scope
+		register(def)
+	}
+}
+
+// RegisterDeviceFields declares the fields a collector prints in the rows of device views, in the order of
+// its printed values. A field declared again for one of the views replaces the former declaration.
+func RegisterDeviceFields(collector string, views []string, defs []FieldDef) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, def := range defs {
+		def.Collector = collector
+		def.Scope = ScopeDevice
+		def.Views = views
+		register(def)
+	}
+}
+
+// LookupField returns the declaration of a host or VM field
+func LookupField(scope Scope, name string) (FieldDef, bool) {
+	return lookupField(registryKey(scope, "", name))
+}
+
+// LookupDeviceField returns the declaration of a field of a device view
+func LookupDeviceField(view string, name string) (FieldDef, bool) {
+	return lookupField(registryKey(ScopeDevice, view, name))
+}
+
+// lookupField returns the declaration indexed by key
+func lookupField(key string) (FieldDef, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	index, exists := registryIndex[key]
+	if !exists {
+		return FieldDef{}, false
+	}
+	return registry[index], true
+}
+
+// FieldLookup returns the declaration of a field of a table
+type FieldLookup func(name string) (FieldDef, bool)
+
+// ScopeFields returns the lookup of the host or VM fields
+func ScopeFields(scope Scope) FieldLookup {
+	return func(name string) (FieldDef, bool) {
+		return LookupField(scope, name)
+	}
+}
+
+// DeviceFields returns the lookup of the fields of a device view of the host
+func DeviceFields(view string) FieldLookup {
+	return func(name string) (FieldDef, bool) {
+		return LookupDeviceField(view, name)
+	}
+}
+
+// DomainDeviceFields returns the lookup of the fields of a device table of the VMs: the device fields of
+// the table, then the VM fields, which are mostly printed per device as well
+func DomainDeviceFields(table string) FieldLookup {
+	return func(name string) (FieldDef, bool) {
+		if def, exists := LookupDeviceField(DomainDeviceView(table), name); exists {
+			return def, true
+		}
+		return LookupField(ScopeDomain, name)
+	}
+}
+
+// RegisteredFields returns all declared fields in declaration order
+func RegisteredFields() []FieldDef {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	defs := make([]FieldDef, len(registry))
+	copy(defs, registry)
+	return defs
+}
+
+// DeviceFieldNames returns the names of the fields printed in a device view, verbose ones only if verbose
+func DeviceFieldNames(view string, verbose bool) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := []string{}
+	for _, def := range registry {
+		if def.Scope == ScopeDevice && def.inView(view) && (verbose || !def.Verbose) {
+			names = append(names, def.Name)
+		}
+	}
+	return names
+}
+
+// inView returns true if the device field is printed in view
+func (def FieldDef) inView(view string) bool {
+	for _, defView := range def.Views {
+		if defView == view {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldNames returns the names of the host or VM fields a collector prints for scope, verbose ones only if verbose
+func FieldNames(collector string, scope Scope, verbose bool) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := []string{}
+	for _, def := range registry {
+		if def.Collector == collector && def.Scope == scope && (verbose || !def.Verbose) {
+			names = append(names, def.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,43 @@
+package models
+
+import "testing"
+
+func TestDeviceFieldsByView(t *testing.T) {
+	RegisterFields("test-view", ScopeDomain, []FieldDef{{Name: "t_rx", Description: "VM"}, {Name: "t_state", Kind: KindInfo}})
+	RegisterDeviceFields("test-view", []string{"t-phys", "t-lvm"}, []FieldDef{{Name: "t_rx", Description: "physical"}, {Name: "t_dev", Verbose: true}})
+	RegisterDeviceFields("test-view", []string{DomainDeviceView("t-vcpu")}, []FieldDef{{Name: "t_rx", Description: "vCPU"}})
+
+	tests := []struct {
+		lookup FieldLookup
+		name   string
+		want   string
+		exists bool
+	}{
+		{ScopeFields(ScopeDomain), "t_rx", "VM", true},
+		{DeviceFields("t-phys"), "t_rx", "physical", true},
+		{DeviceFields("t-lvm"), "t_rx", "physical", true},
+		{DeviceFields("t-other"), "t_rx", "", false},
+		{ScopeFields(ScopeDevice), "t_rx", "", false},
+		// the device tables of VMs fall back to the VM fields
+		{DomainDeviceFields("t-vcpu"), "t_rx", "vCPU", true},
+		{DomainDeviceFields("t-disk"), "t_rx", "VM", true},
+		{DomainDeviceFields("t-phys"), "t_rx", "VM", true},
+		{DomainDeviceFields("t-disk"), "t_dev", "", false},
+	}
+	for i, test := range tests {
+		def, exists := test.lookup(test.name)
+		if exists != test.exists || def.Description != test.want {
+			t.Errorf("%d: lookup(%q) = %q, %v, want %q, %v", i, test.name, def.Description, exists, test.want, test.exists)
+		}
+	}
+
+	if names := DeviceFieldNames("t-lvm", false); len(names) != 1 || names[0] != "t_rx" {
+		t.Errorf("DeviceFieldNames(t-lvm, false) = %v, want [t_rx]", names)
+	}
+	if names := DeviceFieldNames("t-phys", true); len(names) != 2 {
+		t.Errorf("DeviceFieldNames(t-phys, true) = %v, want [t_rx t_dev]", names)
+	}
+	if names := FieldNames("test-view", ScopeDomain, true); len(names) != 2 {
+		t.Errorf("FieldNames(test-view, domain) = %v, want [t_rx t_state]", names)
+	}
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"proxtop/config"
+)
+
+// SchemaVersion is the version of the record layout of the json printer, increased on incompatible changes
+const SchemaVersion = 2
+
+// schemaProperty describes a field in the JSON schema
+type schemaProperty struct {
+	Type        []string `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Unit        Unit     `json:"x-unit,omitempty"`
+	Kind        Kind     `json:"x-kind"`
+	Collector   string   `json:"x-collector"`
+	Verbose     bool     `json:"x-verbose,omitempty"`
+}
+
+// schemaObject describes an object of fields in the JSON schema
+type schemaObject struct {
+	Type       string                    `json:"type"`
+	Properties map[string]schemaProperty `json:"properties"`
+}
+
+// machineNameReplacer replaces the characters of field names which are awkward in queries and column names
+var machineNameReplacer = strings.NewReplacer("%", "pct_", "/s", "_per_s", "/", "_", "-", "_", ".", "_", " ", "_")
+
+// MachineName returns the field name for machines: lower case, % as pct_ and /s as _per_s,
+// e.g. cpu_%used becomes cpu_pct_used and dsk_READS/s dsk_reads_per_s
+func MachineName(name string) string {
+	return strings.ToLower(machineNameReplacer.Replace(name))
+}
+
+// JSONFieldName returns the name of a field in the json printer output, with --json-machine-names its MachineName
+func JSONFieldName(name string) string {
+	if config.Options.JSONMachineNames {
+		return MachineName(name)
+	}
+	return name
+}
+
+// JSONValue returns the typed value of a printed field for the json printer: a string for info fields,
+// a number for the others, nil for values which are no number (e.g. reset markers). The field is declared
+// in lookup, e.g. DomainDeviceFields for the per device rows of VMs.
+func JSONValue(lookup FieldLookup, field string, value string) interface{} {
+	def, exists := lookup(field)
+	if exists && def.Kind == KindInfo {
+		return value
+	}
+	number, err := def.Unit.Parse(value)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		// fields of no collector, e.g. of plugins, may be texts
+		if !exists && value != ResetMarker && value != "" && value != "-" {
+			return value
+		}
+		return nil
+	}
+	return number
+}
+
+// hostDeviceViews are the device views of the host in the json printer output
+var hostDeviceViews = []string{
+	DevicesNet, DevicesDisk, DevicesLVM, DevicesMpath, DevicesIRQ, DevicesCores, DevicesVFIO, DevicesMigrate, DevicesJobs,
+	DevicesOverheadSummary, DevicesOverhead,
+}
+
+// inScope selects the host or VM fields of scope
+func inScope(scope Scope) func(def FieldDef) bool {
+	return func(def FieldDef) bool {
+		return def.Scope == scope
+	}
+}
+
+// inView selects the fields of a device view
+func inView(view string) func(def FieldDef) bool {
+	return func(def FieldDef) bool {
+		return def.Scope == ScopeDevice && def.inView(view)
+	}
+}
+
+// schemaProperties returns the properties of the declared fields selected by the selectors, the first declaration wins
+func schemaProperties(selectors ...func(def FieldDef) bool) map[string]schemaProperty {
+	properties := make(map[string]schemaProperty)
+	for _, selected := range selectors {
+		for _, def := range RegisteredFields() {
+			name := JSONFieldName(def.Name)
+			if _, exists := properties[name]; exists || !selected(def) {
+				continue
+			}
+			// info fields are strings, the others numbers or null for values across counter resets
+			types := []string{"number", "null"}
+			if def.Kind == KindInfo {
+				types = []string{"string"}
+			}
+			properties[name] = schemaProperty{
+				Type:        types,
+				Description: def.Description,
+				Unit:        def.Unit,
+				Kind:        def.Kind,
+				Collector:   def.Collector,
+				Verbose:     def.Verbose,
+			}
+		}
+	}
+	return properties
+}
+
+// deviceSchema describes the rows of a device table with the fields of properties
+func deviceSchema(properties map[string]schemaProperty) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"device", "fields"},
+		"properties": map[string]interface{}{
+			"device": map[string]string{"type": "string", "description": "Device name, e.g. interface, disk or vCPU index"},
+			"fields": schemaObject{Type: "object", Properties: properties},
+		},
+	}
+}
+
+// FieldSchema returns a JSON schema of the records of the json printer, built from the metric registry.
+// The rows of each device view are described by definition "device-<view>", the devices of VMs by
+// "domain-disk", "domain-net" and "domain-vcpu".
+func FieldSchema() ([]byte, error) {
+	devices := func(definition string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":  "array",
+			"items": map[string]string{"$ref": "#/$defs/" + definition},
+		}
+	}
+	definitions := map[string]interface{}{
+		DomainDeviceView(DomainDevicesDisk): deviceSchema(schemaProperties(inView(DomainDeviceView(DomainDevicesDisk)), inScope(ScopeDomain))),
+		DomainDeviceView(DomainDevicesNet): deviceSchema(schemaProperties(inView(DomainDeviceView(DomainDevicesNet)),
+			inView(DomainDeviceView(DomainDevicesTC)), inScope(ScopeDomain))),
+		DomainDeviceView(DomainDevicesVCPU): deviceSchema(schemaProperties(inView(DomainDeviceView(DomainDevicesVCPU)), inScope(ScopeDomain))),
+	}
+	views := make(map[string]interface{}, len(hostDeviceViews))
+	for _, view := range hostDeviceViews {
+		definitions["device-"+view] = deviceSchema(schemaProperties(inView(view)))
+		views[view] = devices("device-" + view)
+	}
+	schema := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "proxtop",
+		"description": "One record (line) of the json printer per collection cycle, events of the cycle precede it as lines with an event key",
+		"type":        "object",
+		"required":    []string{"version", "timestamp", "host", "domains"},
+		"properties": map[string]interface{}{
+			"version":   map[string]interface{}{"const": SchemaVersion, "description": "Version of the record layout"},
+			"timestamp": map[string]string{"type": "string", "format": "date-time", "description": "Start of the collection cycle"},
+			"host": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name":   map[string]string{"type": "string", "description": "Host name of the hypervisor"},
+					"fields": schemaObject{Type: "object", Properties: schemaProperties(inScope(ScopeHost))},
+					"devices": map[string]interface{}{
+						"type":        "object",
+						"description": "Rows of the device views by view: " + strings.Join(hostDeviceViews, ", "),
+						"properties":  views,
+					},
+				},
+			},
+			"domains": map[string]interface{}{
+				"type":        "array",
+				"description": "VMs sorted by UUID",
+				"items": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"uuid", "name", "fields"},
+					"properties": map[string]interface{}{
+						"uuid":   map[string]string{"type": "string"},
+						"vmid":   map[string]string{"type": "integer", "description": "Proxmox VMID, missing without Proxmox"},
+						"name":   map[string]string{"type": "string"},
+						"stale":  map[string]string{"type": "boolean", "description": "Values are from an earlier cycle, the circuit breaker of the VM is open"},
+						"fields": schemaObject{Type: "object", Properties: schemaProperties(inScope(ScopeDomain))},
+						"disks":  devices(DomainDeviceView(DomainDevicesDisk)),
+						"nics":   devices(DomainDeviceView(DomainDevicesNet)),
+						"vcpus":  devices(DomainDeviceView(DomainDevicesVCPU)),
+					},
+				},
+			},
+			"stale": map[string]interface{}{
+				"type":        "array",
+				"description": "Collectors which did not finish in time, lookup for the VM discovery",
+				"items":       map[string]string{"type": "string"},
+			},
+			"proxtop": map[string]string{"type": "object", "description": "Diagnostics of proxtop itself"},
+		},
+		"$defs": definitions,
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
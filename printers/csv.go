@@ -0,0 +1,250 @@
+package printers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// CSVPrinter prints one wide row per snapshot with the host fields and the fields of every VM.
+// A file has one header row and a fixed set of columns: columns of VMs which are gone stay empty,
+// a VM appearing later starts a new file with its columns added at the end (output 'file'),
+// or is left out for other outputs.
+type CSVPrinter struct {
+	models.Printer
+}
+
+// csvColumn is a column of the CSV output, key identifies the host or VM and the field
+type csvColumn struct {
+	key    string
+	header string
+}
+
+var csvColumns []csvColumn
+var csvColumnIndex = make(map[string]int)
+
+// csvNewColumns are the columns of the current snapshot not in the header yet, csvLeftOut the columns
+// left out because the header was written already
+var csvNewColumns []csvColumn
+var csvLeftOut = make(map[string]bool)
+
+// csvFileNumber is the number of the current output file, counted from 1 for the target
+var csvFileNumber = 1
+
+// csvInstances holds the instance name of each VM by UUID, fixed when the VM first appeared
+var csvInstances = make(map[string]string)
+var csvHost string
+var csvHeaderPending bool
+
+// csvGroups are the titles of the collectors in the esxtop format, "Physical Cpu" for the host and "Group Cpu" for VMs
+var csvGroups = map[string]string{
+	"cpu":       "Cpu",
+	"mem":       "Memory",
+	"disk":      "Disk",
+	"net":       "Network",
+	"io":        "IO",
+	"psi":       "Pressure",
+	"tc":        "Traffic Control",
+	"irq":       "Interrupts",
+	"vfio":      "VFIO",
+	"power":     "Power",
+	"host":      "Host",
+	"dirtyrate": "Dirty Rate",
+	"migrate":   "Migration",
+	"jobs":      "Jobs",
+	"overhead":  "Overhead",
+	"plugin":    "Plugin",
+	"derived":   "Derived",
+}
+
+// Open opens the output
+func (printer *CSVPrinter) Open() error {
+	if config.Options.CSVFormat != "plain" && config.Options.CSVFormat != "esxtop" {
+		fmt.Fprintf(os.Stderr, "invalid csv format %s (valid formats: plain, esxtop)\n", config.Options.CSVFormat)
+		os.Exit(1)
+	}
+	csvHost, _ = os.Hostname()
+	OutputOpen()
+	// a file of an earlier run has its own header and columns
+	if info, err := os.Stat(config.Options.OutputTarget); config.Options.Output == "file" && err == nil && info.Size() > 0 {
+		OutputReopen(csvNextFile())
+	}
+	return nil
+}
+
+// Screen prints the row of a snapshot, preceded by the header row at the start of a file
+func (printer *CSVPrinter) Screen(printable models.Printable) {
+	values := make(map[string]string)
+
+	for i, field := range printable.HostFields {
+		if i < len(printable.HostValues) {
+			addCSVColumn("host/"+field, csvHeader(models.ScopeHost, "", field))
+			values["host/"+field] = printable.HostValues[i]
+		}
+	}
+
+	// new VMs sorted by UUID, so VMs appearing in the same snapshot get their columns in a stable order
+	uuids := make([]string, 0, len(printable.DomainValues))
+	for uuid := range printable.DomainValues {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	for _, uuid := range uuids {
+		domainValues := printable.DomainValues[uuid]
+		if _, exists := csvInstances[uuid]; !exists {
+			csvInstances[uuid] = csvInstance(printable, uuid)
+		}
+		for i, field := range printable.DomainFields {
+			if i >= len(domainValues) || field == "UUID" || field == "name" {
+				continue
+			}
+			key := uuid + "/" + field
+			addCSVColumn(key, csvHeader(models.ScopeDomain, csvInstances[uuid], field))
+			values[key] = domainValues[i]
+		}
+	}
+
+	if len(csvNewColumns) > 0 {
+		addCSVColumns()
+	}
+
+	if csvHeaderPending {
+		header := []string{"timestamp"}
+		if config.Options.CSVFormat == "esxtop" {
+			header[0] = "(PDH-CSV 4.0) (UTC)(0)"
+		}
+		for _, column := range csvColumns {
+			header = append(header, column.header)
+		}
+		Output(csvRow(header))
+		csvHeaderPending = false
+	}
+
+	row := []string{printable.Timestamp.Format(time.RFC3339)}
+	if config.Options.CSVFormat == "esxtop" {
+		row[0] = printable.Timestamp.UTC().Format("01/02/2006 15:04:05")
+	}
+	for _, column := range csvColumns {
+		row = append(row, values[column.key])
+	}
+	Output(csvRow(row))
+}
+
+// Close closes the output
+func (printer *CSVPrinter) Close() {
+	OutputClose()
+}
+
+// CreateCSV creates a new csv printer
+func CreateCSV() CSVPrinter {
+	return CSVPrinter{}
+}
+
+// addCSVColumn notes a column of the snapshot not in the header yet
+func addCSVColumn(key string, header string) {
+	if _, exists := csvColumnIndex[key]; exists || csvLeftOut[key] {
+		return
+	}
+	csvNewColumns = append(csvNewColumns, csvColumn{key: key, header: header})
+}
+
+// addCSVColumns appends the new columns of the snapshot to the header. Once the header is written, they
+// start a new file with output 'file', other outputs leave them out so the rows keep their width.
+func addCSVColumns() {
+	defer func() { csvNewColumns = nil }()
+	if len(csvColumns) > 0 && !csvHeaderPending {
+		if config.Options.Output != "file" {
+			log.Printf("csv: %d columns of new VMs left out, the header was written already (output 'file' starts a new file)", len(csvNewColumns))
+			for _, column := range csvNewColumns {
+				csvLeftOut[column.key] = true
+			}
+			return
+		}
+		OutputReopen(csvNextFile())
+	}
+	for _, column := range csvNewColumns {
+		csvColumnIndex[column.key] = len(csvColumns)
+		csvColumns = append(csvColumns, column)
+	}
+	csvHeaderPending = true
+}
+
+// csvNextFile returns the next file name of the output target not taken yet, e.g. proxtop-2.csv for proxtop.csv
+func csvNextFile() string {
+	extension := filepath.Ext(config.Options.OutputTarget)
+	base := strings.TrimSuffix(config.Options.OutputTarget, extension)
+	for {
+		csvFileNumber++
+		name := fmt.Sprintf("%s-%d%s", base, csvFileNumber, extension)
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return name
+		}
+	}
+}
+
+// csvInstance returns the instance name of a VM, VMID:name like the group IDs of esxtop, UUID:name without Proxmox
+func csvInstance(printable models.Printable, uuid string) string {
+	id := printable.VMIDs[uuid]
+	if id == "" {
+		id = uuid
+	}
+	name := ""
+	if values := printable.DomainValues[uuid]; len(values) > 1 {
+		name = values[1]
+	}
+	return id + ":" + name
+}
+
+// csvHeader returns the header of a column: the field name (instance/field for VMs), or in the esxtop
+// format the counter path \\host\Group(instance)\Counter with the field name without its prefix as counter
+func csvHeader(scope models.Scope, instance string, field string) string {
+	if config.Options.CSVFormat != "esxtop" {
+		if instance == "" {
+			return field
+		}
+		return instance + "/" + field
+	}
+
+	group := "Proxtop"
+	if def, exists := models.LookupField(scope, field); exists {
+		group = csvGroups[def.Collector]
+		if group == "" {
+			group = strings.Title(def.Collector)
+		}
+	}
+	counter := field
+	if index := strings.Index(field, "_"); index >= 0 && index < len(field)-1 {
+		counter = field[index+1:]
+	}
+	// backslashes and parentheses would break the counter path
+	sanitize := strings.NewReplacer(`\`, "_", "(", "[", ")", "]")
+	if instance == "" {
+		return fmt.Sprintf(`\\%s\Physical %s\%s`, csvHost, group, sanitize.Replace(counter))
+	}
+	return fmt.Sprintf(`\\%s\Group %s(%s)\%s`, csvHost, group, sanitize.Replace(instance), sanitize.Replace(counter))
+}
+
+// csvRow formats a row, every value quoted in the esxtop format like esxtop -b, quoted where needed otherwise
+func csvRow(values []string) string {
+	if config.Options.CSVFormat == "esxtop" {
+		quoted := make([]string, len(values))
+		for i, value := range values {
+			quoted[i] = "\"" + strings.Replace(value, "\"", "\"\"", -1) + "\""
+		}
+		return strings.Join(quoted, ",") + "\r\n"
+	}
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write(values)
+	writer.Flush()
+	return buffer.String()
+}
@@ -0,0 +1,128 @@
+package printers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// withCSV resets the columns of the csv printer and configures it, the returned function restores the options
+func withCSV(format string, output string, target string) func() {
+	saved := config.Options
+	config.Options.CSVFormat = format
+	config.Options.Output = output
+	config.Options.OutputTarget = target
+	csvColumns = nil
+	csvColumnIndex = make(map[string]int)
+	csvNewColumns = nil
+	csvLeftOut = make(map[string]bool)
+	csvFileNumber = 1
+	csvInstances = make(map[string]string)
+	csvHeaderPending = false
+	return func() { config.Options = saved }
+}
+
+// csvSnapshot returns a snapshot with the host field t_load and the VM field t_iops of the VMs
+func csvSnapshot(vms ...string) models.Printable {
+	printable := models.Printable{
+		Timestamp:    time.Date(2026, 3, 2, 10, 15, 0, 0, time.UTC),
+		HostFields:   []string{"t_load"},
+		HostValues:   []string{"1.5"},
+		DomainFields: []string{"UUID", "name", "t_iops"},
+		DomainValues: make(map[string][]string),
+		VMIDs:        make(map[string]string),
+	}
+	for i, vmid := range vms {
+		uuid := "uuid-" + vmid
+		printable.DomainValues[uuid] = []string{uuid, "vm" + vmid, strings.Repeat("1", i+1)}
+		printable.VMIDs[uuid] = vmid
+	}
+	return printable
+}
+
+func TestCSVColumns(t *testing.T) {
+	directory, err := ioutil.TempDir("", "proxtop-csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	target := filepath.Join(directory, "proxtop.csv")
+	defer withCSV("plain", "file", target)()
+
+	printer := CreateCSV()
+	if err := printer.Open(); err != nil {
+		t.Fatal(err)
+	}
+	printer.Screen(csvSnapshot("101"))
+	// a VM appearing later starts a new file, the columns of a VM which is gone stay empty
+	printer.Screen(csvSnapshot("101", "102"))
+	printer.Screen(csvSnapshot("102"))
+	printer.Close()
+
+	files := map[string]string{
+		"proxtop.csv": "timestamp,t_load,101:vm101/t_iops\n" +
+			"2026-03-02T10:15:00Z,1.5,1\n",
+		"proxtop-2.csv": "timestamp,t_load,101:vm101/t_iops,102:vm102/t_iops\n" +
+			"2026-03-02T10:15:00Z,1.5,1,11\n" +
+			"2026-03-02T10:15:00Z,1.5,,1\n",
+	}
+	for name, want := range files {
+		got, err := ioutil.ReadFile(filepath.Join(directory, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+}
+
+func TestCSVColumnsLeftOut(t *testing.T) {
+	defer withCSV("plain", "tcp", "")()
+	addCSVColumn("host/t_load", "t_load")
+	addCSVColumns()
+	csvHeaderPending = false
+
+	// with the header written, columns of new VMs are left out of network outputs
+	addCSVColumn("uuid-102/t_iops", "102:vm102/t_iops")
+	addCSVColumns()
+	if len(csvColumns) != 1 || !csvLeftOut["uuid-102/t_iops"] {
+		t.Errorf("columns %v, left out %v, want the new column left out", csvColumns, csvLeftOut)
+	}
+	addCSVColumn("uuid-102/t_iops", "102:vm102/t_iops")
+	if len(csvNewColumns) != 0 {
+		t.Errorf("column left out added again: %v", csvNewColumns)
+	}
+}
+
+func TestCSVHeaderEsxtop(t *testing.T) {
+	defer withCSV("esxtop", "", "")()
+	csvHost = "pve1"
+	models.RegisterFields("cpu", models.ScopeHost, []models.FieldDef{{Name: "t_cpu_%USED"}})
+	models.RegisterFields("test-csv", models.ScopeDomain, []models.FieldDef{{Name: "t_csv_LAT(rd)"}})
+	tests := []struct {
+		scope    models.Scope
+		instance string
+		field    string
+		want     string
+	}{
+		{models.ScopeHost, "", "t_cpu_%USED", `\\pve1\Physical Cpu\cpu_%USED`},
+		{models.ScopeDomain, "101:web(1)", "t_csv_LAT(rd)", `\\pve1\Group Test-Csv(101:web[1])\csv_LAT[rd]`},
+		{models.ScopeDomain, "101:web", "t_unknown", `\\pve1\Group Proxtop(101:web)\unknown`},
+		{models.ScopeHost, "", "nounderscore", `\\pve1\Physical Proxtop\nounderscore`},
+	}
+	for _, test := range tests {
+		if got := csvHeader(test.scope, test.instance, test.field); got != test.want {
+			t.Errorf("csvHeader(%s, %q, %q) = %q, want %q", test.scope, test.instance, test.field, got, test.want)
+		}
+	}
+	if got, want := csvRow([]string{"a", `say "hi"`}), "\"a\",\"say \"\"hi\"\"\"\r\n"; got != want {
+		t.Errorf("csvRow = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,329 @@
+package printers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// InfluxPrinter prints the snapshots in the InfluxDB line protocol, one measurement per collector.
+// With output 'http' the lines are written in batches to the write endpoint given as target, queued
+// and retried by the output sender like the messages of output 'tcp'.
+type InfluxPrinter struct {
+	models.Printer
+}
+
+// influxPrecisions maps the precisions of --influx-precision to their duration
+var influxPrecisions = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+}
+
+// influxHost is the host tag of all lines
+var influxHost string
+
+// influxBatch holds the lines not yet queued for the write endpoint, influxBatchStart the time of the oldest
+var influxBatch []string
+var influxBatchStart time.Time
+var influxClient = &http.Client{Timeout: 10 * time.Second}
+
+// influxWriteTarget is the write url of the target with the precision parameter
+var influxWriteTarget string
+
+// Open opens the output, for output 'http' the write requests are sent by the output sender
+func (printer *InfluxPrinter) Open() error {
+	influxHost, _ = os.Hostname()
+	if _, valid := influxPrecisions[config.Options.InfluxPrecision]; !valid {
+		fmt.Fprintf(os.Stderr, "invalid influx precision %s (valid precisions: ns, us, ms, s)\n", config.Options.InfluxPrecision)
+		os.Exit(1)
+	}
+	if config.Options.Output == "http" {
+		var err error
+		if influxWriteTarget, err = influxWriteURL(); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid influx write url %s: %v\n", config.Options.OutputTarget, err)
+			os.Exit(1)
+		}
+		outputSend = influxSend
+	}
+	OutputOpen()
+	return nil
+}
+
+// Screen prints the lines of a snapshot
+func (printer *InfluxPrinter) Screen(printable models.Printable) {
+	lines := influxLines(printable)
+	if config.Options.Output == "http" {
+		if len(influxBatch) == 0 {
+			influxBatchStart = time.Now()
+		}
+		influxBatch = append(influxBatch, lines...)
+		if len(influxBatch) >= influxBatchSize() || time.Since(influxBatchStart) >= time.Duration(config.Options.InfluxFlush)*time.Second {
+			influxFlush()
+		}
+		return
+	}
+	// one line per write, so each line is a datagram of its own with output 'udp'
+	for _, line := range lines {
+		Output(line + "\n")
+	}
+}
+
+// Close queues the remaining lines and closes the output
+func (printer *InfluxPrinter) Close() {
+	if config.Options.Output == "http" {
+		influxFlush()
+	}
+	OutputClose()
+}
+
+// CreateInflux creates a new line protocol printer
+func CreateInflux() InfluxPrinter {
+	return InfluxPrinter{}
+}
+
+// influxLines returns the lines of a snapshot: host and VM fields grouped by collector, and the rows of the device tables
+func influxLines(printable models.Printable) []string {
+	timestamp := strconv.FormatInt(printable.Timestamp.UnixNano()/int64(influxPrecisions[config.Options.InfluxPrecision]), 10)
+	lines := []string{}
+
+	hostTags := influxTags([][2]string{{"host", influxHost}})
+	lines = append(lines, influxFieldLines(models.ScopeFields(models.ScopeHost), printable.HostFields, printable.HostValues, hostTags, false, timestamp)...)
+
+	// VMs sorted by UUID for a stable output
+	uuids := make([]string, 0, len(printable.DomainValues))
+	for uuid := range printable.DomainValues {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	domainTags := make(map[string]string)
+	for _, uuid := range uuids {
+		values := printable.DomainValues[uuid]
+		name := ""
+		if len(values) > 1 {
+			name = values[1]
+		}
+		domainTags[uuid] = influxTags([][2]string{{"host", influxHost}, {"vmid", printable.VMIDs[uuid]}, {"uuid", uuid}, {"name", name}})
+		lines = append(lines, influxFieldLines(models.ScopeFields(models.ScopeDomain), printable.DomainFields, values, domainTags[uuid], printable.StaleDomains[uuid], timestamp)...)
+	}
+
+	// rows of the device views of the host, tagged with the view
+	for _, view := range sortedKeys(printable.Devices) {
+		table := printable.Devices[view]
+		for _, device := range sortedRows(table.Rows) {
+			tags := influxTags([][2]string{{"host", influxHost}, {"view", view}, {"device", device}})
+			lines = append(lines, influxFieldLines(models.DeviceFields(view), table.Fields, table.Rows[device], tags, false, timestamp)...)
+		}
+	}
+
+	// rows of the device tables of the VMs
+	for _, uuid := range uuids {
+		tables := printable.DomainDevices[uuid]
+		for _, view := range sortedKeys(tables) {
+			table := tables[view]
+			for _, device := range sortedRows(table.Rows) {
+				tags := domainTags[uuid] + influxTags([][2]string{{"view", view}, {"device", device}})
+				lines = append(lines, influxFieldLines(models.DomainDeviceFields(view), table.Fields, table.Rows[device], tags, printable.StaleDomains[uuid], timestamp)...)
+			}
+		}
+	}
+	return lines
+}
+
+// influxFieldLines returns one line per collector of the fields, info fields as strings and other fields
+// as floats. Values which are no number (e.g. reset markers) are left out, so a field keeps its type.
+// The fields are declared in lookup, e.g. DomainDeviceFields for the per device rows of VMs.
+func influxFieldLines(lookup models.FieldLookup, fields []string, values []string, tags string, stale bool, timestamp string) []string {
+	collectors := []string{}
+	fieldsByCollector := make(map[string][]string)
+	for i, field := range fields {
+		if i >= len(values) || field == "UUID" || field == "name" {
+			continue
+		}
+		collector := "proxtop"
+		def, exists := lookup(field)
+		if exists {
+			collector = def.Collector
+		}
+
+		var value string
+		if exists && def.Kind == models.KindInfo {
+			if values[i] == "" {
+				continue
+			}
+			value = "\"" + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(values[i]) + "\""
+		} else {
+			number, err := def.Unit.Parse(values[i])
+			if err != nil {
+				continue
+			}
+			value = strconv.FormatFloat(number, 'f', -1, 64)
+		}
+
+		if _, seen := fieldsByCollector[collector]; !seen {
+			collectors = append(collectors, collector)
+		}
+		fieldsByCollector[collector] = append(fieldsByCollector[collector], influxEscape(field, ",= ")+"="+value)
+	}
+
+	lines := []string{}
+	for _, collector := range collectors {
+		fieldSet := fieldsByCollector[collector]
+		// values of a VM skipped by its circuit breaker are from an earlier cycle
+		if stale {
+			fieldSet = append(fieldSet, "stale=true")
+		}
+		lines = append(lines, influxEscape(collector, ", ")+tags+" "+strings.Join(fieldSet, ",")+" "+timestamp)
+	}
+	return lines
+}
+
+// influxTags returns the tag set of the tags which are set, starting with a comma
+func influxTags(tags [][2]string) string {
+	tagSet := ""
+	for _, tag := range tags {
+		if tag[1] != "" {
+			tagSet += "," + tag[0] + "=" + influxEscape(tag[1], ",= ")
+		}
+	}
+	return tagSet
+}
+
+// influxEscape escapes the special characters of a measurement, tag or field key with a backslash
+func influxEscape(value string, special string) string {
+	var escaped strings.Builder
+	for _, char := range value {
+		if strings.ContainsRune(special, char) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(char)
+	}
+	return escaped.String()
+}
+
+// sortedKeys returns the names of device tables, sorted
+func sortedKeys(tables map[string]models.DeviceTable) []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedRows returns the devices of a device table, sorted
+func sortedRows(rows map[string][]string) []string {
+	devices := make([]string, 0, len(rows))
+	for device := range rows {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	return devices
+}
+
+// influxBatchSize returns the configured maximum number of lines per write request
+func influxBatchSize() int {
+	if config.Options.InfluxBatch < 1 {
+		return 1
+	}
+	return config.Options.InfluxBatch
+}
+
+// influxWriteURL returns the write url of the target with the precision parameter,
+// v1 (/write) and v2 (/api/v2/write) name the precisions differently
+func influxWriteURL() (string, error) {
+	target, err := url.Parse(config.Options.OutputTarget)
+	if err != nil {
+		return "", err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return "", fmt.Errorf("scheme must be http or https")
+	}
+	precision := config.Options.InfluxPrecision
+	if !strings.HasSuffix(target.Path, "/api/v2/write") {
+		precision = map[string]string{"ns": "n", "us": "u", "ms": "ms", "s": "s"}[precision]
+	}
+	query := target.Query()
+	query.Set("precision", precision)
+	target.RawQuery = query.Encode()
+	return target.String(), nil
+}
+
+// influxFlush queues the batched lines for the write endpoint in messages of at most --influx-batch lines
+func influxFlush() {
+	for len(influxBatch) > 0 {
+		count := influxBatchSize()
+		if count > len(influxBatch) {
+			count = len(influxBatch)
+		}
+		Output(strings.Join(influxBatch[:count], "\n") + "\n")
+		influxBatch = influxBatch[count:]
+	}
+	influxBatch = nil
+}
+
+// influxSend posts the lines of a message to the write endpoint, gzip compressed with --influx-gzip.
+// A request rejected for its lines (4xx) is not retried, the other failures are.
+func influxSend(text string) error {
+	err := influxWrite(influxWriteTarget, text)
+	setConnected(err == nil || errors.Is(err, errOutputRejected))
+	if err != nil {
+		log.Printf("influx: write to %s failed: %v", config.Options.OutputTarget, err)
+	}
+	return err
+}
+
+// influxWrite posts lines to the write endpoint, gzip compressed with --influx-gzip
+func influxWrite(writeURL string, payload string) error {
+	var body bytes.Buffer
+	if config.Options.InfluxGzip {
+		writer := gzip.NewWriter(&body)
+		writer.Write([]byte(payload))
+		writer.Close()
+	} else {
+		body.WriteString(payload)
+	}
+
+	request, err := http.NewRequest("POST", writeURL, &body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if config.Options.InfluxGzip {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
+	// v2 takes the API token, v1 (1.8+) user:password in the same header
+	if config.Options.InfluxToken != "" {
+		request.Header.Set("Authorization", "Token "+config.Options.InfluxToken)
+	}
+
+	response, err := influxClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 512))
+		// e.g. a parse error or a missing bucket, too many requests and timeouts are retried
+		if response.StatusCode/100 == 4 && response.StatusCode != http.StatusTooManyRequests && response.StatusCode != http.StatusRequestTimeout {
+			return fmt.Errorf("%w: %s: %s", errOutputRejected, response.Status, strings.TrimSpace(string(message)))
+		}
+		return fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
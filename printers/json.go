@@ -1,80 +1,243 @@
 package printers
 
 import (
-	"fmt"
+	"encoding/json"
+	"os"
+	"sort"
 	"strconv"
+	"time"
 
 	"proxtop/models"
 )
 
-// JSONPrinter describes the json printer
+// JSONPrinter prints one JSON record per snapshot and line (NDJSON), laid out as described by --schema.
+// Fields are typed by the metric registry, VMs are sorted by UUID and the rows of devices by device name.
 type JSONPrinter struct {
 	models.Printer
 }
 
-// Open opens the printer
-func (printer *JSONPrinter) Open() {
+// jsonRecord is the record of a snapshot, version models.SchemaVersion
+type jsonRecord struct {
+	Version   int                    `json:"version"`
+	Timestamp string                 `json:"timestamp"`
+	Host      jsonHost               `json:"host"`
+	Domains   []jsonDomain           `json:"domains"`
+	Stale     []string               `json:"stale,omitempty"`
+	Proxtop   map[string]interface{} `json:"proxtop"`
+}
+
+// jsonHost holds the host fields and the rows of the device views by view
+type jsonHost struct {
+	Name    string                  `json:"name"`
+	Fields  map[string]interface{}  `json:"fields"`
+	Devices map[string][]jsonDevice `json:"devices,omitempty"`
+}
+
+// jsonDomain holds the fields of a VM and the rows of its devices
+type jsonDomain struct {
+	UUID   string                 `json:"uuid"`
+	VMID   int                    `json:"vmid,omitempty"`
+	Name   string                 `json:"name"`
+	Stale  bool                   `json:"stale"`
+	Fields map[string]interface{} `json:"fields"`
+	Disks  []jsonDevice           `json:"disks,omitempty"`
+	NICs   []jsonDevice           `json:"nics,omitempty"`
+	VCPUs  []jsonDevice           `json:"vcpus,omitempty"`
+}
+
+// jsonDevice is the row of a device
+type jsonDevice struct {
+	Device string                 `json:"device"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// jsonHostName is the host name of all records
+var jsonHostName string
+
+// Open opens the output
+func (printer *JSONPrinter) Open() error {
+	jsonHostName, _ = os.Hostname()
 	OutputOpen()
+	return nil
 }
 
-// Screen prints the measurements on the screen
+// Screen prints the events of a snapshot and its record, one line each
 func (printer *JSONPrinter) Screen(printable models.Printable) {
-	Output(fmt.Sprintf("{ \"host\": {"))
-	hostFields := printable.HostFields
-	hostValues := printable.HostValues
-	i := 0
-	for _, value := range hostValues {
-		if i > 0 {
-			Output(fmt.Sprintf(","))
+	for _, event := range printable.Events {
+		Output(string(event) + "\n")
+	}
+
+	record := jsonRecord{
+		Version:   models.SchemaVersion,
+		Timestamp: printable.Timestamp.UTC().Format(time.RFC3339Nano),
+		Host: jsonHost{
+			Name:   jsonHostName,
+			Fields: jsonFields(printable.HostFields, printable.HostValues, models.ScopeFields(models.ScopeHost)),
+		},
+		Domains: []jsonDomain{},
+		// collectors not done in time
+		Stale: printable.StaleCollectors,
+		// metrics of proxtop itself
+		Proxtop: diagnosticsSection(printable.Diagnostics),
+	}
+
+	for _, view := range sortedKeys(printable.Devices) {
+		if record.Host.Devices == nil {
+			record.Host.Devices = make(map[string][]jsonDevice)
 		}
+		record.Host.Devices[view] = jsonDevices(printable.Devices[view], models.DeviceFields(view))
+	}
 
-		// but """ only for strings
-		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
-			Output(fmt.Sprintf("\"%s\": %d", hostFields[i], intValue))
-		} else if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
-			Output(fmt.Sprintf("\"%s\": %f", hostFields[i], floatValue))
-		} else {
-			Output(fmt.Sprintf("\"%s\": \"%s\"", hostFields[i], value))
+	uuids := make([]string, 0, len(printable.DomainValues))
+	for uuid := range printable.DomainValues {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	for _, uuid := range uuids {
+		values := printable.DomainValues[uuid]
+		domain := jsonDomain{
+			UUID: uuid,
+			// values of a VM skipped by its circuit breaker are from an earlier cycle
+			Stale:  printable.StaleDomains[uuid],
+			Fields: jsonFields(printable.DomainFields, values, models.ScopeFields(models.ScopeDomain)),
+		}
+		domain.VMID, _ = strconv.Atoi(printable.VMIDs[uuid])
+		if len(values) > 1 {
+			domain.Name = values[1]
 		}
-		i++
+		tables := printable.DomainDevices[uuid]
+		domain.Disks = jsonDevices(tables[models.DomainDevicesDisk], models.DomainDeviceFields(models.DomainDevicesDisk))
+		domain.NICs = jsonDevices(mergeDeviceTables(tables[models.DomainDevicesNet], tables[models.DomainDevicesTC]), nicFields)
+		domain.VCPUs = jsonDevices(tables[models.DomainDevicesVCPU], models.DomainDeviceFields(models.DomainDevicesVCPU))
+		record.Domains = append(record.Domains, domain)
 	}
 
-	Output(fmt.Sprintf("}, \"domains\": ["))
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	Output(string(line) + "\n")
+}
+
+// nicFields looks up the fields of the merged net and tc tables of the interfaces of a VM
+func nicFields(field string) (models.FieldDef, bool) {
+	if def, exists := models.DomainDeviceFields(models.DomainDevicesNet)(field); exists {
+		return def, true
+	}
+	return models.DomainDeviceFields(models.DomainDevicesTC)(field)
+}
 
-	domainFields := printable.DomainFields
-	domainValues := printable.DomainValues
-	i = 0
-	for domvalue := range domainValues {
-		if i > 0 {
-			Output(fmt.Sprintf(","))
+// jsonFields returns the typed values of the fields by their name in the output, without the identifying
+// fields UUID and name. The fields are declared in lookup.
+func jsonFields(fields []string, values []string, lookup models.FieldLookup) map[string]interface{} {
+	typed := make(map[string]interface{})
+	for i, field := range fields {
+		if i >= len(values) || field == "UUID" || field == "name" {
+			continue
 		}
-		Output(fmt.Sprintf("{"))
-		for j, value := range domainValues[domvalue] {
-			if j > 0 {
-				Output(fmt.Sprintf(","))
-			}
+		typed[models.JSONFieldName(field)] = models.JSONValue(lookup, field, values[i])
+	}
+	return typed
+}
+
+// jsonDevices returns the rows of a device table sorted by device
+func jsonDevices(table models.DeviceTable, lookup models.FieldLookup) []jsonDevice {
+	devices := []jsonDevice{}
+	for _, device := range sortedRows(table.Rows) {
+		devices = append(devices, jsonDevice{Device: device, Fields: jsonFields(table.Fields, table.Rows[device], lookup)})
+	}
+	return devices
+}
 
-			// but """ only for strings
-			if _, err := strconv.ParseInt(value, 10, 64); err == nil {
-				Output(fmt.Sprintf("\"%s\": %s", domainFields[j], value))
-			} else if _, err := strconv.ParseFloat(value, 64); err == nil {
-				Output(fmt.Sprintf("\"%s\": %s", domainFields[j], value))
-			} else {
-				Output(fmt.Sprintf("\"%s\": \"%s\"", domainFields[j], value))
+// mergeDeviceTables merges the rows of device tables of the same devices, e.g. the net and tc tables of
+// the interfaces of a VM. The fields of the first table win.
+func mergeDeviceTables(tables ...models.DeviceTable) models.DeviceTable {
+	merged := models.DeviceTable{Rows: make(map[string][]string)}
+	index := make(map[string]int)
+	for _, table := range tables {
+		for i, field := range table.Fields {
+			if _, exists := index[field]; !exists {
+				index[field] = len(merged.Fields)
+				merged.Fields = append(merged.Fields, field)
 			}
+			for device, values := range table.Rows {
+				if i >= len(values) {
+					continue
+				}
+				row := merged.Rows[device]
+				for len(row) < len(merged.Fields) {
+					row = append(row, "")
+				}
+				if row[index[field]] == "" {
+					row[index[field]] = values[i]
+				}
+				merged.Rows[device] = row
+			}
+		}
+	}
+	return merged
+}
+
+// diagnosticsSection returns the diagnostics as proxtop section of the JSON output, durations in milliseconds
+func diagnosticsSection(diagnostics models.Diagnostics) map[string]interface{} {
+	milliseconds := func(duration time.Duration) float64 {
+		return float64(duration) / float64(time.Millisecond)
+	}
+
+	phases := make(map[string]interface{})
+	for name, durations := range diagnostics.Phases {
+		phases[name] = map[string]float64{
+			"lookup_ms":  milliseconds(durations.Lookup),
+			"collect_ms": milliseconds(durations.Collect),
+			"print_ms":   milliseconds(durations.Print),
+		}
+	}
+	calls := make(map[string]interface{})
+	for kind, stats := range diagnostics.Calls {
+		calls[kind] = map[string]interface{}{
+			"calls":  stats.Calls,
+			"errors": stats.Errors,
+			"avg_ms": milliseconds(stats.Average()),
+			"max_ms": milliseconds(stats.Max),
+		}
+	}
+	caches := make(map[string]interface{})
+	for name, stats := range diagnostics.Caches {
+		caches[name] = map[string]interface{}{
+			"hits":      stats.Hits,
+			"misses":    stats.Misses,
+			"hit_ratio": stats.HitRatio(),
+		}
+	}
+
+	section := map[string]interface{}{
+		"cpu":             diagnostics.CPU,
+		"rss":             diagnostics.RSS,
+		"unresolved_pids": diagnostics.UnresolvedPIDs,
+		"phases":          phases,
+		"calls":           calls,
+		"caches":          caches,
+	}
+	// counters of the network output, of the output before this record
+	if output := diagnostics.Output; output != nil {
+		section["output"] = map[string]interface{}{
+			"connected":     output.Connected,
+			"reconnects":    output.Reconnects,
+			"queued":        output.Queued,
+			"queued_bytes":  output.QueuedBytes,
+			"spooled_bytes": output.SpooledBytes,
+			"dropped":       output.Dropped,
 		}
-		Output(fmt.Sprintf("}"))
-		i++
 	}
-	Output(fmt.Sprintf("]}\n"))
+	return section
 }
 
-// Close terminates the printer
+// Close closes the output
 func (printer *JSONPrinter) Close() {
 	OutputClose()
 }
 
-// CreateJSON creates a new simple text printer
+// CreateJSON creates a new json printer
 func CreateJSON() JSONPrinter {
 	return JSONPrinter{}
 }
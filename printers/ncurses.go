@@ -7,13 +7,22 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cha87de/goncurses"
+	"proxtop/collectors/cpucollector"
+	"proxtop/collectors/dirtyratecollector"
 	"proxtop/collectors/diskcollector"
+	"proxtop/collectors/irqcollector"
+	"proxtop/collectors/jobcollector"
+	"proxtop/collectors/migratecollector"
 	"proxtop/collectors/netcollector"
+	"proxtop/collectors/overheadcollector"
+	"proxtop/collectors/vfiocollector"
 	"proxtop/config"
 	"proxtop/models"
 	"proxtop/runners"
+	"proxtop/util"
 )
 
 var screen *goncurses.Window
@@ -32,6 +41,12 @@ const (
 	ViewPhysDisk // Physical disk devices
 	ViewLVM      // LVM logical volumes
 	ViewMpath    // Multipath devices
+	ViewIRQ      // Per-CPU interrupts and softirqs
+	ViewCores    // Per-core host CPU usage with vCPU placement
+	ViewVFIO     // PCI passthrough devices
+	ViewMigrate  // Live migrations
+	ViewJobs     // Backup and block jobs
+	ViewOverhead // Host processes outside of guests
 	ViewHelp
 )
 
@@ -62,6 +77,9 @@ var showHelpOverlay bool = false
 var helpDrawn bool = false
 var quitRequested bool = false
 var currentInterval int = 1  // Current refresh interval in seconds
+var sortedField string       // Field of the sort column, described in the help overlay
+var staleDomains map[string]bool // VMs skipped by their circuit breaker, shown dimmed
+var showDiagnostics bool = false  // Diagnostics of proxtop itself instead of the current view
 
 // Field selection state
 var showFieldSelector bool = false
@@ -71,7 +89,7 @@ var availableFields []string        // Fields available in current view
 var hiddenFields map[string]bool    // Map of field name -> hidden status (global for now)
 
 // Open opens the printer
-func (printer *NcursesPrinter) Open() {
+func (printer *NcursesPrinter) Open() error {
 	// Init goncurses
 	var err error
 	screen, err = goncurses.Init()
@@ -88,144 +106,16 @@ func (printer *NcursesPrinter) Open() {
 	// Users can show/hide individual fields via the field selector ('f' key)
 	config.Options.Verbose = true
 
-	// Initialize field selection state with verbose-only fields hidden by default
+	// Initialize field selection state with verbose-only fields of the metric registry hidden by default
 	hiddenFields = make(map[string]bool)
+	for _, def := range models.RegisteredFields() {
+		if def.Verbose {
+			hiddenFields[def.Name] = true
+		}
+	}
 
-	// Verbose-only fields (hidden by default, can be enabled via field selector)
-	// CPU collector
-	hiddenFields["cpu_%othrdy"] = true
-	hiddenFields["cpu_minfreq"] = true
-	hiddenFields["cpu_maxfreq"] = true
-	hiddenFields["cpu_%nice"] = true
-	hiddenFields["cpu_%irq"] = true
-	hiddenFields["cpu_%softirq"] = true
-	hiddenFields["cpu_%guest"] = true
-	hiddenFields["cpu_%guestnice"] = true
-
-	// Memory collector (domain)
-	hiddenFields["mem_MAXSZ"] = true
-	hiddenFields["mem_VSIZE"] = true
-	hiddenFields["mem_SWAPIN"] = true
-	hiddenFields["mem_SWAPOUT"] = true
-	hiddenFields["mem_CMINFLT"] = true
-	hiddenFields["mem_CMAJFLT"] = true
-
-	// Memory collector (host) - detailed breakdown fields
-	hiddenFields["mem_buffers"] = true
-	hiddenFields["mem_cached"] = true
-	hiddenFields["mem_swapcached"] = true
-	hiddenFields["mem_active"] = true
-	hiddenFields["mem_inactive"] = true
-	hiddenFields["mem_activeanon"] = true
-	hiddenFields["mem_inactiveanon"] = true
-	hiddenFields["mem_activefile"] = true
-	hiddenFields["mem_inactivefile"] = true
-	hiddenFields["mem_unevictable"] = true
-	hiddenFields["mem_mlocked"] = true
-	hiddenFields["mem_dirty"] = true
-	hiddenFields["mem_writeback"] = true
-	hiddenFields["mem_anonpages"] = true
-	hiddenFields["mem_mapped"] = true
-	hiddenFields["mem_shmem"] = true
-	hiddenFields["mem_kreclaimable"] = true
-	hiddenFields["mem_slab"] = true
-	hiddenFields["mem_sreclaimable"] = true
-	hiddenFields["mem_sunreclaim"] = true
-	hiddenFields["mem_kernelstack"] = true
-	hiddenFields["mem_pagetables"] = true
-	hiddenFields["mem_nfs_unstable"] = true
-	hiddenFields["mem_bounce"] = true
-	hiddenFields["mem_writebacktmp"] = true
-	hiddenFields["mem_commitlimit"] = true
-	hiddenFields["mem_committed_as"] = true
-	hiddenFields["mem_vmalloctotal"] = true
-	hiddenFields["mem_vmallocused"] = true
-	hiddenFields["mem_vmallocchunk"] = true
-	hiddenFields["mem_percpu"] = true
-	hiddenFields["mem_hardwarecorrupted"] = true
-	hiddenFields["mem_anonhugepages"] = true
-	hiddenFields["mem_shmemhugepages"] = true
-	hiddenFields["mem_shmempmdmapped"] = true
-	hiddenFields["mem_cmatotal"] = true
-	hiddenFields["mem_cmafree"] = true
-	hiddenFields["mem_hugepages_total"] = true
-	hiddenFields["mem_hugepages_free"] = true
-	hiddenFields["mem_hugepages_rsvd"] = true
-	hiddenFields["mem_hugepages_surp"] = true
-	hiddenFields["mem_hugepagesize"] = true
-	hiddenFields["mem_hugetlb"] = true
-	hiddenFields["mem_directmap4k"] = true
-	hiddenFields["mem_directmap2m"] = true
-	hiddenFields["mem_directmap1g"] = true
-
-	// Disk collector (domain)
-	hiddenFields["dsk_PHYSICAL"] = true
-	hiddenFields["dsk_FLUSH/s"] = true
-	hiddenFields["dsk_RDTM"] = true
-	hiddenFields["dsk_WRTM"] = true
-	hiddenFields["dsk_FLTM"] = true
-	hiddenFields["dsk_BLKIO"] = true
-
-	// Disk collector (host)
-	hiddenFields["dsk_rdmerged"] = true
-	hiddenFields["dsk_sectorsrd"] = true
-	hiddenFields["dsk_timerd"] = true
-	hiddenFields["dsk_wrmerged"] = true
-	hiddenFields["dsk_sectorswr"] = true
-	hiddenFields["dsk_timewr"] = true
-	hiddenFields["dsk_timeforops"] = true
-	hiddenFields["dsk_weightedtime"] = true
-	hiddenFields["dsk_count"] = true
-
-	// I/O collector
-	hiddenFields["io_rchar"] = true
-	hiddenFields["io_wchar"] = true
-	hiddenFields["io_cancelled"] = true
-
-	// PSI collector (all PSI fields are verbose-only)
-	hiddenFields["psi_some_cpu_avg10"] = true
-	hiddenFields["psi_some_cpu_avg300"] = true
-	hiddenFields["psi_some_cpu_total"] = true
-	hiddenFields["psi_some_io_avg10"] = true
-	hiddenFields["psi_some_io_avg300"] = true
-	hiddenFields["psi_some_io_total"] = true
-	hiddenFields["psi_full_io_avg10"] = true
-	hiddenFields["psi_full_io_avg300"] = true
-	hiddenFields["psi_full_io_total"] = true
-	hiddenFields["psi_some_mem_avg10"] = true
-	hiddenFields["psi_some_mem_avg300"] = true
-	hiddenFields["psi_some_mem_total"] = true
-	hiddenFields["psi_full_mem_avg10"] = true
-	hiddenFields["psi_full_mem_avg300"] = true
-	hiddenFields["psi_full_mem_total"] = true
-
-	// Host collector
-	hiddenFields["host_uuid"] = true
-
-	// Network collector (domain) - verbose fields hidden by default
-	hiddenFields["net_errsRX"] = true
-	hiddenFields["net_fifoRX"] = true
-	hiddenFields["net_frameRX"] = true
-	hiddenFields["net_compRX"] = true
-	hiddenFields["net_mcastRX"] = true
-	hiddenFields["net_errsTX"] = true
-	hiddenFields["net_fifoTX"] = true
-	hiddenFields["net_collsTX"] = true
-	hiddenFields["net_carrierTX"] = true
-	hiddenFields["net_compTX"] = true
-	hiddenFields["net_interfaces"] = true // Redundant in per-interface view (DEVICE column shows this)
-
-	// Physical network verbose fields (hidden by default)
-	hiddenFields["net_RX-Fifo"] = true
-	hiddenFields["net_RX-Frame"] = true
-	hiddenFields["net_RX-Compressed"] = true
-	hiddenFields["net_RX-Multicast"] = true
-	hiddenFields["net_TX-Fifo"] = true
-	hiddenFields["net_TX-Colls"] = true
-	hiddenFields["net_TX-Carrier"] = true
-	hiddenFields["net_TX-Compressed"] = true
-
-	// Physical network raw counters (hidden by default - rates are more useful)
+	// Fields printed by default by the other printers, but hidden here
+	// Physical network raw counters (rates are more useful)
 	hiddenFields["net_RX-Bytes"] = true
 	hiddenFields["net_RX-Pkts"] = true
 	hiddenFields["net_RX-Errs"] = true
@@ -234,6 +124,12 @@ func (printer *NcursesPrinter) Open() {
 	hiddenFields["net_TX-Pkts"] = true
 	hiddenFields["net_TX-Errs"] = true
 	hiddenFields["net_TX-Drop"] = true
+	// Power collector (attribution inputs are shown in JSON, hidden here)
+	hiddenFields["power_BUSY"] = true
+	hiddenFields["power_MODEL"] = true
+	hiddenFields["power_%PKG"] = true
+	hiddenFields["power_CPU"] = true
+	return nil
 }
 
 // handleInput processes keyboard input and returns true if we should quit
@@ -325,6 +221,35 @@ func handleInput() bool {
 		currentViewMode = ViewMpath
 		showHelpOverlay = false
 		helpDrawn = false
+	case 'o', 'O':
+		currentViewMode = ViewIRQ
+		showHelpOverlay = false
+		helpDrawn = false
+	case 'e', 'E':
+		currentViewMode = ViewCores
+		showHelpOverlay = false
+		helpDrawn = false
+	case 'v', 'V':
+		currentViewMode = ViewVFIO
+		showHelpOverlay = false
+		helpDrawn = false
+	case 'g', 'G':
+		currentViewMode = ViewMigrate
+		showHelpOverlay = false
+		helpDrawn = false
+	case 'b', 'B':
+		currentViewMode = ViewJobs
+		showHelpOverlay = false
+		helpDrawn = false
+	case 'w', 'W':
+		currentViewMode = ViewOverhead
+		showHelpOverlay = false
+		helpDrawn = false
+	case 'z', 'Z':
+		showDiagnostics = !showDiagnostics
+	case 'y', 'Y':
+		// on-demand dirty rate measurement (rate limited per VM)
+		dirtyratecollector.RequestMeasurement()
 	case '<':
 		if currentSortColumn > 0 {
 			currentSortColumn--
@@ -370,6 +295,18 @@ func getViewModeName() string {
 		return "LVM"
 	case ViewMpath:
 		return "MULTIPATH"
+	case ViewIRQ:
+		return "INTERRUPTS"
+	case ViewCores:
+		return "CORES"
+	case ViewVFIO:
+		return "PASSTHROUGH"
+	case ViewMigrate:
+		return "MIGRATION"
+	case ViewJobs:
+		return "JOBS"
+	case ViewOverhead:
+		return "OVERHEAD"
 	default:
 		return "ALL"
 	}
@@ -395,15 +332,16 @@ func filterFieldsByView(fields []string, values map[string][]string) ([]string,
 			fieldLower := strings.ToLower(field)
 			switch currentViewMode {
 			case ViewCPU:
-				include = strings.HasPrefix(fieldLower, "cpu_")
+				include = strings.HasPrefix(fieldLower, "cpu_") || strings.HasPrefix(fieldLower, "power_")
 			case ViewMem:
-				include = strings.HasPrefix(fieldLower, "mem_")
+				include = strings.HasPrefix(fieldLower, "mem_") || strings.HasPrefix(fieldLower, "dirty_")
 			case ViewDisk:
-				include = strings.HasPrefix(fieldLower, "dsk_")
+				include = strings.HasPrefix(fieldLower, "dsk_") || strings.HasPrefix(fieldLower, "job_")
 			case ViewNet:
-				include = strings.HasPrefix(fieldLower, "net_")
+				include = strings.HasPrefix(fieldLower, "net_") || strings.HasPrefix(fieldLower, "tc_")
 			case ViewIO:
-				include = strings.HasPrefix(fieldLower, "io_") || strings.HasPrefix(fieldLower, "psi_")
+				include = strings.HasPrefix(fieldLower, "io_") || strings.HasPrefix(fieldLower, "psi_") ||
+					strings.HasPrefix(fieldLower, "vfio_")
 			default:
 				include = true // ViewAll - include all fields
 			}
@@ -512,6 +450,10 @@ func (printer *NcursesPrinter) Screen(printable models.Printable) {
 	if config.Options.HumanReadable {
 		modeIndicators += " [H]"
 	}
+	if len(printable.StaleCollectors) > 0 {
+		modeIndicators += fmt.Sprintf(" [STALE: %s]", strings.Join(printable.StaleCollectors, ","))
+	}
+	staleDomains = printable.StaleDomains
 	statusLine := fmt.Sprintf(" proxtop | View: %s%s | Refresh: %.1fs (+/-) | 'h' help, 'u' units, 'f' fields, 'q' quit ",
 		getViewModeName(), modeIndicators, actualInterval)
 	for len(statusLine) < maxx {
@@ -523,9 +465,21 @@ func (printer *NcursesPrinter) Screen(printable models.Printable) {
 	screen.Printf("%s", statusLine)
 	screen.AttrOff(goncurses.A_REVERSE)
 
+	// Diagnostics replace the current view until closed, they are updated each cycle
+	if showDiagnostics {
+		diagnosticsWin, _ := goncurses.NewWindow(maxy-1, maxx, 1, 0)
+		goncurses.UpdatePanels()
+		goncurses.Update()
+		goncurses.NewPanel(diagnosticsWin)
+		printDiagnostics(diagnosticsWin, printable.Diagnostics)
+
+		screen.NoutRefresh()
+		goncurses.Update()
+		return
+	}
+
 	// Handle physical device views differently
-	if currentViewMode == ViewPhysNet || currentViewMode == ViewPhysDisk ||
-		currentViewMode == ViewLVM || currentViewMode == ViewMpath {
+	if isDeviceView(currentViewMode) {
 		// Use full screen for device list (no host panel)
 		deviceWin, _ := goncurses.NewWindow(maxy-1, maxx, 1, 0)
 		goncurses.UpdatePanels()
@@ -534,13 +488,25 @@ func (printer *NcursesPrinter) Screen(printable models.Printable) {
 
 		switch currentViewMode {
 		case ViewPhysNet:
-			printPhysicalNetDevices(deviceWin)
+			printSnapshotDevices(deviceWin, printable, models.DevicesNet, "net_")
 		case ViewPhysDisk:
-			printPhysicalDiskDevices(deviceWin)
+			printSnapshotDevices(deviceWin, printable, models.DevicesDisk, "dsk_")
 		case ViewLVM:
-			printLVMDevices(deviceWin)
+			printSnapshotDevices(deviceWin, printable, models.DevicesLVM, "dsk_")
 		case ViewMpath:
-			printMpathDevices(deviceWin)
+			printSnapshotDevices(deviceWin, printable, models.DevicesMpath, "dsk_")
+		case ViewIRQ:
+			printSnapshotDevices(deviceWin, printable, models.DevicesIRQ, "irq_")
+		case ViewCores:
+			printSnapshotDevices(deviceWin, printable, models.DevicesCores, "cpu_")
+		case ViewVFIO:
+			printSnapshotDevices(deviceWin, printable, models.DevicesVFIO, "vfio_")
+		case ViewMigrate:
+			printSnapshotDevices(deviceWin, printable, models.DevicesMigrate, "mig_")
+		case ViewJobs:
+			printSnapshotDevices(deviceWin, printable, models.DevicesJobs, "job_")
+		case ViewOverhead:
+			printOverhead(deviceWin, printable)
 		}
 
 		screen.NoutRefresh()
@@ -553,7 +519,7 @@ func (printer *NcursesPrinter) Screen(printable models.Printable) {
 
 	// Expand per-device data for Net/Disk views
 	if currentViewMode == ViewNet || currentViewMode == ViewDisk {
-		filteredFields, filteredValues = expandPerDeviceView(filteredFields, filteredValues, currentViewMode)
+		filteredFields, filteredValues = expandPerDeviceView(filteredFields, filteredValues, printable, currentViewMode)
 		// Re-apply hidden field filtering after expansion (expansion adds raw collector data)
 		filteredFields, filteredValues = applyHiddenFields(filteredFields, filteredValues)
 	}
@@ -586,8 +552,51 @@ func (printer *NcursesPrinter) Screen(printable models.Printable) {
 	screen.Refresh()
 }
 
+// isDeviceView returns true for the full screen views of host devices, jobs and processes
+func isDeviceView(viewMode ViewMode) bool {
+	switch viewMode {
+	case ViewPhysNet, ViewPhysDisk, ViewLVM, ViewMpath, ViewIRQ, ViewCores, ViewVFIO, ViewMigrate, ViewJobs, ViewOverhead:
+		return true
+	}
+	return false
+}
+
+// deviceViews maps the device views to their device table
+var deviceViews = map[ViewMode]string{
+	ViewPhysNet:  models.DevicesNet,
+	ViewPhysDisk: models.DevicesDisk,
+	ViewLVM:      models.DevicesLVM,
+	ViewMpath:    models.DevicesMpath,
+	ViewIRQ:      models.DevicesIRQ,
+	ViewCores:    models.DevicesCores,
+	ViewVFIO:     models.DevicesVFIO,
+	ViewMigrate:  models.DevicesMigrate,
+	ViewJobs:     models.DevicesJobs,
+	ViewOverhead: models.DevicesOverhead,
+}
+
+// fieldLookup returns the registry lookup of the table fields of the current view
+func fieldLookup() models.FieldLookup {
+	if view, exists := deviceViews[currentViewMode]; exists {
+		return models.DeviceFields(view)
+	}
+	return models.ScopeFields(models.ScopeDomain)
+}
+
+// fieldDescription returns the description and unit of a field of the current view from the metric registry
+func fieldDescription(field string) string {
+	def, ok := fieldLookup()(field)
+	if !ok {
+		return ""
+	}
+	if def.Unit == models.UnitNone {
+		return def.Description
+	}
+	return fmt.Sprintf("%s (%s)", def.Description, def.Unit)
+}
+
 // expandPerDeviceView expands VM rows to show per-device stats for Net/Disk views
-func expandPerDeviceView(fields []string, values map[string][]string, viewMode ViewMode) ([]string, map[string][]string) {
+func expandPerDeviceView(fields []string, values map[string][]string, printable models.Printable, viewMode ViewMode) ([]string, map[string][]string) {
 	expandedValues := make(map[string][]string)
 
 	// Add DEVICE column after UUID and name
@@ -599,18 +608,12 @@ func expandPerDeviceView(fields []string, values map[string][]string, viewMode V
 		expandedFields = append([]string{"DEVICE"}, fields...)
 	}
 
-	// Iterate through domains and expand per-device
-	models.Collection.Domains.Range(func(key, value interface{}) bool {
-		uuid := key.(string)
-		domain := value.(models.Domain)
-		baseValues, ok := values[uuid]
-		if !ok {
-			return true
-		}
+	// Iterate through domains of the snapshot and expand per-device
+	for uuid, baseValues := range values {
 
 		if viewMode == ViewNet {
-			// Get per-interface stats
-			perIfStats := netcollector.DomainPrintPerInterface(&domain)
+			// Get per-interface stats (network and tc collector), keyed by field name
+			perIfStats := perInterfaceNetValues(printable.DomainDevices[uuid])
 			if len(perIfStats) > 1 {
 				// Multiple interfaces - create a row for each
 				for ifName, ifValues := range perIfStats {
@@ -620,7 +623,7 @@ func expandPerDeviceView(fields []string, values map[string][]string, viewMode V
 					row := make([]string, 0, len(expandedFields))
 					if len(baseValues) >= 2 {
 						row = append(row, baseValues[0], baseValues[1], ifName)
-						row = append(row, ifValues...)
+						row = appendFieldValues(row, expandedFields[3:], ifValues)
 					}
 					expandedValues[rowKey] = row
 				}
@@ -630,7 +633,7 @@ func expandPerDeviceView(fields []string, values map[string][]string, viewMode V
 					row := make([]string, 0, len(expandedFields))
 					if len(baseValues) >= 2 {
 						row = append(row, baseValues[0], baseValues[1], ifName)
-						row = append(row, ifValues...)
+						row = appendFieldValues(row, expandedFields[3:], ifValues)
 					}
 					expandedValues[uuid] = row
 				}
@@ -645,7 +648,7 @@ func expandPerDeviceView(fields []string, values map[string][]string, viewMode V
 			}
 		} else if viewMode == ViewDisk {
 			// Get per-disk stats
-			perDiskStats := diskcollector.DiskPrintPerDevice(&domain)
+			perDiskStats := printable.DomainDevices[uuid][models.DomainDevicesDisk].Rows
 			if len(perDiskStats) > 1 {
 				// Multiple disks - create a row for each
 				for diskName, diskValues := range perDiskStats {
@@ -685,12 +688,41 @@ func expandPerDeviceView(fields []string, values map[string][]string, viewMode V
 				expandedValues[uuid] = row
 			}
 		}
-		return true
-	})
+	}
 
 	return expandedFields, expandedValues
 }
 
+// perInterfaceNetValues merges the per-interface values of the network and tc collectors
+// Returns a map of interface name -> field name -> value
+func perInterfaceNetValues(tables map[string]models.DeviceTable) map[string]map[string]string {
+	result := make(map[string]map[string]string)
+	merge := func(table models.DeviceTable) {
+		fields := table.Fields
+		for ifName, ifValues := range table.Rows {
+			if _, ok := result[ifName]; !ok {
+				result[ifName] = make(map[string]string)
+			}
+			for i, field := range fields {
+				if i < len(ifValues) {
+					result[ifName][field] = ifValues[i]
+				}
+			}
+		}
+	}
+	merge(tables[models.DomainDevicesNet])
+	merge(tables[models.DomainDevicesTC])
+	return result
+}
+
+// appendFieldValues appends the values for the given fields to row, in field order
+func appendFieldValues(row []string, fields []string, values map[string]string) []string {
+	for _, field := range fields {
+		row = append(row, values[field])
+	}
+	return row
+}
+
 // filterHostFieldsByView filters host fields based on current view mode
 func filterHostFieldsByView(fields []string, values []string) ([]string, []string) {
 	if currentViewMode == ViewAll {
@@ -705,15 +737,16 @@ func filterHostFieldsByView(fields []string, values []string) ([]string, []strin
 		include := false
 		switch currentViewMode {
 		case ViewCPU:
-			include = strings.HasPrefix(fieldLower, "cpu_")
+			include = strings.HasPrefix(fieldLower, "cpu_") || strings.HasPrefix(fieldLower, "power_")
 		case ViewMem:
-			include = strings.HasPrefix(fieldLower, "mem_")
+			include = strings.HasPrefix(fieldLower, "mem_") || strings.HasPrefix(fieldLower, "dirty_")
 		case ViewDisk:
-			include = strings.HasPrefix(fieldLower, "dsk_")
+			include = strings.HasPrefix(fieldLower, "dsk_") || strings.HasPrefix(fieldLower, "job_")
 		case ViewNet:
-			include = strings.HasPrefix(fieldLower, "net_")
+			include = strings.HasPrefix(fieldLower, "net_") || strings.HasPrefix(fieldLower, "tc_")
 		case ViewIO:
-			include = strings.HasPrefix(fieldLower, "io_") || strings.HasPrefix(fieldLower, "psi_")
+			include = strings.HasPrefix(fieldLower, "io_") || strings.HasPrefix(fieldLower, "psi_") ||
+				strings.HasPrefix(fieldLower, "vfio_")
 		default:
 			include = true
 		}
@@ -732,7 +765,7 @@ func filterHostFieldsByView(fields []string, values []string) ([]string, []strin
 func printHelpOverlay(maxy, maxx int) {
 	// Center the help box
 	helpWidth := 50
-	helpHeight := 33
+	helpHeight := 42
 	startY := (maxy - helpHeight) / 2
 	startX := (maxx - helpWidth) / 2
 
@@ -789,32 +822,56 @@ func printHelpOverlay(maxy, maxx int) {
 	helpWin.Printf("l - LVM logical volumes")
 	helpWin.Move(15, 4)
 	helpWin.Printf("x - MULTIPATH devices")
+	helpWin.Move(16, 4)
+	helpWin.Printf("o - INTERRUPTS and softirqs per CPU")
+	helpWin.Move(17, 4)
+	helpWin.Printf("e - CPU CORES and the vCPUs last on them")
+	helpWin.Move(18, 4)
+	helpWin.Printf("v - PASSTHROUGH (vfio) PCI devices of VMs")
+	helpWin.Move(19, 4)
+	helpWin.Printf("g - MIGRATIONS in progress")
+	helpWin.Move(20, 4)
+	helpWin.Printf("b - JOBS: backup, mirror, stream, commit")
+	helpWin.Move(21, 4)
+	helpWin.Printf("w - OVERHEAD: host processes outside of VMs")
 
-	helpWin.Move(17, 2)
+	helpWin.Move(23, 2)
 	helpWin.Printf("Sorting:")
-	helpWin.Move(18, 4)
+	helpWin.Move(24, 4)
 	helpWin.Printf("< - Sort by previous column")
-	helpWin.Move(19, 4)
+	helpWin.Move(25, 4)
 	helpWin.Printf("> - Sort by next column")
-	helpWin.Move(20, 4)
+	helpWin.Move(26, 4)
 	helpWin.Printf("r - Reverse sort direction (asc/desc)")
+	if description := fieldDescription(sortedField); description != "" {
+		sortLine := fmt.Sprintf("%s: %s", sortedField, description)
+		if len(sortLine) > helpWidth-6 {
+			sortLine = sortLine[:helpWidth-6]
+		}
+		helpWin.Move(27, 4)
+		helpWin.Printf("%s", sortLine)
+	}
 
-	helpWin.Move(22, 2)
+	helpWin.Move(29, 2)
 	helpWin.Printf("Display:")
-	helpWin.Move(23, 4)
+	helpWin.Move(30, 4)
 	helpWin.Printf("u - Toggle human-readable units (KB/MB/GB)")
-	helpWin.Move(24, 4)
+	helpWin.Move(31, 4)
 	helpWin.Printf("+ - Increase refresh interval (slower)")
-	helpWin.Move(25, 4)
+	helpWin.Move(32, 4)
 	helpWin.Printf("- - Decrease refresh interval (faster)")
 
-	helpWin.Move(27, 2)
+	helpWin.Move(34, 2)
 	helpWin.Printf("Other:")
-	helpWin.Move(28, 4)
+	helpWin.Move(35, 4)
 	helpWin.Printf("f - Field selector (show/hide columns)")
-	helpWin.Move(29, 4)
+	helpWin.Move(36, 4)
+	helpWin.Printf("y - Measure dirty page rate now (--dirtyrate)")
+	helpWin.Move(37, 4)
+	helpWin.Printf("z - Diagnostics of proxtop itself")
+	helpWin.Move(38, 4)
 	helpWin.Printf("h/? - Toggle this help")
-	helpWin.Move(30, 4)
+	helpWin.Move(39, 4)
 	helpWin.Printf("q   - Quit (also Ctrl+C)")
 
 	helpWin.NoutRefresh()
@@ -836,7 +893,7 @@ func printFieldSelectorOverlay(maxy, maxx int, domainFields []string) {
 
 	// Calculate box dimensions
 	boxWidth := 55
-	boxHeight := len(availableFields) + 8 // Header + footer + fields
+	boxHeight := len(availableFields) + 9 // Header + footer + description + fields
 	if boxHeight > maxy-2 {
 		boxHeight = maxy - 2
 	}
@@ -869,7 +926,7 @@ func printFieldSelectorOverlay(maxy, maxx int, domainFields []string) {
 	fieldWin.Printf("Up/Down: navigate, Space/Enter: toggle, f/q: close")
 
 	// Calculate visible range for scrolling
-	visibleFields := boxHeight - 6
+	visibleFields := boxHeight - 7
 	startIdx := 0
 	if fieldSelectorCursor >= visibleFields {
 		startIdx = fieldSelectorCursor - visibleFields + 1
@@ -894,8 +951,11 @@ func printFieldSelectorOverlay(maxy, maxx int, domainFields []string) {
 			checkbox = "[ ]"
 		}
 
-		// Display field name (remove prefix for cleaner display)
+		// Display field name and its unit from the metric registry
 		displayName := field
+		if def, exists := fieldLookup()(field); exists && def.Unit != models.UnitNone {
+			displayName = fmt.Sprintf("%-22s %s", field, def.Unit)
+		}
 		if len(displayName) > boxWidth-10 {
 			displayName = displayName[:boxWidth-10]
 		}
@@ -911,6 +971,16 @@ func printFieldSelectorOverlay(maxy, maxx int, domainFields []string) {
 		}
 	}
 
+	// Describe the field under the cursor
+	if len(availableFields) > 0 {
+		description := fieldDescription(availableFields[fieldSelectorCursor])
+		if len(description) > boxWidth-4 {
+			description = description[:boxWidth-4]
+		}
+		fieldWin.Move(boxHeight-3, 2)
+		fieldWin.Printf("%s", description)
+	}
+
 	// Show scroll indicator if needed
 	if len(availableFields) > visibleFields {
 		fieldWin.Move(boxHeight-2, 2)
@@ -945,29 +1015,77 @@ func filterFieldsForSelector(domainFields []string) []string {
 			}
 		}
 		return filtered
+	case ViewIRQ:
+		// Get fields from irq collector (skip first "CPU" column)
+		for i, field := range irqcollector.HostIRQFields() {
+			if i > 0 {
+				filtered = append(filtered, field)
+			}
+		}
+		return filtered
+	case ViewCores:
+		// Get fields from cpu collector (skip first "CORE" column)
+		for i, field := range cpucollector.HostCoreFields() {
+			if i > 0 {
+				filtered = append(filtered, field)
+			}
+		}
+		return filtered
+	case ViewVFIO:
+		// Get fields from vfio collector (skip first "DEVICE" column)
+		for i, field := range vfiocollector.DomainVFIOFields() {
+			if i > 0 {
+				filtered = append(filtered, field)
+			}
+		}
+		return filtered
+	case ViewMigrate:
+		// Get fields from migration collector (skip first "VM" column)
+		for i, field := range migratecollector.DomainMigrationFields() {
+			if i > 0 {
+				filtered = append(filtered, field)
+			}
+		}
+		return filtered
+	case ViewJobs:
+		// Get fields from job collector (skip first "VM" column)
+		for i, field := range jobcollector.DomainJobFields() {
+			if i > 0 {
+				filtered = append(filtered, field)
+			}
+		}
+		return filtered
+	case ViewOverhead:
+		// Get fields from overhead collector (skip first "PROCESS" column)
+		for i, field := range overheadcollector.HostOverheadFields() {
+			if i > 0 {
+				filtered = append(filtered, field)
+			}
+		}
+		return filtered
 	}
 
 	// For other views, filter domain fields
 	for _, field := range domainFields {
 		switch currentViewMode {
 		case ViewCPU:
-			if strings.HasPrefix(field, "cpu_") {
+			if strings.HasPrefix(field, "cpu_") || strings.HasPrefix(field, "power_") {
 				filtered = append(filtered, field)
 			}
 		case ViewMem:
-			if strings.HasPrefix(field, "mem_") {
+			if strings.HasPrefix(field, "mem_") || strings.HasPrefix(field, "dirty_") {
 				filtered = append(filtered, field)
 			}
 		case ViewDisk:
-			if strings.HasPrefix(field, "dsk_") {
+			if strings.HasPrefix(field, "dsk_") || strings.HasPrefix(field, "job_") {
 				filtered = append(filtered, field)
 			}
 		case ViewNet:
-			if strings.HasPrefix(field, "net_") {
+			if strings.HasPrefix(field, "net_") || strings.HasPrefix(field, "tc_") {
 				filtered = append(filtered, field)
 			}
 		case ViewIO:
-			if strings.HasPrefix(field, "io_") || strings.HasPrefix(field, "psi_") {
+			if strings.HasPrefix(field, "io_") || strings.HasPrefix(field, "psi_") || strings.HasPrefix(field, "vfio_") {
 				filtered = append(filtered, field)
 			}
 		default: // ViewAll
@@ -1065,11 +1183,19 @@ func printDomain(window *goncurses.Window, fields []string, values map[string][]
 	_, maxx := window.MaxYX()
 	availableWidth := maxx - 2 // Leave margin
 
+	// Per-device rows are added after the runner marked counter resets
+	for _, row := range values {
+		models.MarkResets(row)
+	}
+
 	// Reset column widths for this view
 	numColumns := len(fields)
 	if numColumns == 0 {
 		return
 	}
+	if sortByColumn < numColumns {
+		sortedField = fields[sortByColumn]
+	}
 	domainColumnWidths = make([]int, numColumns)
 	desiredWidths := make([]int, numColumns) // Track what each column ideally wants
 
@@ -1214,6 +1340,11 @@ func printDomain(window *goncurses.Window, fields []string, values map[string][]
 	rowCounter := 3
 	for _, domain := range domainList {
 		window.Move(rowCounter, 1)
+		// rows of VMs skipped by their circuit breaker show the values of an earlier cycle
+		isStale := staleDomains[strings.SplitN(domain.Key, ":", 2)[0]]
+		if isStale {
+			window.AttrOn(goncurses.A_DIM)
+		}
 		for colID, value := range values[domain.Key] {
 			if sortByColumn == colID {
 				window.AttrOn(goncurses.A_BOLD)
@@ -1229,6 +1360,9 @@ func printDomain(window *goncurses.Window, fields []string, values map[string][]
 			window.Printf("%s ", val)
 		}
 		window.AttrOff(goncurses.A_BOLD)
+		if isStale {
+			window.AttrOff(goncurses.A_DIM)
+		}
 		rowCounter++
 	}
 }
@@ -1329,13 +1463,16 @@ func padRight(s string, width int) string {
 	return s
 }
 
-// printPhysicalNetDevices displays physical network interface statistics
-func printPhysicalNetDevices(window *goncurses.Window) {
-	maxy, maxx := window.MaxYX()
+// printSnapshotDevices displays a device table of the snapshot (physical interfaces, disks, IRQs, ...)
+func printSnapshotDevices(window *goncurses.Window, printable models.Printable, name string, prefix string) {
+	table := printable.Devices[name]
+	printDeviceTable(window, table.Fields, table.Rows, prefix)
+}
 
-	// Get fields and data from collector
-	allFields := netcollector.HostNetFields()
-	allDeviceData := netcollector.HostPrintPerDevice()
+// printDeviceTable displays one row per device with the given fields (first field is the
+// device name), honoring hidden fields and the current sort column
+func printDeviceTable(window *goncurses.Window, allFields []string, deviceData map[string][]string, prefix string) {
+	maxy, maxx := window.MaxYX()
 
 	// Filter fields based on hiddenFields (but always keep DEVICE column)
 	visibleFields := []string{}
@@ -1347,36 +1484,38 @@ func printPhysicalNetDevices(window *goncurses.Window) {
 		}
 	}
 
-	// Build data rows with only visible columns
-	type netDevRow struct {
+	// Build rows from device data
+	type deviceRow struct {
 		name   string
 		values []string
 	}
-	rows := make([]netDevRow, 0, len(allDeviceData))
-	for name, vals := range allDeviceData {
+	rows := make([]deviceRow, 0, len(deviceData))
+	for name, vals := range deviceData {
 		visibleVals := make([]string, len(visibleIndices))
 		for vi, origIdx := range visibleIndices {
 			if origIdx < len(vals) {
 				visibleVals[vi] = vals[origIdx]
 			}
 		}
-		rows = append(rows, netDevRow{name: name, values: visibleVals})
+		models.MarkResets(visibleVals)
+		rows = append(rows, deviceRow{name: name, values: visibleVals})
 	}
 
-	// Sort by selected column
+	// Sort rows
 	sortCol := currentSortColumn
 	if sortCol >= len(visibleFields) {
 		sortCol = 0
 	}
+	if sortCol < len(visibleFields) {
+		sortedField = visibleFields[sortCol]
+	}
 	sort.Slice(rows, func(i, j int) bool {
 		if sortCol == 0 {
-			// Sort by name alphabetically
 			if sortAscending {
-				return rows[i].name < rows[j].name
+				return naturalLess(rows[i].name, rows[j].name)
 			}
-			return rows[i].name > rows[j].name
+			return naturalLess(rows[j].name, rows[i].name)
 		}
-		// Parse as float for numeric comparison
 		vi, _ := strconv.ParseFloat(rows[i].values[sortCol], 64)
 		vj, _ := strconv.ParseFloat(rows[j].values[sortCol], 64)
 		if sortAscending {
@@ -1385,11 +1524,11 @@ func printPhysicalNetDevices(window *goncurses.Window) {
 		return vi > vj
 	})
 
-	// Calculate column widths dynamically (add space for sort indicator)
+	// Calculate column widths
 	widths := make([]int, len(visibleFields))
 	for i, field := range visibleFields {
-		fieldName := strings.TrimPrefix(field, "net_")
-		widths[i] = len(fieldName) + 1 // +1 for sort indicator
+		fieldName := strings.TrimPrefix(field, prefix)
+		widths[i] = len(fieldName) + 1
 		if widths[i] < 8 {
 			widths[i] = 8
 		}
@@ -1402,11 +1541,10 @@ func printPhysicalNetDevices(window *goncurses.Window) {
 		}
 	}
 
-	// Print header with sort indicator
+	// Print header
 	window.Move(0, 0)
 	for i, field := range visibleFields {
-		fieldName := strings.TrimPrefix(field, "net_")
-		// Add sort direction indicator
+		fieldName := strings.TrimPrefix(field, prefix)
 		if i == sortCol {
 			if sortAscending {
 				fieldName = fieldName + "^"
@@ -1453,140 +1591,76 @@ func printPhysicalNetDevices(window *goncurses.Window) {
 	window.NoutRefresh()
 }
 
-// printDiskDeviceView is a helper that displays disk devices from a specific category
-func printDiskDeviceView(window *goncurses.Window, deviceData map[string][]string) {
-	maxy, maxx := window.MaxYX()
-
-	// Get fields from collector
-	allFields := diskcollector.HostDiskFields()
-
-	// Filter fields based on hiddenFields (but always keep DEVICE column)
-	visibleFields := []string{}
-	visibleIndices := []int{}
-	for i, field := range allFields {
-		if i == 0 || !hiddenFields[field] {
-			visibleFields = append(visibleFields, field)
-			visibleIndices = append(visibleIndices, i)
+// naturalLess compares names with numeric suffixes by number (cpu2 < cpu10)
+func naturalLess(a, b string) bool {
+	aBase := strings.TrimRight(a, "0123456789")
+	bBase := strings.TrimRight(b, "0123456789")
+	if aBase == bBase && aBase != a && bBase != b {
+		aNum, errA := strconv.Atoi(a[len(aBase):])
+		bNum, errB := strconv.Atoi(b[len(bBase):])
+		if errA == nil && errB == nil {
+			return aNum < bNum
 		}
 	}
+	return a < b
+}
 
-	// Build rows from device data
-	type diskDevRow struct {
-		name   string
-		values []string
-	}
-	rows := make([]diskDevRow, 0, len(deviceData))
-	for name, vals := range deviceData {
-		visibleVals := make([]string, len(visibleIndices))
-		for vi, origIdx := range visibleIndices {
-			if origIdx < len(vals) {
-				visibleVals[vi] = vals[origIdx]
-			}
-		}
-		rows = append(rows, diskDevRow{name: name, values: visibleVals})
-	}
-
-	// Sort rows
-	sortCol := currentSortColumn
-	if sortCol >= len(visibleFields) {
-		sortCol = 0
-	}
-	sort.Slice(rows, func(i, j int) bool {
-		if sortCol == 0 {
-			if sortAscending {
-				return rows[i].name < rows[j].name
-			}
-			return rows[i].name > rows[j].name
-		}
-		vi, _ := strconv.ParseFloat(rows[i].values[sortCol], 64)
-		vj, _ := strconv.ParseFloat(rows[j].values[sortCol], 64)
-		if sortAscending {
-			return vi < vj
-		}
-		return vi > vj
-	})
-
-	// Calculate column widths
-	widths := make([]int, len(visibleFields))
-	for i, field := range visibleFields {
-		fieldName := strings.TrimPrefix(field, "dsk_")
-		widths[i] = len(fieldName) + 1
-		if widths[i] < 8 {
-			widths[i] = 8
-		}
-	}
-	for _, r := range rows {
-		for i, v := range r.values {
-			if i < len(widths) && len(v) > widths[i] {
-				widths[i] = len(v)
-			}
-		}
+// printOverhead displays the hypervisor overhead and guest totals above the host processes outside of guests
+func printOverhead(window *goncurses.Window, printable models.Printable) {
+	maxy, maxx := window.MaxYX()
+	if maxy < 6 {
+		return
 	}
+	summaryWin := window.Derived(3, maxx, 0, 0)
+	printSnapshotDevices(summaryWin, printable, models.DevicesOverheadSummary, "ovh_")
+	processWin := window.Derived(maxy-4, maxx, 4, 0)
+	printSnapshotDevices(processWin, printable, models.DevicesOverhead, "ovh_")
+}
 
-	// Print header
-	window.Move(0, 0)
-	for i, field := range visibleFields {
-		fieldName := strings.TrimPrefix(field, "dsk_")
-		if i == sortCol {
-			if sortAscending {
-				fieldName = fieldName + "^"
-			} else {
-				fieldName = fieldName + "v"
-			}
-			window.AttrOn(goncurses.A_BOLD | goncurses.A_REVERSE)
-		} else {
-			window.AttrOn(goncurses.A_BOLD)
-		}
-		format := fmt.Sprintf("%%%ds ", widths[i])
-		if i == 0 {
-			format = fmt.Sprintf("%%-%ds ", widths[i])
-		}
-		window.Printf(format, fieldName)
-		window.AttrOff(goncurses.A_BOLD | goncurses.A_REVERSE)
+// printDiagnostics displays the metrics of proxtop itself: phase durations per collector,
+// hypervisor calls, cache hit ratios, VMs without PID and the own CPU and memory usage
+func printDiagnostics(window *goncurses.Window, diagnostics models.Diagnostics) {
+	maxy, _ := window.MaxYX()
+	milliseconds := func(duration time.Duration) float64 {
+		return float64(duration) / float64(time.Millisecond)
 	}
-
-	// Print rows
 	row := 1
-	for _, r := range rows {
-		if row >= maxy-1 {
-			break
-		}
-		window.Move(row, 0)
-		line := ""
-		for i, v := range r.values {
-			if i >= len(widths) {
-				break
-			}
-			if i == 0 {
-				line += fmt.Sprintf("%-*s ", widths[i], v)
-			} else {
-				line += fmt.Sprintf("%*s ", widths[i], v)
-			}
-		}
-		if len(line) > maxx {
-			line = line[:maxx]
-		}
-		window.Printf("%s", line)
+	line := func(attr goncurses.Char, format string, args ...interface{}) {
+		if row >= maxy {
+			return
+		}
+		window.Move(row, 1)
+		window.AttrOn(attr)
+		window.Printf(format, args...)
+		window.AttrOff(attr)
 		row++
 	}
 
-	window.NoutRefresh()
-}
+	line(goncurses.A_BOLD, "proxtop diagnostics (press 'z' to close)")
+	row++
+	line(goncurses.A_NORMAL, "CPU %.1f%%   RSS %s   VMs without PID %d",
+		diagnostics.CPU, util.FormatBytes(diagnostics.RSS), diagnostics.UnresolvedPIDs)
+	row++
 
-// printPhysicalDiskDevices displays physical disk device statistics (sd*, nvme*, vd*, etc.)
-func printPhysicalDiskDevices(window *goncurses.Window) {
-	categorized := diskcollector.HostPrintPerDeviceCategorized()
-	printDiskDeviceView(window, categorized.Physical)
-}
+	line(goncurses.A_REVERSE, "%-16s %12s %12s %12s ", "COLLECTOR", "LOOKUP ms", "COLLECT ms", "PRINT ms")
+	for _, name := range diagnostics.Collectors() {
+		durations := diagnostics.Phases[name]
+		line(goncurses.A_NORMAL, "%-16s %12.1f %12.1f %12.1f", name,
+			milliseconds(durations.Lookup), milliseconds(durations.Collect), milliseconds(durations.Print))
+	}
+	row++
 
-// printLVMDevices displays LVM logical volume statistics
-func printLVMDevices(window *goncurses.Window) {
-	categorized := diskcollector.HostPrintPerDeviceCategorized()
-	printDiskDeviceView(window, categorized.LVM)
-}
+	line(goncurses.A_REVERSE, "%-16s %12s %12s %12s %12s ", "CALLS", "COUNT", "ERRORS", "AVG ms", "MAX ms")
+	for _, kind := range []string{models.CallQMP, models.CallQMStatus, models.CallLibvirt} {
+		stats := diagnostics.Calls[kind]
+		line(goncurses.A_NORMAL, "%-16s %12d %12d %12.1f %12.1f", kind,
+			stats.Calls, stats.Errors, milliseconds(stats.Average()), milliseconds(stats.Max))
+	}
+	row++
 
-// printMpathDevices displays multipath device statistics
-func printMpathDevices(window *goncurses.Window) {
-	categorized := diskcollector.HostPrintPerDeviceCategorized()
-	printDiskDeviceView(window, categorized.Mpath)
+	line(goncurses.A_REVERSE, "%-16s %12s %12s %12s ", "CACHE", "HITS", "MISSES", "HIT %")
+	for _, name := range []string{models.CacheQMP, models.CacheStatus} {
+		stats := diagnostics.Caches[name]
+		line(goncurses.A_NORMAL, "%-16s %12d %12d %12.1f", name, stats.Hits, stats.Misses, stats.HitRatio())
+	}
 }
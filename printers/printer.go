@@ -1,26 +1,77 @@
 package printers
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net"
 	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"proxtop/config"
+	"proxtop/models"
 )
 
 var conn net.Conn
 var file *os.File
 
+// outputMu protects the queue, the spool and the counters of the network output. Messages are sent by
+// outputSender, so a target which is down or slow does not block the printer.
+var outputMu sync.Mutex
+var outputReady = sync.NewCond(&outputMu)
+var outputQueue []string
+var outputStats models.OutputStats
+var outputClosing bool
+var outputStop chan struct{}
+var outputDone chan struct{}
+
+// outputDequeued counts the messages removed from the front of the queue, so outputSender notices when
+// the message it sends was dropped meanwhile
+var outputDequeued uint64
+
+// outputSpool is the spool file, the messages between outputSpoolRead and outputSpoolWritten are not sent yet
+var outputSpool *os.File
+var outputSpoolRead int64
+var outputSpoolWritten int64
+
+// outputSend sends a message to the target of a network output, for output 'http' set by the printer
+// writing to it. A message the target rejects is dropped if the error wraps errOutputRejected.
+var outputSend = sendOutput
+var errOutputRejected = errors.New("rejected by the target")
+
+// outputDialTimeout and outputWriteTimeout bound the time a connection attempt or a write to the target may hang
+const outputDialTimeout = 5 * time.Second
+const outputWriteTimeout = 10 * time.Second
+
+// outputNetwork returns whether the output is sent to a network target by outputSender
+func outputNetwork() bool {
+	return config.Options.Output == "tcp" || config.Options.Output == "udp" || config.Options.Output == "http"
+}
+
 // OutputOpen establishes the output channel for the printer
 func OutputOpen() {
-	if config.Options.Output == "tcp" || config.Options.Output == "udp" {
-		var err error
-		conn, err = net.Dial(config.Options.Output, config.Options.OutputTarget)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to open %s connection", config.Options.Output)
-			os.Exit(1)
+	if outputNetwork() {
+		if config.Options.OutputSpool != "" {
+			if err := openSpool(); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to open spool file: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		// connected by outputSender, an unreachable target is retried
+		outputStop = make(chan struct{})
+		outputDone = make(chan struct{})
+		models.RecordOutput(outputStats)
+		go outputSender()
+		if config.Options.Output == "http" {
+			fmt.Printf("Output will be written to %s\n", config.Options.OutputTarget)
+		} else {
+			fmt.Printf("Output will be redirected to %s://%s\n", config.Options.Output, config.Options.OutputTarget)
 		}
-		fmt.Printf("Output will be redirected to %s://%s\n", config.Options.Output, config.Options.OutputTarget)
 	} else if config.Options.Output == "file" {
 		var err error
 		file, err = os.OpenFile(config.Options.OutputTarget, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -34,14 +85,22 @@ func OutputOpen() {
 	}
 }
 
+// OutputReopen continues the output 'file' in the file target, e.g. the csv printer starting a new file
+func OutputReopen(target string) {
+	file.Close()
+	var err error
+	file, err = os.OpenFile(target, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open output file")
+		os.Exit(1)
+	}
+	log.Printf("output: continued in file://%s", target)
+}
+
 // Output takes a string and prints it to the configured output
 func Output(text string) {
-	if config.Options.Output == "tcp" || config.Options.Output == "udp" {
-		_, err := fmt.Fprintf(conn, text)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to send to network connection")
-			os.Exit(1)
-		}
+	if outputNetwork() {
+		enqueueOutput(text)
 	} else if config.Options.Output == "file" {
 		if _, err := file.WriteString(text); err != nil {
 			fmt.Fprintf(os.Stderr, "failed to write to file")
@@ -55,11 +114,280 @@ func Output(text string) {
 
 // OutputClose closes the channel for printing the output
 func OutputClose() {
-	if config.Options.Output == "tcp" || config.Options.Output == "udp" {
-		conn.Close()
+	if outputNetwork() {
+		// the queue is still sent if the target is connected, otherwise kept in the spool
+		outputMu.Lock()
+		outputClosing = true
+		outputReady.Broadcast()
+		outputMu.Unlock()
+		close(outputStop)
+		<-outputDone
+		if conn != nil {
+			conn.Close()
+		}
+		saveOutput()
 	} else if config.Options.Output == "file" {
 		file.Close()
 	} else {
 		// stdout, nothing to close
 	}
 }
+
+// enqueueOutput queues a message for outputSender. A full queue spills over into the spool file; once
+// messages are spooled, all messages are spooled until the spool is sent, so the order is kept.
+// Without spool the oldest messages are dropped.
+func enqueueOutput(text string) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	limit := config.Options.OutputQueue * 1024 * 1024
+	spooling := outputSpool != nil && outputSpoolWritten > outputSpoolRead
+	if !spooling && outputStats.QueuedBytes+len(text) <= limit {
+		outputQueue = append(outputQueue, text)
+		outputStats.QueuedBytes += len(text)
+	} else if outputSpool != nil {
+		if err := spoolOutput(text); err != nil {
+			outputStats.Dropped++
+		}
+	} else {
+		for len(outputQueue) > 0 && outputStats.QueuedBytes+len(text) > limit {
+			outputStats.QueuedBytes -= len(outputQueue[0])
+			outputQueue[0] = ""
+			outputQueue = outputQueue[1:]
+			outputDequeued++
+			outputStats.Dropped++
+		}
+		outputQueue = append(outputQueue, text)
+		outputStats.QueuedBytes += len(text)
+	}
+	outputStats.Queued = len(outputQueue)
+	models.RecordOutput(outputStats)
+	outputReady.Signal()
+}
+
+// outputSender sends the queued and spooled messages to the target in order, reconnecting with a backoff
+// doubled from 1 second up to --output-backoff while the target is unreachable. A message is only removed
+// after it was sent, messages too large for a datagram or rejected by the target are dropped.
+func outputSender() {
+	defer close(outputDone)
+	maxBackoff := time.Duration(config.Options.OutputBackoff) * time.Second
+	backoff := time.Second
+	for {
+		text, position, fromSpool, ok := nextOutput()
+		if !ok {
+			return
+		}
+		for {
+			err := outputSend(text)
+			if err == nil {
+				break
+			}
+			if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, errOutputRejected) {
+				log.Printf("output: dropped message of %d bytes: %v", len(text), err)
+				outputMu.Lock()
+				outputStats.Dropped++
+				outputMu.Unlock()
+				break
+			}
+			// keep the message, the remaining messages are sent by the next run if closing
+			select {
+			case <-outputStop:
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+		backoff = time.Second
+		removeOutput(position, fromSpool)
+	}
+}
+
+// nextOutput waits for the next message to send: the front of the queue, then the spooled messages.
+// The position is the offset of the next spooled message or the count of dequeued messages; not ok
+// when closing and no message is left.
+func nextOutput() (string, uint64, bool, bool) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	for {
+		spooled := outputSpool != nil && outputSpoolWritten > outputSpoolRead
+		if len(outputQueue) > 0 {
+			return outputQueue[0], outputDequeued, false, true
+		}
+		if spooled {
+			text, next, err := readSpool(outputSpoolRead)
+			if err == nil {
+				return text, uint64(next), true, true
+			}
+			// e.g. a message cut off by a crash while spooling
+			log.Printf("output: discarded %d bytes of spool %s: %v", outputSpoolWritten-outputSpoolRead, config.Options.OutputSpool, err)
+			resetSpool()
+			continue
+		}
+		if outputClosing {
+			return "", 0, false, false
+		}
+		outputReady.Wait()
+	}
+}
+
+// removeOutput removes a sent message from the spool or the front of the queue, unless it was dropped meanwhile
+func removeOutput(position uint64, fromSpool bool) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if fromSpool {
+		outputSpoolRead = int64(position)
+		if outputSpoolRead >= outputSpoolWritten {
+			resetSpool()
+		}
+	} else if position == outputDequeued && len(outputQueue) > 0 {
+		outputStats.QueuedBytes -= len(outputQueue[0])
+		outputQueue[0] = ""
+		outputQueue = outputQueue[1:]
+		outputDequeued++
+	}
+	outputStats.Queued = len(outputQueue)
+	outputStats.SpooledBytes = outputSpoolWritten - outputSpoolRead
+	models.RecordOutput(outputStats)
+}
+
+// sendOutput writes a message to the target, connecting first if not connected. On failure the connection is closed.
+func sendOutput(text string) error {
+	if conn == nil {
+		var err error
+		conn, err = net.DialTimeout(config.Options.Output, config.Options.OutputTarget, outputDialTimeout)
+		if err != nil {
+			log.Printf("output: failed to connect to %s://%s: %v", config.Options.Output, config.Options.OutputTarget, err)
+			return err
+		}
+		log.Printf("output: connected to %s://%s", config.Options.Output, config.Options.OutputTarget)
+		setConnected(true)
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(outputWriteTimeout))
+	if _, err := io.WriteString(conn, text); err != nil {
+		if errors.Is(err, syscall.EMSGSIZE) {
+			return err
+		}
+		log.Printf("output: lost connection to %s://%s: %v", config.Options.Output, config.Options.OutputTarget, err)
+		conn.Close()
+		conn = nil
+		setConnected(false)
+		return err
+	}
+	return nil
+}
+
+// setConnected records whether the target is connected, a lost connection counts as reconnect
+func setConnected(connected bool) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if outputStats.Connected && !connected {
+		outputStats.Reconnects++
+	}
+	outputStats.Connected = connected
+	models.RecordOutput(outputStats)
+}
+
+// openSpool opens the spool file, messages spooled by an earlier run are sent first
+func openSpool() error {
+	var err error
+	outputSpool, err = os.OpenFile(config.Options.OutputSpool, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := outputSpool.Stat()
+	if err != nil {
+		return err
+	}
+	outputSpoolWritten = info.Size()
+	outputStats.SpooledBytes = outputSpoolWritten
+	return nil
+}
+
+// spoolOutput appends a message to the spool file: its length in bytes on a line of its own, then the message
+func spoolOutput(text string) error {
+	record := strconv.Itoa(len(text)) + "\n" + text
+	if outputSpoolWritten+int64(len(record)) > int64(config.Options.OutputSpoolMax)*1024*1024 {
+		return fmt.Errorf("spool full")
+	}
+	if _, err := outputSpool.WriteString(record); err != nil {
+		// a partly written message would corrupt the spool
+		outputSpool.Truncate(outputSpoolWritten)
+		return err
+	}
+	outputSpoolWritten += int64(len(record))
+	outputStats.SpooledBytes = outputSpoolWritten - outputSpoolRead
+	return nil
+}
+
+// readSpool reads the spooled message at offset, returns it and the offset of the next message
+func readSpool(offset int64) (string, int64, error) {
+	header := make([]byte, 24)
+	n, err := outputSpool.ReadAt(header, offset)
+	if n == 0 {
+		return "", 0, err
+	}
+	end := bytes.IndexByte(header[:n], '\n')
+	if end < 0 {
+		return "", 0, fmt.Errorf("invalid message length")
+	}
+	length, err := strconv.Atoi(string(header[:end]))
+	if err != nil || length < 0 {
+		return "", 0, fmt.Errorf("invalid message length")
+	}
+	text := make([]byte, length)
+	if n, err := outputSpool.ReadAt(text, offset+int64(end)+1); n < length {
+		return "", 0, err
+	}
+	return string(text), offset + int64(end) + 1 + int64(length), nil
+}
+
+// resetSpool empties the spool file once all spooled messages are sent
+func resetSpool() {
+	outputSpool.Truncate(0)
+	outputSpoolRead = 0
+	outputSpoolWritten = 0
+	outputStats.SpooledBytes = 0
+}
+
+// saveOutput keeps the messages not sent when closing: the queue is put in front of the spooled messages,
+// so the next run sends them in order. Without spool they are dropped.
+func saveOutput() {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if outputSpool == nil {
+		if len(outputQueue) > 0 {
+			log.Printf("output: dropped %d messages not sent to %s://%s", len(outputQueue), config.Options.Output, config.Options.OutputTarget)
+			outputStats.Dropped += uint64(len(outputQueue))
+		}
+		return
+	}
+	defer outputSpool.Close()
+	if len(outputQueue) == 0 && outputSpoolRead == 0 {
+		return
+	}
+
+	// the spool is written again without the messages sent
+	path := config.Options.OutputSpool + ".tmp"
+	spool, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		log.Printf("output: dropped %d messages, failed to write spool: %v", len(outputQueue), err)
+		return
+	}
+	for _, text := range outputQueue {
+		spool.WriteString(strconv.Itoa(len(text)) + "\n" + text)
+	}
+	_, err = io.Copy(spool, io.NewSectionReader(outputSpool, outputSpoolRead, outputSpoolWritten-outputSpoolRead))
+	if closeErr := spool.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(path, config.Options.OutputSpool)
+	}
+	if err != nil {
+		log.Printf("output: dropped %d messages, failed to write spool: %v", len(outputQueue), err)
+		os.Remove(path)
+	}
+}
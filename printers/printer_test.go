@@ -0,0 +1,133 @@
+package printers
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// recordingTarget is a network target for outputSend, it accepts the first accept messages and fails the others
+type recordingTarget struct {
+	mu     sync.Mutex
+	accept int
+	sent   []string
+}
+
+func (target *recordingTarget) send(text string) error {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	if len(target.sent) >= target.accept {
+		return errors.New("connection refused")
+	}
+	target.sent = append(target.sent, text)
+	return nil
+}
+
+func (target *recordingTarget) messages() []string {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	return append([]string{}, target.sent...)
+}
+
+// runOutput opens the network output with a fresh state, sends the messages and closes it again
+func runOutput(t *testing.T, target *recordingTarget, messages ...string) {
+	t.Helper()
+	outputQueue = nil
+	outputStats = models.OutputStats{}
+	outputClosing = false
+	outputDequeued = 0
+	outputSpool = nil
+	outputSpoolRead = 0
+	outputSpoolWritten = 0
+	outputSend = target.send
+
+	OutputOpen()
+	for _, text := range messages {
+		Output(text)
+	}
+	// wait for the messages the target accepts
+	deadline := time.Now().Add(5 * time.Second)
+	for len(target.messages()) < target.accept && len(target.messages()) < len(messages) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	OutputClose()
+}
+
+func TestOutputSpoolReplay(t *testing.T) {
+	directory, err := ioutil.TempDir("", "proxtop-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	saved := config.Options
+	savedSend := outputSend
+	defer func() {
+		config.Options = saved
+		outputSend = savedSend
+	}()
+	config.Options.Output = "tcp"
+	config.Options.OutputTarget = "localhost:0"
+	config.Options.OutputSpool = filepath.Join(directory, "spool")
+	config.Options.OutputQueue = 1
+	config.Options.OutputSpoolMax = 1
+	config.Options.OutputBackoff = 1
+
+	// the target goes away after the first message, the others are spooled when closing
+	first := &recordingTarget{accept: 1}
+	runOutput(t, first, "one\n", "two\nlines\n", strings.Repeat("x", 100)+"\n")
+	if want := []string{"one\n"}; !reflect.DeepEqual(first.messages(), want) {
+		t.Fatalf("first run sent %q, want %q", first.messages(), want)
+	}
+	spooled, err := ioutil.ReadFile(config.Options.OutputSpool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "10\ntwo\nlines\n101\n" + strings.Repeat("x", 100) + "\n"; string(spooled) != want {
+		t.Errorf("spool %q, want %q", spooled, want)
+	}
+
+	// the next run sends the spooled messages first, then the new ones
+	second := &recordingTarget{accept: 10}
+	runOutput(t, second, "four\n")
+	if want := []string{"two\nlines\n", strings.Repeat("x", 100) + "\n", "four\n"}; !reflect.DeepEqual(second.messages(), want) {
+		t.Errorf("second run sent %q, want %q", second.messages(), want)
+	}
+	if info, err := os.Stat(config.Options.OutputSpool); err != nil || info.Size() != 0 {
+		t.Errorf("spool not emptied after replay: %v, %v", info, err)
+	}
+}
+
+func TestReadSpoolCorrupted(t *testing.T) {
+	spool, err := ioutil.TempFile("", "proxtop-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+	saved := outputSpool
+	outputSpool = spool
+	defer func() { outputSpool = saved }()
+
+	// a valid message, a message cut off by a crash and a header which is no length
+	spool.WriteString("3\nabc5\nab")
+	text, next, err := readSpool(0)
+	if err != nil || text != "abc" || next != 5 {
+		t.Errorf("readSpool(0) = %q, %d, %v, want abc, 5", text, next, err)
+	}
+	if _, _, err := readSpool(next); err == nil {
+		t.Error("readSpool of a cut off message succeeded, want error")
+	}
+	spool.WriteString("\nx\n")
+	if _, _, err := readSpool(next + 5); err == nil {
+		t.Error("readSpool of an invalid length succeeded, want error")
+	}
+}
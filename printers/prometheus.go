@@ -0,0 +1,273 @@
+package printers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"proxtop/config"
+	"proxtop/models"
+)
+
+// PrometheusPrinter serves the last snapshot on /metrics in the Prometheus text format, or
+// OpenMetrics if the scraper asks for it
+type PrometheusPrinter struct {
+	models.Printer
+}
+
+// prometheusMu protects lastSnapshot, the snapshot rendered on each scrape
+var prometheusMu sync.Mutex
+var lastSnapshot *models.Printable
+var prometheusServer *http.Server
+
+// node is the node label of all metrics
+var node string
+
+// metricNameInvalid matches the runs of characters not allowed in metric names and underscores next to them,
+// e.g. cpu_%USED becomes cpu_used
+var metricNameInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// prometheusUnits maps the units of printed fields to the base unit of the exported gauge and the factor to convert
+var prometheusUnits = map[models.Unit]struct {
+	base  string
+	scale float64
+}{
+	models.UnitNone:           {"", 1},
+	models.UnitPercent:        {"ratio", 0.01},
+	models.UnitBytes:          {"bytes", 1},
+	models.UnitKibibytes:      {"bytes", 1024},
+	models.UnitBytesPerSecond: {"bytes_per_second", 1},
+	models.UnitMBPerSecond:    {"bytes_per_second", 1024 * 1024},
+	models.UnitMbitPerSecond:  {"bits_per_second", 1000000},
+	models.UnitPerSecond:      {"per_second", 1},
+	models.UnitMicroseconds:   {"seconds", 1e-6},
+	models.UnitMilliseconds:   {"seconds", 1e-3},
+	models.UnitSeconds:        {"seconds", 1},
+	models.UnitMegahertz:      {"hertz", 1000000},
+	models.UnitWatts:          {"watts", 1},
+	models.UnitCelsius:        {"celsius", 1},
+	models.UnitRPM:            {"rpm", 1},
+}
+
+// metricFamily holds the samples of one metric name
+type metricFamily struct {
+	name        string
+	kind        models.Kind
+	unit        string
+	description string
+	samples     []string
+}
+
+// Open starts serving /metrics on --listen
+func (printer *PrometheusPrinter) Open() error {
+	node, _ = os.Hostname()
+
+	listener, err := net.Listen("tcp", config.Options.Listen)
+	if err != nil {
+		log.Printf("prometheus: failed to listen on %s: %v", config.Options.Listen, err)
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", serveMetrics)
+	prometheusServer = &http.Server{Handler: mux}
+	go prometheusServer.Serve(listener)
+	fmt.Printf("Metrics will be served on http://%s/metrics\n", listener.Addr())
+	return nil
+}
+
+// Screen keeps the snapshot for the next scrape
+func (printer *PrometheusPrinter) Screen(printable models.Printable) {
+	prometheusMu.Lock()
+	defer prometheusMu.Unlock()
+	lastSnapshot = &printable
+}
+
+// Close stops serving /metrics
+func (printer *PrometheusPrinter) Close() {
+	if prometheusServer != nil {
+		prometheusServer.Close()
+	}
+}
+
+// CreatePrometheus creates a new prometheus exporter
+func CreatePrometheus() PrometheusPrinter {
+	return PrometheusPrinter{}
+}
+
+// serveMetrics renders the last snapshot, OpenMetrics if accepted by the scraper
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	prometheusMu.Lock()
+	snapshot := lastSnapshot
+	prometheusMu.Unlock()
+	if snapshot == nil {
+		http.Error(w, "no collection cycle finished yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	}
+	w.Write(renderMetrics(*snapshot, openMetrics))
+}
+
+// renderMetrics renders the gauges of the printed fields and the raw counters of a snapshot.
+// Rates are left out, they are computed by Prometheus from the counters.
+func renderMetrics(printable models.Printable, openMetrics bool) []byte {
+	families := make(map[string]*metricFamily)
+	order := []string{}
+	add := func(name string, kind models.Kind, unit string, description string, labels []string, value float64) {
+		family, exists := families[name]
+		if !exists {
+			family = &metricFamily{name: name, kind: kind, unit: unit, description: description}
+			families[name] = family
+			order = append(order, name)
+		}
+		sample := name
+		if kind == models.KindCounter {
+			sample += "_total"
+		}
+		if len(labels) > 0 {
+			sample += "{" + strings.Join(labels, ",") + "}"
+		}
+		family.samples = append(family.samples, sample+" "+strconv.FormatFloat(value, 'g', -1, 64))
+	}
+
+	// gauges of the host
+	hostLabels := metricLabels("", "", "", "")
+	for i, field := range printable.HostFields {
+		if i >= len(printable.HostValues) {
+			break
+		}
+		if name, def, value, ok := gaugeValue("host", models.ScopeHost, field, printable.HostValues[i]); ok {
+			add(name, models.KindGauge, prometheusUnits[def.Unit].base, def.Description, hostLabels, value)
+		}
+	}
+
+	// gauges of the VMs, sorted by UUID for a stable output
+	uuids := make([]string, 0, len(printable.DomainValues))
+	for uuid := range printable.DomainValues {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	for _, uuid := range uuids {
+		values := printable.DomainValues[uuid]
+		labels := domainLabels(printable, uuid, "")
+		stale := 0.0
+		if printable.StaleDomains[uuid] {
+			stale = 1
+		}
+		add("proxtop_vm_stale", models.KindGauge, "", "Whether the values of the VM are from an earlier cycle, its circuit breaker is open", labels, stale)
+		for i, field := range printable.DomainFields {
+			if i >= len(values) {
+				break
+			}
+			if name, def, value, ok := gaugeValue("vm", models.ScopeDomain, field, values[i]); ok {
+				add(name, models.KindGauge, prometheusUnits[def.Unit].base, def.Description, labels, value)
+			}
+		}
+	}
+
+	// raw counters of the host and the VMs
+	for _, sample := range printable.Counters {
+		name := "proxtop_host_" + sample.Def.Name
+		labels := metricLabels("", "", "", sample.Device)
+		if sample.UUID != "" {
+			name = "proxtop_vm_" + sample.Def.Name
+			labels = domainLabels(printable, sample.UUID, sample.Device)
+		}
+		if sample.Def.BaseUnit != "" {
+			name += "_" + sample.Def.BaseUnit
+		}
+		add(name, models.KindCounter, sample.Def.BaseUnit, sample.Def.Description, labels, sample.Value)
+	}
+
+	var buffer bytes.Buffer
+	for _, name := range order {
+		family := families[name]
+		// the text format names counters with their suffix, OpenMetrics without
+		header := name
+		if family.kind == models.KindCounter && !openMetrics {
+			header += "_total"
+		}
+		fmt.Fprintf(&buffer, "# HELP %s %s\n", header, escapeHelp(family.description, openMetrics))
+		fmt.Fprintf(&buffer, "# TYPE %s %s\n", header, family.kind)
+		if openMetrics && family.unit != "" {
+			fmt.Fprintf(&buffer, "# UNIT %s %s\n", name, family.unit)
+		}
+		for _, sample := range family.samples {
+			buffer.WriteString(sample)
+			buffer.WriteString("\n")
+		}
+	}
+	if openMetrics {
+		buffer.WriteString("# EOF\n")
+	}
+	return buffer.Bytes()
+}
+
+// gaugeValue returns the metric name, declaration and value of a printed gauge field,
+// not ok for other kinds, undeclared fields and values which are no number (e.g. reset markers)
+func gaugeValue(prefix string, scope models.Scope, field string, printed string) (string, models.FieldDef, float64, bool) {
+	def, exists := models.LookupField(scope, field)
+	if !exists || def.Kind != models.KindGauge {
+		return "", def, 0, false
+	}
+	value, err := def.Unit.Parse(printed)
+	if err != nil {
+		return "", def, 0, false
+	}
+	unit := prometheusUnits[def.Unit]
+	name := "proxtop_" + prefix + "_" + strings.Trim(metricNameInvalid.ReplaceAllString(strings.ToLower(field), "_"), "_")
+	if unit.base != "" && !strings.HasSuffix(name, "_"+unit.base) {
+		name += "_" + unit.base
+	}
+	return name, def, value * unit.scale, true
+}
+
+// domainLabels returns the labels of the metrics of a VM
+func domainLabels(printable models.Printable, uuid string, device string) []string {
+	name := ""
+	if values := printable.DomainValues[uuid]; len(values) > 1 {
+		name = values[1]
+	}
+	return metricLabels(printable.VMIDs[uuid], uuid, name, device)
+}
+
+// metricLabels returns the node label and the given labels which are set, in the order vmid, uuid, name, device
+func metricLabels(vmid string, uuid string, name string, device string) []string {
+	labels := []string{fmt.Sprintf("node=\"%s\"", escapeLabel(node))}
+	for _, label := range [][2]string{{"vmid", vmid}, {"uuid", uuid}, {"name", name}, {"device", device}} {
+		if label[1] != "" {
+			labels = append(labels, fmt.Sprintf("%s=\"%s\"", label[0], escapeLabel(label[1])))
+		}
+	}
+	return labels
+}
+
+// escapeLabel escapes a label value of the exposition formats
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// helpReplacer and openMetricsHelpReplacer escape a HELP text, OpenMetrics escapes double quotes as well
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var openMetricsHelpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// escapeHelp escapes a HELP text of the exposition format
+func escapeHelp(value string, openMetrics bool) string {
+	if openMetrics {
+		return openMetricsHelpReplacer.Replace(value)
+	}
+	return helpReplacer.Replace(value)
+}
@@ -0,0 +1,60 @@
+package printers
+
+import (
+	"strings"
+	"testing"
+
+	"proxtop/models"
+)
+
+func TestEscapeHelp(t *testing.T) {
+	tests := []struct {
+		help        string
+		text        string
+		openMetrics string
+	}{
+		{"plain", "plain", "plain"},
+		{`C:\dir`, `C:\\dir`, `C:\\dir`},
+		{"two\nlines", `two\nlines`, `two\nlines`},
+		{`"quoted"`, `"quoted"`, `\"quoted\"`},
+		{"\\n\n\"", `\\n\n"`, `\\n\n\"`},
+	}
+	for _, test := range tests {
+		if got := escapeHelp(test.help, false); got != test.text {
+			t.Errorf("escapeHelp(%q, false) = %q, want %q", test.help, got, test.text)
+		}
+		if got := escapeHelp(test.help, true); got != test.openMetrics {
+			t.Errorf("escapeHelp(%q, true) = %q, want %q", test.help, got, test.openMetrics)
+		}
+	}
+}
+
+func TestRenderMetricsHelp(t *testing.T) {
+	models.RegisterFields("test", models.ScopeHost, []models.FieldDef{
+		{Name: "t_sensor", Kind: models.KindGauge, Unit: models.UnitCelsius, Description: "Sensor \"temp1\" of C:\\hwmon\nsecond line"},
+	})
+	printable := models.Printable{HostFields: []string{"t_sensor"}, HostValues: []string{"42"}}
+
+	tests := []struct {
+		openMetrics bool
+		help        string
+	}{
+		{false, `Sensor "temp1" of C:\\hwmon\nsecond line`},
+		{true, `Sensor \"temp1\" of C:\\hwmon\nsecond line`},
+	}
+	for _, test := range tests {
+		rendered := string(renderMetrics(printable, test.openMetrics))
+		found := false
+		for _, line := range strings.Split(rendered, "\n") {
+			if strings.HasPrefix(line, "# HELP ") && strings.Contains(line, "t_sensor") {
+				found = true
+				if !strings.HasSuffix(line, " "+test.help) {
+					t.Errorf("openMetrics %v: %q, want help %q", test.openMetrics, line, test.help)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("openMetrics %v: no HELP line of t_sensor in\n%s", test.openMetrics, rendered)
+		}
+	}
+}
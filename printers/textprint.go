@@ -14,8 +14,9 @@ type TextPrinter struct {
 }
 
 // Open opens the printer
-func (printer *TextPrinter) Open() {
+func (printer *TextPrinter) Open() error {
 	OutputOpen()
+	return nil
 }
 
 // Screen prints the measurements on the screen
@@ -53,10 +54,14 @@ func (printer *TextPrinter) Screen(printable models.Printable) {
 	}
 
 	// iterate over domains
-	for _, domvalue := range values {
+	for uuid, domvalue := range values {
 		for _, value := range domvalue {
 			Output(fmt.Sprintf("%s\t", value))
 		}
+		// values of a VM skipped by its circuit breaker are from an earlier cycle
+		if printable.StaleDomains[uuid] {
+			Output(fmt.Sprint("stale\t"))
+		}
 		Output(fmt.Sprint("\n"))
 	}
 }
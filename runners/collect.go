@@ -1,35 +1,122 @@
 package runners
 
 import (
+	"context"
+	"log"
+	"sort"
 	"sync"
+	"time"
 
+	"proxtop/config"
 	"proxtop/models"
 )
 
-// InitializeCollect starts the periodic collect calls
-func InitializeCollect(wg *sync.WaitGroup) {
-	for {
-		// wait with execution for lookup routine
-		_, ok := <-initialLookupDone
-		if !ok {
-			wg.Done()
-			return
-		}
-		Collect()
-	}
-}
+// busyMu protects busy, the collectors still running a lookup or collect phase by name, and
+// stale, the collectors which did not finish their last phase within --collector-timeout
+var busyMu sync.Mutex
+var busy = make(map[string]bool)
+var stale = make(map[string]bool)
 
-// Collect runs one collect cycle to measure frequently changing metrics
+// Collect runs one collect cycle to measure frequently changing metrics, returns when all collectors are done
 func Collect() {
-	// initialize models
-	/*if models.Collection.Domains.Length() <= 0 {
-		// wait for lookup to create domains
-		return
-	}*/
+	runCollectors("collect", func(collector models.Collector) {
+		collector.Collect()
+	})
+}
+
+// runCollectors calls run for every collector, at most --parallel at the same time, and waits for them
+// until --collector-timeout. A collector not done by then is left running and marked stale, it is
+// not started again until it returned.
+func runCollectors(phase string, run func(collector models.Collector)) {
+	parallel := config.Options.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	slots := make(chan bool, parallel)
+
+	ctx, cancel := context.WithTimeout(context.Background(), models.CollectorTimeout())
+	defer cancel()
 
-	// run collectors
-	models.Collection.Collectors.Range(func(_ interface{}, collector models.Collector) bool {
-		go collector.Collect()
+	var wg sync.WaitGroup
+	models.Collection.Collectors.Range(func(key interface{}, collector models.Collector) bool {
+		wg.Add(1)
+		go func(name string, c models.Collector) {
+			defer wg.Done()
+			select {
+			case slots <- true:
+			case <-ctx.Done():
+				markStale(name, true)
+				return
+			}
+			defer func() { <-slots }()
+			runCollector(ctx, name, phase, func() {
+				run(c)
+			})
+		}(key.(string), collector)
 		return true
 	})
+	wg.Wait()
+}
+
+// runCollector runs one phase of the collector name unless it is still busy, waiting until ctx is done.
+// The duration of the phase is recorded when it returned.
+func runCollector(ctx context.Context, name string, phase string, run func()) {
+	busyMu.Lock()
+	if busy[name] {
+		busyMu.Unlock()
+		return
+	}
+	busy[name] = true
+	busyMu.Unlock()
+
+	done := make(chan bool, 1)
+	go func() {
+		start := time.Now()
+		defer func() {
+			models.RecordPhase(name, phase, time.Since(start))
+			busyMu.Lock()
+			busy[name] = false
+			busyMu.Unlock()
+			done <- true
+		}()
+		run()
+	}()
+
+	select {
+	case <-done:
+		markStale(name, false)
+	case <-ctx.Done():
+		log.Printf("collector %s: not done within %s, continuing without it", name, models.CollectorTimeout())
+		markStale(name, true)
+	}
+}
+
+// collectorBusy returns whether a phase of the collector name is still running
+func collectorBusy(name string) bool {
+	busyMu.Lock()
+	defer busyMu.Unlock()
+	return busy[name]
+}
+
+// markStale records whether the values of a collector are from an earlier cycle
+func markStale(name string, isStale bool) {
+	busyMu.Lock()
+	defer busyMu.Unlock()
+	if isStale {
+		stale[name] = true
+	} else {
+		delete(stale, name)
+	}
+}
+
+// staleCollectors returns the sorted names of the collectors whose values are from an earlier cycle
+func staleCollectors() []string {
+	busyMu.Lock()
+	defer busyMu.Unlock()
+	names := make([]string, 0, len(stale))
+	for name := range stale {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
@@ -0,0 +1,185 @@
+package runners
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"proxtop/config"
+	"proxtop/connector"
+	"proxtop/models"
+	"proxtop/util"
+)
+
+// collectors holds the names of the collectors in print order
+var collectors []string
+
+// refreshRequests asks for the snapshot of the last cycle again, e.g. after the units were toggled
+var refreshRequests = make(chan bool, 1)
+
+// printables holds the last printable of each collector, used instead while the collector is still
+// running after --collector-timeout, so it is not read while it writes its measurements
+var printables = make(map[string]models.Printable)
+
+// InitializeCycles runs the collection cycles: lookup, collect, derive and publish one snapshot per cycle.
+// Without publish only lookup and collect run.
+func InitializeCycles(wg *sync.WaitGroup, publish func(snapshot models.Printable)) {
+	// define collectors and their order
+	models.Collection.Collectors.Range(func(key interface{}, collector models.Collector) bool {
+		collectors = append(collectors, key.(string))
+		return true
+	})
+	sort.Strings(collectors)
+
+	for n := 0; config.Options.Runs == -1 || n < config.Options.Runs; n++ {
+		// Skip collection when paused (overlay shown)
+		if CollectionPaused {
+			time.Sleep(100 * time.Millisecond)
+			n-- // Don't count paused iterations
+			continue
+		}
+
+		start := time.Now()
+		Lookup()
+		Collect()
+		models.RecordCPU()
+		var snapshot models.Printable
+		if publish != nil {
+			snapshot = Snapshot(start)
+			publish(snapshot)
+		}
+		waitForNextCycle(n, start, snapshot, publish)
+	}
+	wg.Done()
+}
+
+// waitForNextCycle sleeps until the next cycle is due, publishing the snapshot of the cycle again on refresh
+// requests, with the sizes formatted for the current settings
+func waitForNextCycle(n int, start time.Time, snapshot models.Printable, publish func(snapshot models.Printable)) {
+	for {
+		// Fast startup: for first two runs, use very short delay (200ms)
+		// This allows differential metrics to be calculated quickly
+		// After that, use normal frequency
+		next := start.Add(time.Duration(config.Options.Frequency) * time.Second)
+		if n <= 1 {
+			next = start.Add(200 * time.Millisecond)
+		}
+		wait := next.Sub(time.Now())
+		if wait <= 0 {
+			return
+		}
+		select {
+		case <-refreshRequests:
+			if publish != nil {
+				// the events of the cycle were printed already
+				refreshed := snapshot.Reformatted()
+				refreshed.Events = nil
+				publish(refreshed)
+			}
+		case <-time.After(wait):
+			return
+		}
+	}
+}
+
+// Snapshot merges the printables of all collectors and the derived fields to the snapshot of the cycle started at start
+func Snapshot(start time.Time) models.Printable {
+	printable := models.Printable{
+		Timestamp:       start,
+		StaleDomains:    models.StaleDomains(),
+		StaleCollectors: staleCollectors(),
+		HumanReadable:   config.Options.HumanReadable,
+	}
+
+	// add general domain fields first
+	printable.DomainFields = []string{"UUID", "name"}
+	printable.DomainValues = make(map[string][]string)
+	printable.VMIDs = make(map[string]string)
+	models.Collection.Domains.Range(func(key, value interface{}) bool {
+		uuid := key.(string)
+		domain := value.(models.Domain)
+		printable.DomainValues[uuid] = []string{
+			uuid,
+			domain.Name,
+		}
+		if vmInfo, ok := connector.ProxmoxVMStore.Load(uuid); ok {
+			printable.VMIDs[uuid] = vmInfo.VMID
+		}
+		return true
+	})
+
+	// collect fields for each collector and merge together
+	for _, collectorName := range collectors {
+		collector, ok := models.Collection.Collectors.Load(collectorName)
+		if !ok {
+			continue
+		}
+		collectorPrintable, ok := printables[collectorName]
+		if !collectorBusy(collectorName) {
+			printStart := time.Now()
+			collectorPrintable = collector.Print()
+			models.RecordPhase(collectorName, "print", time.Since(printStart))
+			printables[collectorName] = collectorPrintable
+		} else if !ok {
+			continue
+		}
+
+		// merge host data
+		printable.HostFields = append(printable.HostFields, collectorPrintable.HostFields[0:]...)
+		printable.HostValues = append(printable.HostValues, collectorPrintable.HostValues[0:]...)
+
+		// merge domain data, VMs the collector has no values of yet (e.g. of an earlier cycle) are left empty
+		printable.DomainFields = append(printable.DomainFields, collectorPrintable.DomainFields[0:]...)
+		for uuid := range printable.DomainValues {
+			values, exists := collectorPrintable.DomainValues[uuid]
+			if !exists {
+				values = make([]string, len(collectorPrintable.DomainFields))
+				for i := range values {
+					values[i] = "-"
+				}
+			}
+			printable.DomainValues[uuid] = append(printable.DomainValues[uuid], values...)
+		}
+
+		// merge device tables
+		for name, table := range collectorPrintable.Devices {
+			printable.AddDevices(name, table.Fields, table.Rows)
+		}
+		for uuid, tables := range collectorPrintable.DomainDevices {
+			for name, table := range tables {
+				printable.AddDomainDevices(uuid, name, table.Fields, table.Rows)
+			}
+		}
+	}
+
+	// user-defined fields computed from the merged fields
+	models.ApplyDerivedFields(&printable)
+
+	// values computed across counter resets are shown as reset marker by all printers
+	models.MarkResets(printable.HostValues)
+	for _, values := range printable.DomainValues {
+		models.MarkResets(values)
+	}
+	for _, table := range printable.Devices {
+		for _, values := range table.Rows {
+			models.MarkResets(values)
+		}
+	}
+	for _, tables := range printable.DomainDevices {
+		for _, table := range tables {
+			for _, values := range table.Rows {
+				models.MarkResets(values)
+			}
+		}
+	}
+
+	// events go to the json printer with the snapshot and to the --events target
+	printable.Events = models.TakeEvents()
+	for _, event := range printable.Events {
+		util.EmitEvent(event)
+	}
+
+	printable.Counters = models.CounterSamples()
+	printable.Diagnostics = models.CurrentDiagnostics()
+	return printable
+}
@@ -1,12 +1,11 @@
 package runners
 
 import (
+	"context"
 	"log"
 	"strings"
-	"sync"
 	"time"
 
-	"proxtop/config"
 	"proxtop/connector"
 	"proxtop/models"
 	"proxtop/util"
@@ -15,61 +14,26 @@ import (
 
 var processes []int
 
-
-// InitializeLookup starts the periodic lookup calls
-func InitializeLookup(wg *sync.WaitGroup) {
-
-	for n := -1; config.Options.Runs == -1 || n < config.Options.Runs; n++ {
-		// Skip collection when paused (overlay shown)
-		if CollectionPaused {
-			time.Sleep(100 * time.Millisecond)
-			n-- // Don't count paused iterations
-			continue
-		}
-
-		// execution, then sleep
-		start := time.Now()
-		Lookup()
-		initialLookupDone <- true
-
-		// Fast startup: for first two runs, use very short delay (200ms)
-		// This allows differential metrics to be calculated quickly
-		// After that, use normal frequency
-		var sleepDuration time.Duration
-		if n <= 1 {
-			sleepDuration = 200 * time.Millisecond
-		} else {
-			freq := time.Duration(config.Options.Frequency) * time.Second
-			nextRun := start.Add(freq)
-			sleepDuration = nextRun.Sub(time.Now())
-		}
-		if sleepDuration > 0 {
-			time.Sleep(sleepDuration)
-		}
-	}
-	close(initialLookupDone)
-	wg.Done()
-}
+// lookupName is the name the VM discovery is marked stale with when the hypervisor does not respond in time
+const lookupName = "lookup"
 
 // Lookup runs one lookup cycle to detect rather static metrics
 func Lookup() {
-	if connector.IsProxmox() {
-		lookupProxmox()
-	} else {
-		lookupLibvirt()
-	}
+	// discover VMs, a hypervisor not responding in time keeps the VMs of the last lookup
+	ctx, cancel := context.WithTimeout(context.Background(), models.CollectorTimeout())
+	defer cancel()
+	runCollector(ctx, lookupName, "lookup", func() {
+		if connector.IsProxmox() {
+			lookupProxmox()
+		} else {
+			lookupLibvirt()
+		}
+	})
 
 	// call collector lookup functions in parallel for faster startup
-	var lookupWg sync.WaitGroup
-	models.Collection.Collectors.Range(func(_ interface{}, collector models.Collector) bool {
-		lookupWg.Add(1)
-		go func(c models.Collector) {
-			defer lookupWg.Done()
-			c.Lookup()
-		}(collector)
-		return true
+	runCollectors("lookup", func(collector models.Collector) {
+		collector.Lookup()
 	})
-	lookupWg.Wait()
 }
 
 // lookupProxmox discovers VMs using Proxmox connector
@@ -110,6 +74,8 @@ func lookupProxmox() {
 		} else {
 			domain = connector.DomainFromVMInfo(vm)
 		}
+		// counters of a restarted VM start again
+		domain.SetIdentity(uint64(domain.PID))
 
 		// write back domain
 		models.Collection.Domains.Store(vm.UUID, domain)
@@ -125,7 +91,9 @@ func lookupProxmox() {
 // lookupLibvirt discovers VMs using libvirt connector
 func lookupLibvirt() {
 	// query libvirt
+	start := time.Now()
 	doms, err := connector.Libvirt.Connection.ListAllDomains(libvirt.CONNECT_LIST_DOMAINS_ACTIVE)
+	models.RecordCall(models.CallLibvirt, start, err)
 	if err != nil {
 		log.Printf("Cannot get list of domains from libvirt.")
 		return
@@ -193,6 +161,8 @@ func handleDomain(dom libvirt.Domain) (models.Domain, error) {
 		}
 	}
 	domain.PID = pid
+	// counters of a restarted VM start again
+	domain.SetIdentity(uint64(domain.PID))
 
 	// write back domain
 	models.Collection.Domains.Store(uuid, domain)
@@ -2,9 +2,9 @@ package runners
 
 import (
 	"sync"
-)
 
-var initialLookupDone chan bool
+	"proxtop/models"
+)
 
 // CollectionPaused is set to true when overlays are shown to pause data collection
 // This allows the UI to remain responsive during help/field selection
@@ -14,16 +14,23 @@ var CollectionPaused bool = false
 // Used when settings change (e.g., human-readable toggle)
 var ForceRefresh bool = false
 
-// InitializeRunners starts necessary runners as threads
-func InitializeRunners() {
-	var wg sync.WaitGroup
-	wg.Add(3) // terminate when all threads terminate
+// InitializeRunners opens the printer and starts the collection cycles and the printer as threads,
+// no cycle runs if the printer cannot be opened
+func InitializeRunners() error {
+	// open configured printer
+	if err := models.Collection.Printer.Open(); err != nil {
+		return err
+	}
 
-	initialLookupDone = make(chan bool, 1)
+	var wg sync.WaitGroup
+	wg.Add(2) // terminate when all threads terminate
 
-	go InitializeLookup(&wg)
-	go InitializeCollect(&wg)
 	go InitializePrinter(&wg)
+	go func() {
+		InitializeCycles(&wg, publishSnapshot)
+		close(snapshots)
+	}()
 
 	wg.Wait()
+	return nil
 }
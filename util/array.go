@@ -9,3 +9,13 @@ func RemoveFromArray(s []string, r string) []string {
 	}
 	return s
 }
+
+// ContainsString checks if array `s` contains the element `e`
+func ContainsString(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"proxtop/config"
+)
+
+var eventsMu sync.Mutex
+
+// EmitEvent writes an event as one JSON line to the events target (--events). Without target the event
+// is logged, which is discarded by the ncurses printer, unless the json printer prints it with the snapshot.
+func EmitEvent(event interface{}) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Cannot encode event: %v", err)
+		return
+	}
+
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+
+	switch config.Options.Events {
+	case "":
+		if config.Options.Printer != "json" {
+			log.Printf("event: %s", line)
+		}
+	case "-":
+		fmt.Printf("%s\n", line)
+	default:
+		file, err := os.OpenFile(config.Options.Events, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Printf("Cannot open events file %s: %v", config.Options.Events, err)
+			return
+		}
+		defer file.Close()
+		file.Write(append(line, '\n'))
+	}
+}
@@ -37,3 +37,24 @@ func FormatBytesFromString(bytesStr string) string {
 	return FormatBytes(bytes)
 }
 
+// ParseBytes converts a human-readable size of FormatBytes back to bytes, precision is lost by its rounding
+func ParseBytes(size string) (float64, error) {
+	multipliers := map[byte]float64{
+		'K': 1 << 10,
+		'M': 1 << 20,
+		'G': 1 << 30,
+		'T': 1 << 40,
+	}
+	multiplier := 1.0
+	if len(size) > 0 {
+		if unit, exists := multipliers[size[len(size)-1]]; exists {
+			multiplier = unit
+			size = size[:len(size)-1]
+		}
+	}
+	value, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return 0, err
+	}
+	return value * multiplier, nil
+}
@@ -0,0 +1,221 @@
+package util
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// rtnetlink message types and attributes for traffic control,
+// cf. include/uapi/linux/rtnetlink.h and include/uapi/linux/gen_stats.h
+const (
+	rtmNewQdisc  = 36
+	rtmGetQdisc  = 38
+	rtmNewTClass = 40
+	rtmGetTClass = 42
+
+	tcaKind   = 1
+	tcaStats  = 3
+	tcaStats2 = 7
+
+	tcaStatsBasic = 1
+	tcaStatsQueue = 3
+	tcaStatsPkt64 = 8
+
+	sizeofTcMsg = 20
+)
+
+// TCParentIngress is the parent id of the ingress and clsact qdiscs (TC_H_INGRESS)
+const TCParentIngress uint32 = 0xfffffff1
+
+// TCParentRoot is the parent id of a root qdisc (TC_H_ROOT)
+const TCParentRoot uint32 = 0xffffffff
+
+// netlink uses host byte order, proxtop runs on little endian hosts (amd64, arm64)
+var nativeEndian = binary.LittleEndian
+
+// NetlinkTCStat describes the statistics of one tc qdisc or class of a network device
+type NetlinkTCStat struct {
+	Ifindex int
+	Kind    string // e.g. htb, ingress, pfifo_fast
+	Handle  uint32
+	Parent  uint32
+	Class   bool
+
+	Bytes      uint64
+	Packets    uint64
+	Drops      uint64
+	Overlimits uint64
+	Requeues   uint64
+	Backlog    uint64
+	Qlen       uint64
+}
+
+// GetNetlinkQdiscs reads the qdisc statistics of all network devices via rtnetlink, grouped by interface index.
+// The kernel dumps the qdiscs of all devices for any request, so they are read once for all devices.
+func GetNetlinkQdiscs() (map[int][]NetlinkTCStat, error) {
+	stats, err := getNetlinkTC(0, rtmGetQdisc, rtmNewQdisc, false)
+	qdiscs := make(map[int][]NetlinkTCStat)
+	for _, stat := range stats {
+		qdiscs[stat.Ifindex] = append(qdiscs[stat.Ifindex], stat)
+	}
+	return qdiscs, err
+}
+
+// GetNetlinkClasses reads the tc class statistics of the network device with the interface index via rtnetlink
+func GetNetlinkClasses(ifindex int) ([]NetlinkTCStat, error) {
+	return getNetlinkTC(ifindex, rtmGetTClass, rtmNewTClass, true)
+}
+
+// getNetlinkTC dumps the qdiscs or classes of the device with the interface index, of all devices for 0
+func getNetlinkTC(ifindex int, requestType uint16, responseType uint16, class bool) ([]NetlinkTCStat, error) {
+	stats := []NetlinkTCStat{}
+
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return stats, fmt.Errorf("cannot open netlink socket: %v", err)
+	}
+	defer syscall.Close(fd)
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return stats, fmt.Errorf("cannot bind netlink socket: %v", err)
+	}
+
+	// request: nlmsghdr followed by tcmsg with the interface index set
+	request := make([]byte, syscall.NLMSG_HDRLEN+sizeofTcMsg)
+	nativeEndian.PutUint32(request[0:4], uint32(len(request)))
+	nativeEndian.PutUint16(request[4:6], requestType)
+	nativeEndian.PutUint16(request[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	nativeEndian.PutUint32(request[8:12], 1)
+	request[syscall.NLMSG_HDRLEN] = syscall.AF_UNSPEC
+	nativeEndian.PutUint32(request[syscall.NLMSG_HDRLEN+4:syscall.NLMSG_HDRLEN+8], uint32(ifindex))
+	if err := syscall.Sendto(fd, request, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return stats, fmt.Errorf("cannot send netlink request: %v", err)
+	}
+
+	buffer := make([]byte, os.Getpagesize()*4)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buffer, 0)
+		if err != nil {
+			return stats, fmt.Errorf("cannot read netlink response: %v", err)
+		}
+		messages, err := syscall.ParseNetlinkMessage(buffer[:n])
+		if err != nil {
+			return stats, fmt.Errorf("cannot parse netlink response: %v", err)
+		}
+		for _, message := range messages {
+			switch message.Header.Type {
+			case syscall.NLMSG_DONE:
+				return stats, nil
+			case syscall.NLMSG_ERROR:
+				if err := netlinkError(message.Data); err != nil {
+					return stats, fmt.Errorf("netlink request failed: %v", err)
+				}
+				return stats, nil
+			case responseType:
+				if stat, ok := parseTCMessage(message.Data); ok && (ifindex == 0 || stat.Ifindex == ifindex) {
+					stat.Class = class
+					stats = append(stats, stat)
+				}
+			}
+		}
+	}
+}
+
+// netlinkError returns the error of a NLMSG_ERROR message: struct nlmsgerr { int error; ... },
+// the negative errno or 0 for an acknowledgement
+func netlinkError(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("truncated error message")
+	}
+	if errno := int32(nativeEndian.Uint32(data[0:4])); errno != 0 {
+		return syscall.Errno(-errno)
+	}
+	return nil
+}
+
+// parseTCMessage parses a tcmsg and its attributes
+func parseTCMessage(data []byte) (NetlinkTCStat, bool) {
+	stat := NetlinkTCStat{}
+	if len(data) < sizeofTcMsg {
+		return stat, false
+	}
+	stat.Ifindex = int(int32(nativeEndian.Uint32(data[4:8])))
+	stat.Handle = nativeEndian.Uint32(data[8:12])
+	stat.Parent = nativeEndian.Uint32(data[12:16])
+
+	hasStats2 := false
+	for attrType, value := range parseNetlinkAttributes(data[sizeofTcMsg:]) {
+		switch attrType {
+		case tcaKind:
+			stat.Kind = cString(value)
+		case tcaStats2:
+			hasStats2 = true
+			for statsType, statsValue := range parseNetlinkAttributes(value) {
+				switch statsType {
+				case tcaStatsBasic:
+					// struct gnet_stats_basic { __u64 bytes; __u32 packets; }
+					if len(statsValue) >= 12 {
+						stat.Bytes = nativeEndian.Uint64(statsValue[0:8])
+						if stat.Packets == 0 {
+							stat.Packets = uint64(nativeEndian.Uint32(statsValue[8:12]))
+						}
+					}
+				case tcaStatsPkt64:
+					if len(statsValue) >= 8 {
+						stat.Packets = nativeEndian.Uint64(statsValue[0:8])
+					}
+				case tcaStatsQueue:
+					// struct gnet_stats_queue { qlen, backlog, drops, requeues, overlimits }
+					if len(statsValue) >= 20 {
+						stat.Qlen = uint64(nativeEndian.Uint32(statsValue[0:4]))
+						stat.Backlog = uint64(nativeEndian.Uint32(statsValue[4:8]))
+						stat.Drops = uint64(nativeEndian.Uint32(statsValue[8:12]))
+						stat.Requeues = uint64(nativeEndian.Uint32(statsValue[12:16]))
+						stat.Overlimits = uint64(nativeEndian.Uint32(statsValue[16:20]))
+					}
+				}
+			}
+		}
+	}
+
+	// fall back to the legacy struct tc_stats on old kernels
+	if value, ok := parseNetlinkAttributes(data[sizeofTcMsg:])[tcaStats]; ok && !hasStats2 && len(value) >= 36 {
+		stat.Bytes = nativeEndian.Uint64(value[0:8])
+		stat.Packets = uint64(nativeEndian.Uint32(value[8:12]))
+		stat.Drops = uint64(nativeEndian.Uint32(value[12:16]))
+		stat.Overlimits = uint64(nativeEndian.Uint32(value[16:20]))
+		stat.Qlen = uint64(nativeEndian.Uint32(value[28:32]))
+		stat.Backlog = uint64(nativeEndian.Uint32(value[32:36]))
+	}
+
+	return stat, true
+}
+
+// parseNetlinkAttributes splits a buffer of rtattr structs into a map of type -> payload
+func parseNetlinkAttributes(data []byte) map[uint16][]byte {
+	attributes := make(map[uint16][]byte)
+	for len(data) >= syscall.SizeofRtAttr {
+		length := int(nativeEndian.Uint16(data[0:2]))
+		attrType := nativeEndian.Uint16(data[2:4]) & 0x3fff // strip NLA_F_NESTED and NLA_F_NET_BYTEORDER
+		if length < syscall.SizeofRtAttr || length > len(data) {
+			break
+		}
+		attributes[attrType] = data[syscall.SizeofRtAttr:length]
+		aligned := (length + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if aligned > len(data) {
+			break
+		}
+		data = data[aligned:]
+	}
+	return attributes
+}
+
+func cString(value []byte) string {
+	for i, b := range value {
+		if b == 0 {
+			return string(value[:i])
+		}
+	}
+	return string(value)
+}
@@ -0,0 +1,109 @@
+package util
+
+import (
+	"syscall"
+	"testing"
+)
+
+// rtattr returns an attribute with its header, padded to the netlink alignment
+func rtattr(attrType uint16, payload []byte) []byte {
+	length := syscall.SizeofRtAttr + len(payload)
+	attribute := make([]byte, (length+syscall.RTA_ALIGNTO-1)&^(syscall.RTA_ALIGNTO-1))
+	nativeEndian.PutUint16(attribute[0:2], uint16(length))
+	nativeEndian.PutUint16(attribute[2:4], attrType)
+	copy(attribute[syscall.SizeofRtAttr:], payload)
+	return attribute
+}
+
+// tcmsg returns a tcmsg of the interface followed by the attributes
+func tcmsg(ifindex int, handle uint32, parent uint32, attributes ...[]byte) []byte {
+	message := make([]byte, sizeofTcMsg)
+	nativeEndian.PutUint32(message[4:8], uint32(ifindex))
+	nativeEndian.PutUint32(message[8:12], handle)
+	nativeEndian.PutUint32(message[12:16], parent)
+	for _, attribute := range attributes {
+		message = append(message, attribute...)
+	}
+	return message
+}
+
+// uint32s encodes values in host byte order
+func uint32s(values ...uint32) []byte {
+	encoded := make([]byte, 4*len(values))
+	for i, value := range values {
+		nativeEndian.PutUint32(encoded[4*i:], value)
+	}
+	return encoded
+}
+
+// statsBasic encodes struct gnet_stats_basic
+func statsBasic(bytes uint64, packets uint32) []byte {
+	encoded := make([]byte, 16)
+	nativeEndian.PutUint64(encoded[0:8], bytes)
+	nativeEndian.PutUint32(encoded[8:12], packets)
+	return encoded
+}
+
+func TestParseTCMessage(t *testing.T) {
+	packets64 := make([]byte, 8)
+	nativeEndian.PutUint64(packets64, 1<<33)
+	legacy := append(statsBasic(1000, 10)[:12], uint32s(1, 2, 0, 0, 3, 4)...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want NetlinkTCStat
+		ok   bool
+	}{
+		{"truncated tcmsg", make([]byte, sizeofTcMsg-1), NetlinkTCStat{}, false},
+		{"no attributes", tcmsg(3, 0x10000, TCParentRoot), NetlinkTCStat{Ifindex: 3, Handle: 0x10000, Parent: TCParentRoot}, true},
+		{"stats2", tcmsg(3, 0x10000, TCParentRoot,
+			rtattr(tcaKind, []byte("htb\x00")),
+			rtattr(tcaStats2, append(rtattr(tcaStatsBasic, statsBasic(5000, 50)), rtattr(tcaStatsQueue, uint32s(7, 800, 5, 2, 9))...))),
+			NetlinkTCStat{Ifindex: 3, Kind: "htb", Handle: 0x10000, Parent: TCParentRoot, Bytes: 5000, Packets: 50, Qlen: 7, Backlog: 800, Drops: 5, Requeues: 2, Overlimits: 9}, true},
+		{"64 bit packets", tcmsg(4, 0, TCParentIngress,
+			rtattr(tcaKind, []byte("ingress\x00")),
+			rtattr(tcaStats2|syscall.NLA_F_NESTED, append(rtattr(tcaStatsPkt64, packets64), rtattr(tcaStatsBasic, statsBasic(1<<40, 12))...))),
+			NetlinkTCStat{Ifindex: 4, Kind: "ingress", Parent: TCParentIngress, Bytes: 1 << 40, Packets: 1 << 33}, true},
+		{"legacy tc_stats", tcmsg(5, 0, TCParentRoot, rtattr(tcaKind, []byte("pfifo_fast")), rtattr(tcaStats, legacy)),
+			NetlinkTCStat{Ifindex: 5, Kind: "pfifo_fast", Parent: TCParentRoot, Bytes: 1000, Packets: 10, Drops: 1, Overlimits: 2, Qlen: 3, Backlog: 4}, true},
+		{"stats2 before legacy", tcmsg(5, 0, TCParentRoot, rtattr(tcaStats, legacy), rtattr(tcaStats2, rtattr(tcaStatsBasic, statsBasic(2000, 20)))),
+			NetlinkTCStat{Ifindex: 5, Parent: TCParentRoot, Bytes: 2000, Packets: 20}, true},
+		{"short queue stats", tcmsg(6, 0, 0, rtattr(tcaStats2, rtattr(tcaStatsQueue, uint32s(1, 2)))),
+			NetlinkTCStat{Ifindex: 6}, true},
+		{"truncated attribute", append(tcmsg(7, 0, 0, rtattr(tcaKind, []byte("sfq\x00"))), 0xff, 0x00, 0x03, 0x00),
+			NetlinkTCStat{Ifindex: 7, Kind: "sfq"}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := parseTCMessage(test.data)
+			if ok != test.ok || got != test.want {
+				t.Errorf("parseTCMessage = %+v, %v, want %+v, %v", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestNetlinkError(t *testing.T) {
+	// struct nlmsgerr holds the negative errno
+	negative := func(errno syscall.Errno) uint32 {
+		return uint32(-int32(errno))
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"acknowledgement", uint32s(0, 0), nil},
+		{"errno", uint32s(negative(syscall.EOPNOTSUPP), 0), syscall.EOPNOTSUPP},
+		{"permission", uint32s(negative(syscall.EPERM)), syscall.EPERM},
+	}
+	for _, test := range tests {
+		if got := netlinkError(test.data); got != test.want {
+			t.Errorf("%s: netlinkError = %v, want %v", test.name, got, test.want)
+		}
+	}
+	if err := netlinkError([]byte{1, 2}); err == nil {
+		t.Error("netlinkError of a truncated message succeeded, want error")
+	}
+}
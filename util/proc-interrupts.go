@@ -0,0 +1,138 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"proxtop/config"
+)
+
+// ProcInterrupt describes one row in /proc/interrupts
+type ProcInterrupt struct {
+	// IRQ number or name of the architecture specific interrupt (e.g. LOC, RES)
+	IRQ string
+	// Interrupt count per CPU, indexed by CPU number
+	Counts map[int]uint64
+	// Device (action) name for numbered IRQs, description otherwise
+	Device string
+}
+
+// ProcSoftIRQ describes one row in /proc/softirqs
+type ProcSoftIRQ struct {
+	// Softirq name (e.g. NET_RX, NET_TX, BLOCK)
+	Name string
+	// Softirq count per CPU, indexed by CPU number
+	Counts map[int]uint64
+}
+
+// vfio-msix[0](0000:01:00.0), vfio-msi[0](0000:01:00.0), vfio-intx(0000:01:00.0)
+var vfioInterruptRegexp = regexp.MustCompile(`^vfio-[a-z]+(?:\[\d+\])?\((.+)\)$`)
+
+// GetVFIOInterruptAddress returns the PCI address of a passed through device from the
+// interrupt's device name, or an empty string if the interrupt is not a vfio interrupt
+func GetVFIOInterruptAddress(device string) string {
+	if match := vfioInterruptRegexp.FindStringSubmatch(device); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// GetProcInterrupts reads and returns the rows of /proc/interrupts
+func GetProcInterrupts() []ProcInterrupt {
+	interrupts := []ProcInterrupt{}
+	cpus, rows := readProcPerCPUFile(fmt.Sprint(config.Options.ProcFS, "/interrupts"))
+	for _, row := range rows {
+		name, counts, rest := parsePerCPURow(row, cpus)
+		if name == "" {
+			continue
+		}
+		interrupt := ProcInterrupt{
+			IRQ:    name,
+			Counts: counts,
+		}
+		if _, err := strconv.Atoi(name); err == nil && len(rest) > 2 {
+			// numbered IRQs: <chip> <hwirq-type> <device[, device]>
+			interrupt.Device = strings.Join(rest[2:], " ")
+		} else {
+			interrupt.Device = strings.Join(rest, " ")
+		}
+		interrupts = append(interrupts, interrupt)
+	}
+	return interrupts
+}
+
+// GetProcSoftIRQs reads and returns the rows of /proc/softirqs
+func GetProcSoftIRQs() []ProcSoftIRQ {
+	softirqs := []ProcSoftIRQ{}
+	cpus, rows := readProcPerCPUFile(fmt.Sprint(config.Options.ProcFS, "/softirqs"))
+	for _, row := range rows {
+		name, counts, _ := parsePerCPURow(row, cpus)
+		if name == "" {
+			continue
+		}
+		softirqs = append(softirqs, ProcSoftIRQ{
+			Name:   name,
+			Counts: counts,
+		})
+	}
+	return softirqs
+}
+
+// readProcPerCPUFile reads a file with a "CPU0 CPU1 ..." header line and returns
+// the CPU numbers of the columns and the remaining rows
+func readProcPerCPUFile(filepath string) ([]int, []string) {
+	cpus := []int{}
+	rows := []string{}
+
+	file, err := os.Open(filepath)
+	if err != nil {
+		// cannot open file ...
+		return cpus, rows
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // lines grow with the number of CPUs
+	scanner.Split(bufio.ScanLines)
+
+	if !scanner.Scan() {
+		return cpus, rows
+	}
+	for _, column := range strings.Fields(scanner.Text()) {
+		cpu, err := strconv.Atoi(strings.TrimPrefix(column, "CPU"))
+		if err != nil {
+			continue
+		}
+		cpus = append(cpus, cpu)
+	}
+
+	for scanner.Scan() {
+		rows = append(rows, scanner.Text())
+	}
+	return cpus, rows
+}
+
+// parsePerCPURow splits a row into its name, the per CPU counters and the remaining fields
+func parsePerCPURow(row string, cpus []int) (string, map[int]uint64, []string) {
+	counts := make(map[int]uint64)
+	colon := strings.Index(row, ":")
+	if colon < 0 {
+		return "", counts, nil
+	}
+	name := strings.TrimSpace(row[:colon])
+	fields := strings.Fields(row[colon+1:])
+
+	i := 0
+	for ; i < len(fields) && i < len(cpus); i++ {
+		count, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			// rows like ERR and MIS have a single counter only
+			break
+		}
+		counts[cpus[i]] = count
+	}
+	return name, counts, fields[i:]
+}
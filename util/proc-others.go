@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
 	"strconv"
+	"strings"
 
 	"proxtop/config"
 )
@@ -37,3 +39,18 @@ func GetProcessList() []int {
 
 	return processes
 }
+
+// GetSelfRSS returns the resident set size of proxtop in bytes. Reads /proc/self, as --procfs may
+// belong to another PID namespace.
+func GetSelfRSS() uint64 {
+	filecontent, err := ioutil.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(filecontent))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, _ := strconv.ParseUint(fields[1], 10, 64)
+	return pages * uint64(os.Getpagesize())
+}
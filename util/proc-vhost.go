@@ -0,0 +1,46 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"proxtop/config"
+)
+
+// GetProcPIDComm reads the command name of a process or thread from /proc/<pid>/comm
+func GetProcPIDComm(pid int) string {
+	filepath := fmt.Sprint(config.Options.ProcFS, "/", strconv.Itoa(pid), "/comm")
+	filecontent, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(filecontent))
+}
+
+// GetVhostOwner returns the owner PID of a vhost worker by its comm "vhost-<owner pid>",
+// or 0 if the comm does not belong to a vhost worker
+func GetVhostOwner(comm string) int {
+	if !strings.HasPrefix(comm, "vhost-") {
+		return 0
+	}
+	owner, err := strconv.Atoi(strings.TrimPrefix(comm, "vhost-"))
+	if err != nil {
+		return 0
+	}
+	return owner
+}
+
+// GetVhostWorkers returns the vhost kernel worker threads mapped by the PID of the owning QEMU process
+func GetVhostWorkers() map[int][]int {
+	workers := make(map[int][]int)
+	for _, pid := range GetProcessList() {
+		owner := GetVhostOwner(GetProcPIDComm(pid))
+		if owner == 0 {
+			continue
+		}
+		workers[owner] = append(workers[owner], pid)
+	}
+	return workers
+}
@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 )
 
 // SysCPU reflects cpu system information from /sys/devices/system/cpu/cpu*
 type SysCPU struct {
+	// logical CPU number (from the cpu<N> directory name)
+	CPU     int
 	MaxFreq float32
 	MinFreq float32
 	CurFreq float32
@@ -29,6 +32,7 @@ func GetSysCPU() []SysCPU {
 	var filecontent []byte
 	for _, f := range files {
 		cpuStat := SysCPU{}
+		fmt.Sscanf(path.Base(f), "cpu%d", &cpuStat.CPU)
 
 		filepath = fmt.Sprint(f + "/cpufreq/cpuinfo_max_freq")
 		filecontent, _ = ioutil.ReadFile(filepath)
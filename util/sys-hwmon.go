@@ -0,0 +1,49 @@
+package util
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// SysHwmonSensor reflects one temperature or fan sensor from /sys/class/hwmon
+type SysHwmonSensor struct {
+	// Chip (driver) name, e.g. coretemp, k10temp, nct6775
+	Chip string
+	// Sensor label, e.g. "Package id 0", "Tctl", or the input name if there is no label
+	Label string
+	// Sensor type: temp or fan
+	Type string
+	// Temperature in degree Celsius or fan speed in RPM
+	Value float64
+}
+
+// GetSysHwmonSensors returns the temperature and fan sensors of all hwmon chips
+func GetSysHwmonSensors() []SysHwmonSensor {
+	sensors := []SysHwmonSensor{}
+	chips, _ := filepath.Glob("/sys/class/hwmon/hwmon*")
+	for _, chip := range chips {
+		chipName := readSysString(chip + "/name")
+		for _, sensorType := range []string{"temp", "fan"} {
+			inputs, _ := filepath.Glob(chip + "/" + sensorType + "*_input")
+			for _, input := range inputs {
+				base := strings.TrimSuffix(input, "_input")
+				label := readSysString(base + "_label")
+				if label == "" {
+					label = filepath.Base(base)
+				}
+				value := float64(readSysUint64(input))
+				if sensorType == "temp" {
+					// millidegree Celsius
+					value = value / 1000
+				}
+				sensors = append(sensors, SysHwmonSensor{
+					Chip:  chipName,
+					Label: label,
+					Type:  sensorType,
+					Value: value,
+				})
+			}
+		}
+	}
+	return sensors
+}
@@ -0,0 +1,20 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+)
+
+// GetSysNetIfindex reads the interface index from /sys/class/net/$iface/ifindex, 0 if the device does not exist.
+// A device recreated with the same name (e.g. the tap of a restarted VM) gets a new index.
+func GetSysNetIfindex(devName string) uint64 {
+	var ifindex uint64
+	filepath := fmt.Sprint("/sys/class/net/" + devName + "/ifindex")
+	filecontent, _ := ioutil.ReadFile(filepath)
+	fmt.Fscan(
+		bytes.NewBuffer(filecontent),
+		&ifindex,
+	)
+	return ifindex
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SysPCIDevice reflects a PCI device from /sys/bus/pci/devices/<address>
+type SysPCIDevice struct {
+	// PCI address (domain:bus:slot.function), e.g. 0000:01:00.0
+	Address string
+	// Bound driver, e.g. vfio-pci, empty if no driver is bound
+	Driver string
+	// IOMMU group number, -1 if the IOMMU is disabled
+	IOMMUGroup int
+	// NUMA node the device is attached to, -1 if unknown
+	NUMANode int
+	// PCI class code, e.g. 0x020000
+	Class string
+	// Vendor and device ID, e.g. 8086:1521
+	ID string
+}
+
+// pciClasses maps the PCI base class to a short name
+var pciClasses = map[string]string{
+	"01": "storage",
+	"02": "network",
+	"03": "display",
+	"04": "multimedia",
+	"0c": "serial",
+	"12": "accel",
+}
+
+// GetSysPCIDevice reads the driver, IOMMU group, NUMA node and IDs of a PCI device
+func GetSysPCIDevice(address string) SysPCIDevice {
+	dir := fmt.Sprint("/sys/bus/pci/devices/", address)
+	device := SysPCIDevice{
+		Address:    address,
+		IOMMUGroup: -1,
+		NUMANode:   -1,
+		Class:      readSysString(dir + "/class"),
+	}
+	if driver, err := os.Readlink(dir + "/driver"); err == nil {
+		device.Driver = filepath.Base(driver)
+	}
+	if group, err := os.Readlink(dir + "/iommu_group"); err == nil {
+		if number, err := strconv.Atoi(filepath.Base(group)); err == nil {
+			device.IOMMUGroup = number
+		}
+	}
+	if node, err := strconv.Atoi(readSysString(dir + "/numa_node")); err == nil {
+		device.NUMANode = node
+	}
+	vendor := strings.TrimPrefix(readSysString(dir+"/vendor"), "0x")
+	product := strings.TrimPrefix(readSysString(dir+"/device"), "0x")
+	if vendor != "" {
+		device.ID = vendor + ":" + product
+	}
+	return device
+}
+
+// ClassName returns a short name of the device class (network, storage, display, ...)
+func (device SysPCIDevice) ClassName() string {
+	class := strings.TrimPrefix(device.Class, "0x")
+	if len(class) < 2 {
+		return "unknown"
+	}
+	if name, ok := pciClasses[class[:2]]; ok {
+		return name
+	}
+	return "other"
+}
+
+// ExpandPCIAddress normalizes a PCI ID as used in VM configs (01:00.0, 0000:01:00.0)
+// to full addresses. IDs without function (01:00) stand for all functions of the device.
+func ExpandPCIAddress(id string) []string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if strings.Count(id, ":") == 1 {
+		id = "0000:" + id
+	}
+	if strings.Contains(id, ".") {
+		return []string{id}
+	}
+	addresses := []string{}
+	functions, _ := filepath.Glob(fmt.Sprint("/sys/bus/pci/devices/", id, ".*"))
+	for _, function := range functions {
+		addresses = append(addresses, filepath.Base(function))
+	}
+	return addresses
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// SysEnergyZone reflects one energy counter of the CPU (RAPL powercap zone or amd_energy hwmon channel)
+type SysEnergyZone struct {
+	// Zone name, kind and socket (e.g. package0, dram0, core0, uncore0, psys)
+	Name string
+	// Zone kind: package, dram, core, uncore or psys
+	Kind string
+	// Energy counter in microjoules
+	EnergyUJ uint64
+	// Value at which the energy counter wraps around, 0 if it does not wrap
+	MaxEnergyRangeUJ uint64
+}
+
+// GetSysEnergyZones returns the RAPL energy counters from /sys/class/powercap/intel-rapl*
+// (Intel and AMD since Linux 5.11) and falls back to the amd_energy hwmon driver
+func GetSysEnergyZones() []SysEnergyZone {
+	zones := getPowercapZones()
+	if len(zones) == 0 {
+		zones = getAMDEnergyZones()
+	}
+	return zones
+}
+
+// getPowercapZones reads intel-rapl:<socket> and intel-rapl:<socket>:<subzone> zones
+func getPowercapZones() []SysEnergyZone {
+	zones := []SysEnergyZone{}
+	dirs, _ := filepath.Glob("/sys/class/powercap/intel-rapl:*")
+	for _, dir := range dirs {
+		var socket int
+		if _, err := fmt.Sscanf(filepath.Base(dir), "intel-rapl:%d", &socket); err != nil {
+			continue
+		}
+
+		name := readSysString(dir + "/name")
+		var kind string
+		switch {
+		case strings.HasPrefix(name, "package"):
+			// package-<n>
+			kind = "package"
+			fmt.Sscanf(name, "package-%d", &socket)
+		case name == "dram" || name == "core" || name == "uncore" || name == "psys":
+			kind = name
+		default:
+			continue
+		}
+
+		zone := SysEnergyZone{
+			Name:             fmt.Sprintf("%s%d", kind, socket),
+			Kind:             kind,
+			EnergyUJ:         readSysUint64(dir + "/energy_uj"),
+			MaxEnergyRangeUJ: readSysUint64(dir + "/max_energy_range_uj"),
+		}
+		if kind == "psys" {
+			// platform domain, not bound to a socket
+			zone.Name = kind
+		}
+		zones = append(zones, zone)
+	}
+	return zones
+}
+
+// getAMDEnergyZones reads the socket counters (label Esocket<n>) of the amd_energy hwmon driver,
+// the driver accumulates the counters to 64 bit so they do not wrap around
+func getAMDEnergyZones() []SysEnergyZone {
+	zones := []SysEnergyZone{}
+	chips, _ := filepath.Glob("/sys/class/hwmon/hwmon*")
+	for _, chip := range chips {
+		if readSysString(chip+"/name") != "amd_energy" {
+			continue
+		}
+		inputs, _ := filepath.Glob(chip + "/energy*_input")
+		for _, input := range inputs {
+			var socket int
+			label := readSysString(strings.TrimSuffix(input, "_input") + "_label")
+			if _, err := fmt.Sscanf(label, "Esocket%d", &socket); err != nil {
+				// per core counters are not needed
+				continue
+			}
+			zones = append(zones, SysEnergyZone{
+				Name:     fmt.Sprintf("package%d", socket),
+				Kind:     "package",
+				EnergyUJ: readSysUint64(input),
+			})
+		}
+	}
+	return zones
+}
+
+func readSysString(filepath string) string {
+	filecontent, _ := ioutil.ReadFile(filepath)
+	return strings.TrimSpace(string(filecontent))
+}
+
+func readSysUint64(filepath string) uint64 {
+	var value uint64
+	filecontent, _ := ioutil.ReadFile(filepath)
+	fmt.Fscan(
+		bytes.NewBuffer(filecontent),
+		&value,
+	)
+	return value
+}